@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-olive/olive/app/services/olive-api/handlers/debug/checkgrp"
 	v1 "github.com/go-olive/olive/app/services/olive-api/handlers/v1"
+	"github.com/go-olive/olive/business/core/cluster"
 	"github.com/go-olive/olive/business/web/v1/mid"
 	"github.com/go-olive/olive/engine/kernel"
 	"github.com/go-olive/olive/foundation/web"
@@ -60,6 +61,7 @@ type APIMuxConfig struct {
 	Log      *zap.SugaredLogger
 	DB       *sqlx.DB
 	K        *kernel.Kernel
+	Cluster  *cluster.Cluster
 }
 
 // APIMux constructs an http.Handler with all application routes defined.
@@ -75,9 +77,10 @@ func APIMux(cfg APIMuxConfig) *web.App {
 
 	// Load the v1 routes.
 	v1.Routes(app, v1.Config{
-		Log: cfg.Log,
-		DB:  cfg.DB,
-		K:   cfg.K,
+		Log:     cfg.Log,
+		DB:      cfg.DB,
+		K:       cfg.K,
+		Cluster: cfg.Cluster,
 	})
 
 	return app
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/go-olive/olive/business/core/cluster"
 	"github.com/go-olive/olive/business/core/config"
 	v1Web "github.com/go-olive/olive/business/web/v1"
 	"github.com/go-olive/olive/business/web/v1/mid"
@@ -16,8 +17,9 @@ import (
 
 // Handlers manages the set of config endpoints.
 type Handlers struct {
-	Config config.Core
-	K      *kernel.Kernel
+	Config  config.Core
+	K       *kernel.Kernel
+	Cluster *cluster.Cluster
 }
 
 // Create adds a new config to the system.
@@ -65,7 +67,14 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	h.K.UpdateConfig(configKey, *upd.Value)
+	// every node of a cluster reloads the config, this one included.
+	if h.Cluster != nil {
+		if err := h.Cluster.Publish(ctx, cluster.Message{Op: cluster.OpConfig, Key: configKey}); err != nil {
+			return fmt.Errorf("config key[%s]: publish: %w", configKey, err)
+		}
+	} else {
+		h.K.UpdateConfig(configKey, *upd.Value)
+	}
 
 	return mid.Respond(ctx, w, nil, http.StatusOK)
 }
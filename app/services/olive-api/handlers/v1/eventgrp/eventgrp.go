@@ -0,0 +1,173 @@
+// Package eventgrp maintains the group of handlers for the stream of engine
+// events.
+package eventgrp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1Web "github.com/go-olive/olive/business/web/v1"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/web"
+)
+
+const (
+	// subscribeSize is the number of events a client may lag behind before
+	// events are dropped.
+	subscribeSize = 256
+	// pingInterval keeps idle streams open through proxies, and finds out
+	// the clients which left.
+	pingInterval = 15 * time.Second
+)
+
+// Handlers manages the set of event endpoints.
+type Handlers struct {
+	K *kernel.Kernel
+}
+
+// Stream sends the events of the shows run by this node as server-sent
+// events, filtered by the show_id and type query parameters, both taking a
+// comma separated list. Clients resume after the Last-Event-ID header, or
+// the last_event_id query parameter, from the events still kept.
+func (h Handlers) Stream(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	epoch, lastID, err := lastEventID(r)
+	if err != nil {
+		return v1Web.NewRequestError(err, http.StatusBadRequest)
+	}
+	f := newFilter(r)
+
+	// subscribing first, the events published while replaying are not lost.
+	sub, unsubscribe := h.K.Subscribe(subscribeSize)
+	defer unsubscribe()
+	// an ID of a previous run is of another epoch, the client starts over.
+	backlog, lastID := h.K.Replay(epoch, lastID)
+	epoch = h.K.EventEpoch()
+
+	conn, sw, err := web.Stream(ctx, w)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// errors past this point only mean the client left.
+	bw := bufio.NewWriter(sw)
+	bw.WriteString("HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/event-stream\r\n" +
+		"Cache-Control: no-cache\r\n" +
+		"Access-Control-Allow-Origin: *\r\n" +
+		"Connection: close\r\n\r\n")
+	for _, e := range backlog {
+		if f.match(e) {
+			writeEvent(bw, epoch, e)
+		}
+		lastID = e.ID
+	}
+	if err := bw.Flush(); err != nil {
+		return nil
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e, ok := <-sub:
+			if !ok {
+				return nil
+			}
+			if e.ID <= lastID || !f.match(e) {
+				continue
+			}
+			lastID = e.ID
+			writeEvent(bw, epoch, e)
+		case <-ping.C:
+			bw.WriteString(": ping\n\n")
+		}
+		if err := bw.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// writeEvent writes the event, its ID prefixed with the epoch of the events
+// for clients to resume after it.
+func writeEvent(w *bufio.Writer, epoch string, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", epoch, e.ID, e.Type, data)
+}
+
+// lastEventID returns the epoch and ID of the last event the client
+// received, 0 if it is new. An ID without an epoch is of no epoch.
+func lastEventID(r *http.Request) (string, uint64, error) {
+	s := r.Header.Get("Last-Event-ID")
+	if s == "" {
+		s = r.URL.Query().Get("last_event_id")
+	}
+	if s == "" {
+		return "", 0, nil
+	}
+	var epoch string
+	n := s
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		epoch, n = s[:i], s[i+1:]
+	}
+	id, err := strconv.ParseUint(n, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("last event ID[%s] is not valid", s)
+	}
+	return epoch, id, nil
+}
+
+// filter selects the events of some shows or types, empty sets matching
+// every event.
+type filter struct {
+	showIDs map[string]bool
+	types   map[events.Type]bool
+}
+
+func newFilter(r *http.Request) filter {
+	f := filter{
+		showIDs: make(map[string]bool),
+		types:   make(map[events.Type]bool),
+	}
+	q := r.URL.Query()
+	for _, id := range splitList(q["show_id"]) {
+		f.showIDs[id] = true
+	}
+	for _, typ := range splitList(q["type"]) {
+		f.types[events.Type(typ)] = true
+	}
+	return f
+}
+
+func (f filter) match(e events.Event) bool {
+	if len(f.showIDs) > 0 && !f.showIDs[e.ShowID] {
+		return false
+	}
+	if len(f.types) > 0 && !f.types[e.Type] {
+		return false
+	}
+	return true
+}
+
+// splitList splits the comma separated values.
+func splitList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,169 @@
+// Package groupgrp maintains the group of handlers for show group access.
+package groupgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-olive/olive/business/core/cluster"
+	"github.com/go-olive/olive/business/core/group"
+	"github.com/go-olive/olive/business/core/show"
+	v1Web "github.com/go-olive/olive/business/web/v1"
+	"github.com/go-olive/olive/business/web/v1/mid"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/web"
+)
+
+// Handlers manages the set of show group endpoints.
+type Handlers struct {
+	Group   group.Core
+	Show    show.Core
+	K       *kernel.Kernel
+	Cluster *cluster.Cluster
+}
+
+// Create adds a new group to the system.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var newGroup group.NewGroup
+	if err := web.Decode(r, &newGroup); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	g, err := h.Group.Create(ctx, newGroup, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, group.ErrInvalidPostCmds),
+			errors.Is(err, group.ErrInvalidSplitRule),
+			errors.Is(err, group.ErrInvalidOutTmpl),
+			errors.Is(err, group.ErrInvalidSaveDir):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("group[%+v]: %w", &newGroup, err)
+		}
+	}
+
+	return mid.Respond(ctx, w, g, http.StatusCreated)
+}
+
+// Update updates a group in the system, its shows picking up the new
+// defaults.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var upd group.UpdateGroup
+	if err := web.Decode(r, &upd); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	groupID := web.Param(r, "id")
+
+	if err := h.Group.Update(ctx, groupID, upd, v.Now); err != nil {
+		switch {
+		case errors.Is(err, group.ErrInvalidID),
+			errors.Is(err, group.ErrInvalidPostCmds),
+			errors.Is(err, group.ErrInvalidSplitRule),
+			errors.Is(err, group.ErrInvalidOutTmpl),
+			errors.Is(err, group.ErrInvalidSaveDir):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, group.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s] Group[%+v]: %w", groupID, &upd, err)
+		}
+	}
+
+	members, err := h.Show.QueryBySelector(ctx, show.Selector{GroupID: groupID})
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", groupID, err)
+	}
+	if err := h.handle(ctx, members); err != nil {
+		return fmt.Errorf("ID[%s]: %w", groupID, err)
+	}
+
+	return mid.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// Delete removes a group from the system, its shows leaving it.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	groupID := web.Param(r, "id")
+
+	// the shows are applied again once they left the group.
+	members, err := h.Show.QueryBySelector(ctx, show.Selector{GroupID: groupID})
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", groupID, err)
+	}
+
+	if err := h.Group.Delete(ctx, groupID); err != nil {
+		switch {
+		case errors.Is(err, group.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("ID[%s]: %w", groupID, err)
+		}
+	}
+
+	if err := h.handle(ctx, members); err != nil {
+		return fmt.Errorf("ID[%s]: %w", groupID, err)
+	}
+
+	return mid.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// Query returns every group.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	groups, err := h.Group.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query for groups: %w", err)
+	}
+
+	return mid.Respond(ctx, w, groups, http.StatusOK)
+}
+
+// QueryByID returns a group by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	groupID := web.Param(r, "id")
+
+	g, err := h.Group.QueryByID(ctx, groupID)
+	if err != nil {
+		switch {
+		case errors.Is(err, group.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, group.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", groupID, err)
+		}
+	}
+
+	return mid.Respond(ctx, w, g, http.StatusOK)
+}
+
+// handle applies the shows, with the defaults of their groups, to the
+// kernel, or, in a cluster, has the nodes holding them apply them.
+func (h Handlers) handle(ctx context.Context, shows []show.Show) error {
+	for _, s := range shows {
+		if h.Cluster != nil {
+			if err := h.Cluster.Publish(ctx, cluster.Message{Op: cluster.OpUpdate, ShowID: s.ID}); err != nil {
+				return fmt.Errorf("publish: %w", err)
+			}
+			continue
+		}
+
+		s, err := h.Show.QueryEffectiveByID(ctx, s.ID)
+		if err != nil {
+			return err
+		}
+		h.K.HandleShow(s)
+	}
+	return nil
+}
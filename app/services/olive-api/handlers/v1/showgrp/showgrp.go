@@ -5,9 +5,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-olive/olive/business/core/cluster"
 	"github.com/go-olive/olive/business/core/show"
 	v1Web "github.com/go-olive/olive/business/web/v1"
 	"github.com/go-olive/olive/business/web/v1/mid"
@@ -17,8 +21,9 @@ import (
 
 // Handlers manages the set of show endpoints.
 type Handlers struct {
-	Show show.Core
-	K    *kernel.Kernel
+	Show    show.Core
+	K       *kernel.Kernel
+	Cluster *cluster.Cluster
 }
 
 // Create adds a new show to the system.
@@ -35,10 +40,24 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	s, err := h.Show.Create(ctx, newShow, v.Now)
 	if err != nil {
-		return fmt.Errorf("show[%+v]: %w", &s, err)
+		switch {
+		case errors.Is(err, show.ErrInvalidPostCmds),
+			errors.Is(err, show.ErrInvalidSplitRule),
+			errors.Is(err, show.ErrInvalidRelay),
+			errors.Is(err, show.ErrInvalidSchedule),
+			errors.Is(err, show.ErrInvalidFilter),
+			errors.Is(err, show.ErrInvalidOutTmpl),
+			errors.Is(err, show.ErrInvalidSaveDir),
+			errors.Is(err, show.ErrInvalidProfile):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("show[%+v]: %w", &newShow, err)
+		}
 	}
 
-	h.K.HandleShow(s)
+	if err := h.handle(ctx, s.ID); err != nil {
+		return fmt.Errorf("show[%+v]: %w", &s, err)
+	}
 
 	return mid.Respond(ctx, w, s, http.StatusCreated)
 }
@@ -60,7 +79,13 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 	if err := h.Show.Update(ctx, showID, upd, v.Now); err != nil {
 		switch {
 		case errors.Is(err, show.ErrInvalidPostCmds),
-			errors.Is(err, show.ErrInvalidSplitRule):
+			errors.Is(err, show.ErrInvalidSplitRule),
+			errors.Is(err, show.ErrInvalidRelay),
+			errors.Is(err, show.ErrInvalidSchedule),
+			errors.Is(err, show.ErrInvalidFilter),
+			errors.Is(err, show.ErrInvalidOutTmpl),
+			errors.Is(err, show.ErrInvalidSaveDir),
+			errors.Is(err, show.ErrInvalidProfile):
 			return v1Web.NewRequestError(err, http.StatusBadRequest)
 		case errors.Is(err, show.ErrNotFound):
 			return v1Web.NewRequestError(err, http.StatusNotFound)
@@ -69,8 +94,9 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	s, _ := h.Show.QueryByID(ctx, showID)
-	h.K.HandleShow(s)
+	if err := h.handle(ctx, showID); err != nil {
+		return fmt.Errorf("ID[%s]: %w", showID, err)
+	}
 
 	return mid.Respond(ctx, w, nil, http.StatusOK)
 }
@@ -88,7 +114,9 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	h.K.HandleShow(kernel.Show{ID: showID, Enable: false})
+	if err := h.handle(ctx, strings.Split(showID, ",")...); err != nil {
+		return fmt.Errorf("ID[%s]: %w", showID, err)
+	}
 
 	return mid.Respond(ctx, w, nil, http.StatusOK)
 }
@@ -145,3 +173,211 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 
 	return web.Respond(ctx, w, s, http.StatusOK)
 }
+
+// BulkEnable enables the shows matching the selector.
+func (h Handlers) BulkEnable(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.bulk(ctx, w, r, func(ctx context.Context, p bulkPayload, now time.Time) ([]show.Show, error) {
+		return h.Show.BulkEnable(ctx, p.Selector, true, now)
+	})
+}
+
+// BulkDisable disables the shows matching the selector.
+func (h Handlers) BulkDisable(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.bulk(ctx, w, r, func(ctx context.Context, p bulkPayload, now time.Time) ([]show.Show, error) {
+		return h.Show.BulkEnable(ctx, p.Selector, false, now)
+	})
+}
+
+// BulkDelete removes the shows matching the selector.
+func (h Handlers) BulkDelete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.bulk(ctx, w, r, func(ctx context.Context, p bulkPayload, now time.Time) ([]show.Show, error) {
+		return h.Show.BulkDelete(ctx, p.Selector)
+	})
+}
+
+// BulkTag adds and removes tags to the shows matching the selector.
+func (h Handlers) BulkTag(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.bulk(ctx, w, r, func(ctx context.Context, p bulkPayload, now time.Time) ([]show.Show, error) {
+		return h.Show.BulkTag(ctx, p.Selector, p.Add, p.Remove, now)
+	})
+}
+
+// bulkPayload selects the shows of a bulk operation, with the tags to add
+// and remove when re-tagging.
+type bulkPayload struct {
+	show.Selector
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// bulk applies a bulk operation and responds with the shows affected.
+func (h Handlers) bulk(ctx context.Context, w http.ResponseWriter, r *http.Request, fn func(context.Context, bulkPayload, time.Time) ([]show.Show, error)) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var p bulkPayload
+	if err := web.Decode(r, &p); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	shows, err := fn(ctx, p, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, show.ErrEmptySelector),
+			errors.Is(err, show.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("selector[%+v]: %w", &p.Selector, err)
+		}
+	}
+
+	ids := make([]string, len(shows))
+	for i, s := range shows {
+		ids[i] = s.ID
+	}
+	if err := h.handle(ctx, ids...); err != nil {
+		return fmt.Errorf("selector[%+v]: %w", &p.Selector, err)
+	}
+
+	data := struct {
+		Total int         `json:"total"`
+		List  []show.Show `json:"list"`
+	}{
+		Total: len(shows),
+		List:  shows,
+	}
+
+	return mid.Respond(ctx, w, data, http.StatusOK)
+}
+
+// handle applies the shows, as stored with the defaults of their groups, to
+// the kernel, or, in a cluster, has the nodes holding them apply them.
+func (h Handlers) handle(ctx context.Context, showIDs ...string) error {
+	for _, showID := range showIDs {
+		if h.Cluster != nil {
+			if err := h.Cluster.Publish(ctx, cluster.Message{Op: cluster.OpUpdate, ShowID: showID}); err != nil {
+				return fmt.Errorf("publish: %w", err)
+			}
+			continue
+		}
+
+		s, err := h.Show.QueryEffectiveByID(ctx, showID)
+		switch {
+		case errors.Is(err, show.ErrNotFound),
+			errors.Is(err, show.ErrInvalidID):
+			s = kernel.Show{ID: showID, Enable: false}
+		case err != nil:
+			return err
+		}
+		h.K.HandleShow(s)
+	}
+	return nil
+}
+
+// Record starts recording a show now, whatever its live status.
+func (h Handlers) Record(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.control(ctx, w, r, cluster.Message{Op: cluster.OpRecord})
+}
+
+// StopRecord stops recording a show until its next live session, while
+// keeping it monitored. The skip is kept in memory only, the session being
+// recorded again if the node restarts during it.
+func (h Handlers) StopRecord(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.control(ctx, w, r, cluster.Message{Op: cluster.OpStopRecord})
+}
+
+// Pause suspends a show until the given time. The pause is kept in the
+// history file of the node, lasting across its restarts.
+func (h Handlers) Pause(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var p struct {
+		Until time.Time `json:"until"`
+	}
+	if err := web.Decode(r, &p); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+	if p.Until.IsZero() {
+		return v1Web.NewRequestError(errors.New("until is required"), http.StatusBadRequest)
+	}
+
+	return h.control(ctx, w, r, cluster.Message{Op: cluster.OpPause, Until: p.Until})
+}
+
+// Resume resumes a paused show.
+func (h Handlers) Resume(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.control(ctx, w, r, cluster.Message{Op: cluster.OpPause})
+}
+
+// control applies a record control to the show and responds with its
+// runtime status. In a cluster, a show held by another node is handed the
+// control, which is accepted without waiting for it.
+func (h Handlers) control(ctx context.Context, w http.ResponseWriter, r *http.Request, msg cluster.Message) error {
+	showID := web.Param(r, "id")
+	msg.ShowID = showID
+
+	if h.Cluster != nil && !h.Cluster.Owns(showID) {
+		node, err := h.Cluster.Owner(ctx, showID)
+		if err != nil {
+			if errors.Is(err, cluster.ErrNoOwner) {
+				return v1Web.NewRequestError(err, http.StatusNotFound)
+			}
+			return fmt.Errorf("ID[%s]: %w", showID, err)
+		}
+		if err := h.Cluster.Publish(ctx, msg); err != nil {
+			return fmt.Errorf("ID[%s]: publish: %w", showID, err)
+		}
+		return mid.Respond(ctx, w, node, http.StatusAccepted)
+	}
+
+	if err := msg.Apply(h.K); err != nil {
+		switch {
+		case errors.Is(err, kernel.ErrShowNotRunning):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", showID, err)
+		}
+	}
+
+	s, _ := h.K.ShowStatus(showID)
+	return mid.Respond(ctx, w, s, http.StatusOK)
+}
+
+// Live streams the recording in progress of a show as HTTP-FLV. Only the
+// recordings of the flv parser can be previewed.
+func (h Handlers) Live(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	showID := web.Param(r, "id")
+	stream, err := h.K.Preview(showID)
+	if err != nil && h.Cluster != nil && !h.Cluster.Owns(showID) {
+		if node, err := h.Cluster.Owner(ctx, showID); err == nil && node.APIHost != "" {
+			web.SetStatusCode(ctx, http.StatusTemporaryRedirect)
+			http.Redirect(w, r, "http://"+node.APIHost+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return nil
+		}
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, kernel.ErrPreviewUnsupported):
+			return v1Web.NewRequestError(fmt.Errorf("show[%s]: %w", showID, err), http.StatusNotImplemented)
+		default:
+			return v1Web.NewRequestError(fmt.Errorf("show[%s]: %w", showID, err), http.StatusNotFound)
+		}
+	}
+
+	conn, sw, err := web.Stream(ctx, w)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// errors past this point only mean the viewer left.
+	if _, err := io.WriteString(sw, "HTTP/1.1 200 OK\r\n"+
+		"Content-Type: video/x-flv\r\n"+
+		"Cache-Control: no-cache\r\n"+
+		"Access-Control-Allow-Origin: *\r\n"+
+		"Connection: close\r\n\r\n"); err != nil {
+		return nil
+	}
+	stream.ServeFLV(sw, ctx.Done())
+	return nil
+}
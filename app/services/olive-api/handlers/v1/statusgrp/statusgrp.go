@@ -0,0 +1,35 @@
+// Package statusgrp maintains the group of handlers for the runtime status
+// of the shows.
+package statusgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v1Web "github.com/go-olive/olive/business/web/v1"
+	"github.com/go-olive/olive/business/web/v1/mid"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/web"
+)
+
+// Handlers manages the set of status endpoints.
+type Handlers struct {
+	K *kernel.Kernel
+}
+
+// Query returns the runtime status of every show.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return mid.Respond(ctx, w, h.K.Status(), http.StatusOK)
+}
+
+// QueryByID returns the runtime status of a show.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	showID := web.Param(r, "id")
+	s, ok := h.K.ShowStatus(showID)
+	if !ok {
+		return v1Web.NewRequestError(fmt.Errorf("show[%s] is not running", showID), http.StatusNotFound)
+	}
+
+	return mid.Respond(ctx, w, s, http.StatusOK)
+}
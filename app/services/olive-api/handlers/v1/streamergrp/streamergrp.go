@@ -0,0 +1,165 @@
+// Package streamergrp maintains the group of handlers for streamer access.
+package streamergrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-olive/olive/business/core/cluster"
+	"github.com/go-olive/olive/business/core/show"
+	"github.com/go-olive/olive/business/core/streamer"
+	v1Web "github.com/go-olive/olive/business/web/v1"
+	"github.com/go-olive/olive/business/web/v1/mid"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/web"
+)
+
+// Handlers manages the set of streamer endpoints.
+type Handlers struct {
+	Streamer streamer.Core
+	Show     show.Core
+	K        *kernel.Kernel
+	Cluster  *cluster.Cluster
+}
+
+// Create adds a new streamer to the system.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var newStreamer streamer.NewStreamer
+	if err := web.Decode(r, &newStreamer); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	s, err := h.Streamer.Create(ctx, newStreamer, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, streamer.ErrInvalidPolicy):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("streamer[%+v]: %w", &newStreamer, err)
+		}
+	}
+
+	if err := h.handle(ctx, s.ID, &s); err != nil {
+		return fmt.Errorf("streamer[%+v]: %w", &s, err)
+	}
+
+	return mid.Respond(ctx, w, s, http.StatusCreated)
+}
+
+// Update updates a streamer in the system.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var upd streamer.UpdateStreamer
+	if err := web.Decode(r, &upd); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	streamerID := web.Param(r, "id")
+
+	s, err := h.Streamer.Update(ctx, streamerID, upd, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, streamer.ErrInvalidID),
+			errors.Is(err, streamer.ErrInvalidPolicy):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, streamer.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s] Streamer[%+v]: %w", streamerID, &upd, err)
+		}
+	}
+
+	if err := h.handle(ctx, streamerID, &s); err != nil {
+		return fmt.Errorf("ID[%s]: %w", streamerID, err)
+	}
+
+	return mid.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// Delete removes a streamer from the system, its shows being recorded on
+// their own from then on.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	streamerID := web.Param(r, "id")
+
+	if err := h.Streamer.Delete(ctx, streamerID); err != nil {
+		switch {
+		case errors.Is(err, streamer.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("ID[%s]: %w", streamerID, err)
+		}
+	}
+
+	if err := h.handle(ctx, streamerID, nil); err != nil {
+		return fmt.Errorf("ID[%s]: %w", streamerID, err)
+	}
+
+	return mid.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// Query returns every streamer.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	streamers, err := h.Streamer.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query for streamers: %w", err)
+	}
+
+	return mid.Respond(ctx, w, streamers, http.StatusOK)
+}
+
+// QueryByID returns a streamer by its ID, with its shows.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	streamerID := web.Param(r, "id")
+
+	s, err := h.Streamer.QueryByID(ctx, streamerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, streamer.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, streamer.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", streamerID, err)
+		}
+	}
+
+	shows, err := h.Show.QueryBySelector(ctx, show.Selector{StreamerID: streamerID})
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", streamerID, err)
+	}
+
+	data := struct {
+		streamer.Streamer
+		Shows []show.Show `json:"shows"`
+	}{
+		Streamer: s,
+		Shows:    shows,
+	}
+
+	return mid.Respond(ctx, w, data, http.StatusOK)
+}
+
+// handle applies the streamer to the kernel, nil removing it, or, in a
+// cluster, has every node apply it.
+func (h Handlers) handle(ctx context.Context, streamerID string, s *streamer.Streamer) error {
+	switch {
+	case h.Cluster != nil:
+		return h.Cluster.Publish(ctx, cluster.Message{Op: cluster.OpStreamer, Key: streamerID})
+	case s == nil:
+		h.K.DeleteStreamer(streamerID)
+	default:
+		h.K.HandleStreamer(s.Kernel())
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package tmplgrp maintains the group of handlers for the OutTmpl and
+// SaveDir templates.
+package tmplgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-olive/olive/business/core/show"
+	v1Web "github.com/go-olive/olive/business/web/v1"
+	"github.com/go-olive/olive/business/web/v1/mid"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/olivetv"
+	"github.com/go-olive/olive/foundation/web"
+)
+
+// Handlers manages the set of template endpoints.
+type Handlers struct {
+	Show show.Core
+	K    *kernel.Kernel
+}
+
+// Preview renders an OutTmpl and a SaveDir against a live snap of a show,
+// the ones of the show when left empty. The show is either a stored one, or
+// given by its platform and room.
+func (h Handlers) Preview(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var p struct {
+		ShowID   string `json:"show_id"`
+		Platform string `json:"platform"`
+		RoomID   string `json:"room_id"`
+		OutTmpl  string `json:"out_tmpl"`
+		SaveDir  string `json:"save_dir"`
+	}
+	if err := web.Decode(r, &p); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	var s kernel.Show
+	switch {
+	case p.ShowID != "":
+		var err error
+		s, err = h.Show.QueryEffectiveByID(ctx, p.ShowID)
+		if err != nil {
+			switch {
+			case errors.Is(err, show.ErrInvalidID):
+				return v1Web.NewRequestError(err, http.StatusBadRequest)
+			case errors.Is(err, show.ErrNotFound):
+				return v1Web.NewRequestError(err, http.StatusNotFound)
+			default:
+				return fmt.Errorf("ID[%s]: %w", p.ShowID, err)
+			}
+		}
+	case p.Platform != "" && p.RoomID != "":
+		s = kernel.Show{Platform: p.Platform, RoomID: p.RoomID}
+	default:
+		return v1Web.NewRequestError(errors.New("need a show_id, or a platform and a room_id"), http.StatusBadRequest)
+	}
+
+	preview, err := h.K.PreviewTemplate(s, p.OutTmpl, p.SaveDir)
+	if err != nil {
+		switch {
+		case errors.Is(err, kernel.ErrInvalidTemplate),
+			errors.Is(err, olivetv.ErrNotSupported):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return v1Web.NewRequestError(err, http.StatusBadGateway)
+		}
+	}
+
+	return mid.Respond(ctx, w, preview, http.StatusOK)
+}
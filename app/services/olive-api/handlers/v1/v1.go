@@ -6,11 +6,19 @@ import (
 	"net/http"
 
 	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/configgrp"
+	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/eventgrp"
+	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/groupgrp"
 	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/showgrp"
+	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/statusgrp"
+	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/streamergrp"
 	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/testgrp"
+	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/tmplgrp"
 	"github.com/go-olive/olive/app/services/olive-api/handlers/v1/usrgrp"
+	"github.com/go-olive/olive/business/core/cluster"
 	"github.com/go-olive/olive/business/core/config"
+	"github.com/go-olive/olive/business/core/group"
 	"github.com/go-olive/olive/business/core/show"
+	"github.com/go-olive/olive/business/core/streamer"
 	"github.com/go-olive/olive/engine/kernel"
 	"github.com/go-olive/olive/foundation/web"
 	"github.com/jmoiron/sqlx"
@@ -22,6 +30,9 @@ type Config struct {
 	Log *zap.SugaredLogger
 	DB  *sqlx.DB
 	K   *kernel.Kernel
+
+	// Cluster is nil unless the node shares the shows with others.
+	Cluster *cluster.Cluster
 }
 
 // Routes binds all the version 1 routes.
@@ -30,14 +41,70 @@ func Routes(app *web.App, cfg Config) {
 
 	// Register show management and authentication endpoints.
 	sgh := showgrp.Handlers{
-		Show: show.NewCore(cfg.Log, cfg.DB),
-		K:    cfg.K,
+		Show:    show.NewCore(cfg.Log, cfg.DB),
+		K:       cfg.K,
+		Cluster: cfg.Cluster,
 	}
 	app.Handle(http.MethodGet, version, "/shows/:pageIndex/:pageSize", sgh.Query)
 	app.Handle(http.MethodGet, version, "/shows/:id", sgh.QueryByID)
 	app.Handle(http.MethodPost, version, "/shows", sgh.Create)
 	app.Handle(http.MethodPut, version, "/shows/:id", sgh.Update)
 	app.Handle(http.MethodDelete, version, "/shows/:id", sgh.Delete)
+	app.Handle(http.MethodGet, version, "/shows/:id/live", sgh.Live)
+	app.Handle(http.MethodPost, version, "/shows/:id/record", sgh.Record)
+	app.Handle(http.MethodDelete, version, "/shows/:id/record", sgh.StopRecord)
+	app.Handle(http.MethodPost, version, "/shows/:id/record/pause", sgh.Pause)
+	app.Handle(http.MethodDelete, version, "/shows/:id/record/pause", sgh.Resume)
+	app.Handle(http.MethodPost, version, "/shows/bulk/enable", sgh.BulkEnable)
+	app.Handle(http.MethodPost, version, "/shows/bulk/disable", sgh.BulkDisable)
+	app.Handle(http.MethodPost, version, "/shows/bulk/delete", sgh.BulkDelete)
+	app.Handle(http.MethodPost, version, "/shows/bulk/tags", sgh.BulkTag)
+
+	// Register show group endpoints.
+	ggh := groupgrp.Handlers{
+		Group:   group.NewCore(cfg.Log, cfg.DB),
+		Show:    show.NewCore(cfg.Log, cfg.DB),
+		K:       cfg.K,
+		Cluster: cfg.Cluster,
+	}
+	app.Handle(http.MethodGet, version, "/groups", ggh.Query)
+	app.Handle(http.MethodGet, version, "/groups/:id", ggh.QueryByID)
+	app.Handle(http.MethodPost, version, "/groups", ggh.Create)
+	app.Handle(http.MethodPut, version, "/groups/:id", ggh.Update)
+	app.Handle(http.MethodDelete, version, "/groups/:id", ggh.Delete)
+
+	// Register streamer endpoints.
+	srgh := streamergrp.Handlers{
+		Streamer: streamer.NewCore(cfg.Log, cfg.DB),
+		Show:     show.NewCore(cfg.Log, cfg.DB),
+		K:        cfg.K,
+		Cluster:  cfg.Cluster,
+	}
+	app.Handle(http.MethodGet, version, "/streamers", srgh.Query)
+	app.Handle(http.MethodGet, version, "/streamers/:id", srgh.QueryByID)
+	app.Handle(http.MethodPost, version, "/streamers", srgh.Create)
+	app.Handle(http.MethodPut, version, "/streamers/:id", srgh.Update)
+	app.Handle(http.MethodDelete, version, "/streamers/:id", srgh.Delete)
+
+	// Register status endpoints.
+	stgh := statusgrp.Handlers{
+		K: cfg.K,
+	}
+	app.Handle(http.MethodGet, version, "/status", stgh.Query)
+	app.Handle(http.MethodGet, version, "/status/:id", stgh.QueryByID)
+
+	// Register event endpoints.
+	egh := eventgrp.Handlers{
+		K: cfg.K,
+	}
+	app.Handle(http.MethodGet, version, "/events", egh.Stream)
+
+	// Register template endpoints.
+	tmgh := tmplgrp.Handlers{
+		Show: show.NewCore(cfg.Log, cfg.DB),
+		K:    cfg.K,
+	}
+	app.Handle(http.MethodPost, version, "/templates/preview", tmgh.Preview)
 
 	// Register test endpoints.
 	tgh := testgrp.Handlers{
@@ -55,8 +122,9 @@ func Routes(app *web.App, cfg Config) {
 
 	// Register config endpoints.
 	cgh := configgrp.Handlers{
-		Config: config.NewCore(cfg.Log, cfg.DB),
-		K:      cfg.K,
+		Config:  config.NewCore(cfg.Log, cfg.DB),
+		K:       cfg.K,
+		Cluster: cfg.Cluster,
 	}
 	app.Handle(http.MethodGet, version, "/configs/:key", cgh.QueryByKey)
 	app.Handle(http.MethodPost, version, "/configs", cgh.Create)
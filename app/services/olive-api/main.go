@@ -14,8 +14,10 @@ import (
 
 	"github.com/ardanlabs/conf/v3"
 	"github.com/go-olive/olive/app/services/olive-api/handlers"
+	"github.com/go-olive/olive/business/core/cluster"
 	"github.com/go-olive/olive/business/core/config"
 	"github.com/go-olive/olive/business/core/show"
+	"github.com/go-olive/olive/business/core/streamer"
 	"github.com/go-olive/olive/business/sys/database"
 	"github.com/go-olive/olive/engine/kernel"
 	l "github.com/go-olive/olive/engine/log"
@@ -82,6 +84,12 @@ func run(log *zap.SugaredLogger) error {
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
 		}
+		Cluster struct {
+			Enable   bool          `conf:"default:false"`
+			NodeID   string        `conf:"help:defaults to the hostname"`
+			APIHost  string        `conf:"help:address the other nodes redirect live previews to"`
+			LeaseTTL time.Duration `conf:"default:30s"`
+		}
 	}{
 		Version: conf.Version{
 			Build: build,
@@ -119,7 +127,7 @@ func run(log *zap.SugaredLogger) error {
 	// Create connectivity to the database.
 	log.Infow("startup", "status", "initializing database support", "host", cfg.DB.Host)
 
-	db, err := database.Open(database.Config{
+	dbConfig := database.Config{
 		User:         cfg.DB.User,
 		Password:     cfg.DB.Password,
 		Host:         cfg.DB.Host,
@@ -127,7 +135,8 @@ func run(log *zap.SugaredLogger) error {
 		MaxIdleConns: cfg.DB.MaxIdleConns,
 		MaxOpenConns: cfg.DB.MaxOpenConns,
 		DisableTLS:   cfg.DB.DisableTLS,
-	})
+	}
+	db, err := database.Open(dbConfig)
 	if err != nil {
 		return fmt.Errorf("connecting to db: %w", err)
 	}
@@ -151,24 +160,75 @@ func run(log *zap.SugaredLogger) error {
 	engineLogger := l.InitLogger(engineConfig.LogDir)
 	engineLogger.Infof("Powered by go-olive/olive %s", build)
 
-	showCore := show.NewCore(log, db)
-	ctx2, cancel := context.WithTimeout(context.Background(), cfg.Web.ReadTimeout)
+	// in a cluster, the shows are started as their leases are claimed.
+	var showsEnabled []show.Show
+	if !cfg.Cluster.Enable {
+		showCore := show.NewCore(log, db)
+		ctx2, cancel := context.WithTimeout(context.Background(), cfg.Web.ReadTimeout)
+		defer cancel()
+		showsEnabled, err = showCore.QueryAllEnabled(ctx2)
+		if err != nil {
+			return fmt.Errorf("query shows enabled: %w", err)
+		}
+	}
+
+	streamerCore := streamer.NewCore(log, db)
+	ctx3, cancel := context.WithTimeout(context.Background(), cfg.Web.ReadTimeout)
 	defer cancel()
-	showsEnabled, err := showCore.QueryAllEnabled(ctx2)
+	streamers, err := streamerCore.Query(ctx3)
 	if err != nil {
-		return fmt.Errorf("query shows enabled: %w", err)
+		return fmt.Errorf("query streamers: %w", err)
 	}
 
 	k := kernel.New(engineLogger, engineConfig, showsEnabled)
+	for _, s := range streamers {
+		k.HandleStreamer(s.Kernel())
+	}
 	go func() {
 		k.Run()
 	}()
 
+	// =========================================================================
+	// Join Cluster
+
+	var c *cluster.Cluster
+	leave := func() {}
+	if cfg.Cluster.Enable {
+		nodeID := cfg.Cluster.NodeID
+		if nodeID == "" {
+			if nodeID, err = os.Hostname(); err != nil {
+				return fmt.Errorf("node id: %w", err)
+			}
+		}
+		log.Infow("startup", "status", "joining cluster", "node", nodeID)
+
+		c = cluster.New(log, db, k, cluster.Config{
+			NodeID:   nodeID,
+			APIHost:  cfg.Cluster.APIHost,
+			LeaseTTL: cfg.Cluster.LeaseTTL,
+			DB:       dbConfig,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if err := c.Run(ctx); err != nil {
+				log.Errorw("cluster", "ERROR", err)
+			}
+		}()
+		leave = func() {
+			cancel()
+			<-done
+		}
+	}
+
 	// todo(lc): timing is somewhat wrong
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
 		defer cancel()
 		k.Shutdown(ctx)
+		// leaving the cluster only once the recordings are stopped.
+		leave()
 	}()
 
 	// =========================================================================
@@ -206,6 +266,7 @@ func run(log *zap.SugaredLogger) error {
 		Log:      log,
 		DB:       db,
 		K:        k,
+		Cluster:  c,
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/business/core/cluster/db"
+	"github.com/go-olive/olive/business/core/show"
+)
+
+func ids(shows []show.Show) []string {
+	list := make([]string, len(shows))
+	for i, s := range shows {
+		list[i] = s.ID
+	}
+	return list
+}
+
+func equal(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSurplus(t *testing.T) {
+	owned := []show.Show{
+		{ID: "high", Priority: 5},
+		{ID: "recording-low", Priority: 0},
+		{ID: "low", Priority: 0},
+		{ID: "mid", Priority: 3},
+	}
+	recording := func(id string) bool { return id == "recording-low" }
+
+	tests := []struct {
+		share int
+		want  []string
+	}{
+		{4, nil},
+		{5, nil},
+		{3, []string{"low"}},
+		{2, []string{"low", "mid"}},
+		// the shows recording go last, whatever their priority.
+		{1, []string{"low", "mid", "high"}},
+		{0, []string{"low", "mid", "high", "recording-low"}},
+	}
+	for _, tt := range tests {
+		if got := ids(surplus(owned, tt.share, recording)); !equal(got, tt.want) {
+			t.Errorf("share %d: got %v, want %v", tt.share, got, tt.want)
+		}
+	}
+	if owned[0].ID != "high" {
+		t.Error("the shows owned were reordered")
+	}
+}
+
+func TestUnheld(t *testing.T) {
+	enabled := []show.Show{
+		{ID: "a", Priority: 0},
+		{ID: "b", Priority: 5},
+		{ID: "c", Priority: 0},
+		{ID: "d", Priority: 9},
+	}
+	taken := []db.Lease{{ShowID: "d", NodeID: "other"}}
+
+	want := []string{"b", "a", "c"}
+	if got := ids(unheld(enabled, taken)); !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	c := &Cluster{cfg: Config{LeaseTTL: 30 * time.Second}, renewed: now}
+
+	tests := []struct {
+		after time.Duration
+		want  bool
+	}{
+		{0, false},
+		{15 * time.Second, false},
+		{20 * time.Second, false},
+		{21 * time.Second, true},
+		// stopped before the other nodes may claim the shows at the ttl.
+		{30 * time.Second, true},
+	}
+	for _, tt := range tests {
+		if got := c.expired(now.Add(tt.after)); got != tt.want {
+			t.Errorf("%s after the renewal: got expired %v, want %v", tt.after, got, tt.want)
+		}
+	}
+}
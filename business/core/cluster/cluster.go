@@ -0,0 +1,463 @@
+// Package cluster shares the shows of one database among several olive-api
+// nodes. Each node records the shows it holds a lease of, renewing the leases
+// while alive, and the nodes are told of the changes made through any of them
+// by LISTEN/NOTIFY.
+//
+// Clustering is set up by the olive-api service only, the server command of
+// the olive CLI running a single node.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/business/core/cluster/db"
+	"github.com/go-olive/olive/business/core/config"
+	"github.com/go-olive/olive/business/core/show"
+	"github.com/go-olive/olive/business/core/streamer"
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/jmoiron/sqlx"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// channel is the notification channel shared by the nodes.
+const channel = "olive_cluster"
+
+// ErrNoOwner is returned when no node holds the lease of a show.
+var ErrNoOwner = errors.New("show is not held by any node")
+
+// Set of operations sent to the nodes.
+const (
+	OpUpdate     = "update"
+	OpConfig     = "config"
+	OpStreamer   = "streamer"
+	OpRecord     = "record"
+	OpStopRecord = "stop_record"
+	OpPause      = "pause"
+)
+
+// Message is sent to every node on an API change. Show updates and record
+// controls are applied by the node holding the show, config and streamer
+// updates by every node.
+type Message struct {
+	Op     string    `json:"op"`
+	ShowID string    `json:"show_id,omitempty"`
+	Key    string    `json:"key,omitempty"`
+	Until  time.Time `json:"until,omitempty"`
+}
+
+// Apply applies the record control of the message to the kernel, a pause
+// with a zero until resuming the show.
+func (m Message) Apply(k *kernel.Kernel) error {
+	switch m.Op {
+	case OpRecord:
+		return k.ForceStart(m.ShowID)
+	case OpStopRecord:
+		return k.ForceStop(m.ShowID)
+	case OpPause:
+		return k.PauseUntil(m.ShowID, m.Until)
+	default:
+		return fmt.Errorf("unknown record control[%s]", m.Op)
+	}
+}
+
+// Config is the required properties to join a cluster.
+type Config struct {
+	NodeID   string
+	APIHost  string
+	LeaseTTL time.Duration
+	DB       database.Config
+}
+
+// Cluster manages the leases of the shows recorded by this node.
+type Cluster struct {
+	log       *zap.SugaredLogger
+	store     db.Store
+	db        *sqlx.DB
+	shows     show.Core
+	configs   config.Core
+	streamers streamer.Core
+	k         *kernel.Kernel
+	cfg       Config
+
+	mu      sync.Mutex
+	owned   map[string]bool
+	renewed time.Time
+}
+
+// New constructs a cluster node running the shows it holds in the kernel.
+func New(log *zap.SugaredLogger, sqlxDB *sqlx.DB, k *kernel.Kernel, cfg Config) *Cluster {
+	return &Cluster{
+		log:       log,
+		store:     db.NewStore(log, sqlxDB),
+		db:        sqlxDB,
+		shows:     show.NewCore(log, sqlxDB),
+		configs:   config.NewCore(log, sqlxDB),
+		streamers: streamer.NewCore(log, sqlxDB),
+		k:         k,
+		cfg:       cfg,
+
+		owned:   make(map[string]bool),
+		renewed: time.Now(),
+	}
+}
+
+// Run keeps the node in the cluster until the context is done, then leaves
+// it, releasing the leases for the other nodes to take over. The kernel is
+// to be shut down beforehand, lest a show be recorded twice.
+func (c *Cluster) Run(ctx context.Context) error {
+	l, err := database.Listen(c.cfg.DB, channel)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	defer l.Close()
+
+	c.log.Infow("cluster", "status", "joined", "node", c.cfg.NodeID)
+	go c.watchExpiry(ctx)
+	c.balance(ctx)
+
+	ticker := time.NewTicker(c.cfg.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.leave()
+			return nil
+
+		case <-ticker.C:
+			c.balance(ctx)
+
+		case n := <-l.Notify:
+			// notifications were lost while reconnecting.
+			if n == nil {
+				c.balance(ctx)
+				continue
+			}
+			var msg Message
+			if err := jsoniter.UnmarshalFromString(n.Extra, &msg); err != nil {
+				c.log.Errorw("cluster", "status", "invalid message", "payload", n.Extra, "ERROR", err)
+				continue
+			}
+			c.handle(ctx, msg)
+		}
+	}
+}
+
+// Publish sends the message to every node, this one included.
+func (c *Cluster) Publish(ctx context.Context, msg Message) error {
+	payload, err := jsoniter.MarshalToString(msg)
+	if err != nil {
+		return err
+	}
+	return database.Notify(ctx, c.log, c.db, channel, payload)
+}
+
+// Owns reports whether this node holds the show.
+func (c *Cluster) Owns(showID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.owned[showID]
+}
+
+// Owner returns the node holding the show.
+func (c *Cluster) Owner(ctx context.Context, showID string) (Node, error) {
+	dbNode, err := c.store.QueryOwner(ctx, showID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Node{}, ErrNoOwner
+		}
+		return Node{}, fmt.Errorf("query: %w", err)
+	}
+	return toNode(dbNode), nil
+}
+
+func (c *Cluster) handle(ctx context.Context, msg Message) {
+	switch msg.Op {
+	case OpConfig:
+		cfg, err := c.configs.QueryByKey(ctx, msg.Key)
+		if err != nil {
+			c.log.Errorw("cluster", "status", "query config", "key", msg.Key, "ERROR", err)
+			return
+		}
+		c.k.UpdateConfig(cfg.Key, cfg.Value)
+
+	case OpStreamer:
+		s, err := c.streamers.QueryByID(ctx, msg.Key)
+		switch {
+		case errors.Is(err, streamer.ErrNotFound):
+			c.k.DeleteStreamer(msg.Key)
+		case err != nil:
+			c.log.Errorw("cluster", "status", "query streamer", "streamer", msg.Key, "ERROR", err)
+		default:
+			c.k.HandleStreamer(s.Kernel())
+		}
+
+	case OpUpdate:
+		s, err := c.shows.QueryEffectiveByID(ctx, msg.ShowID)
+		switch {
+		case errors.Is(err, show.ErrNotFound):
+			s = show.Show{ID: msg.ShowID, Enable: false}
+		case err != nil:
+			c.log.Errorw("cluster", "status", "query show", "show", msg.ShowID, "ERROR", err)
+			return
+		}
+
+		if c.Owns(s.ID) {
+			if !s.Enable {
+				c.release(ctx, s.ID)
+				return
+			}
+			c.k.HandleShow(s)
+			return
+		}
+		// every node races for a newly enabled show, the next balance
+		// evening out the shares again.
+		if s.Enable {
+			c.claim(ctx, s)
+		}
+
+	default:
+		if !c.Owns(msg.ShowID) {
+			return
+		}
+		if err := msg.Apply(c.k); err != nil {
+			c.log.Errorw("cluster", "status", "record control", "show", msg.ShowID, "op", msg.Op, "ERROR", err)
+		}
+	}
+}
+
+// balance renews the leases of the node, then takes or gives up shows for
+// every live node to hold its share of the enabled ones.
+func (c *Cluster) balance(ctx context.Context) {
+	if err := c.store.Heartbeat(ctx, c.cfg.NodeID, c.cfg.APIHost); err != nil {
+		c.log.Errorw("cluster", "status", "heartbeat", "ERROR", err)
+		return
+	}
+	// the leases expire a ttl after the database renewed them, which is
+	// after now.
+	now := time.Now()
+	leases, err := c.store.Renew(ctx, c.cfg.NodeID, c.cfg.LeaseTTL)
+	if err != nil {
+		c.log.Errorw("cluster", "status", "renew leases", "ERROR", err)
+		return
+	}
+	c.mu.Lock()
+	c.renewed = now
+	c.mu.Unlock()
+
+	nodes, err := c.store.QueryNodes(ctx, c.cfg.LeaseTTL)
+	if err != nil {
+		c.log.Errorw("cluster", "status", "query nodes", "ERROR", err)
+		return
+	}
+	enabled, err := c.shows.QueryAllEnabled(ctx)
+	if err != nil {
+		c.log.Errorw("cluster", "status", "query shows", "ERROR", err)
+		return
+	}
+	taken, err := c.store.QueryLeases(ctx)
+	if err != nil {
+		c.log.Errorw("cluster", "status", "query leases", "ERROR", err)
+		return
+	}
+
+	showMap := make(map[string]show.Show, len(enabled))
+	for _, s := range enabled {
+		showMap[s.ID] = s
+	}
+	held := make(map[string]bool, len(leases))
+	for _, lease := range leases {
+		held[lease.ShowID] = true
+	}
+
+	// the shows of the leases lost, to an expiry or by hand.
+	for _, id := range c.ownedIDs() {
+		if !held[id] {
+			c.log.Infow("cluster", "status", "lease lost", "show", id)
+			c.stop(id)
+		}
+	}
+
+	var owned []show.Show
+	for id := range held {
+		s, ok := showMap[id]
+		if !ok {
+			c.release(ctx, id)
+			continue
+		}
+		owned = append(owned, s)
+		if !c.Owns(id) {
+			c.start(s)
+		}
+	}
+
+	share := Share(len(enabled), len(nodes))
+	for _, s := range surplus(owned, share, c.recording) {
+		c.log.Infow("cluster", "status", "rebalance", "show", s.ID, "share", share)
+		c.release(ctx, s.ID)
+	}
+	if len(owned) < share {
+		for _, s := range unheld(enabled, taken) {
+			if len(owned) >= share {
+				break
+			}
+			if c.claim(ctx, s) {
+				owned = append(owned, s)
+			}
+		}
+	}
+}
+
+// surplus returns the shows held beyond the share of the node, the ones not
+// recording first, then the lowest priority.
+func surplus(owned []show.Show, share int, recording func(showID string) bool) []show.Show {
+	if len(owned) <= share {
+		return nil
+	}
+	owned = append([]show.Show(nil), owned...)
+	sort.SliceStable(owned, func(i, j int) bool {
+		ri, rj := recording(owned[i].ID), recording(owned[j].ID)
+		if ri != rj {
+			return !ri
+		}
+		return owned[i].Priority < owned[j].Priority
+	})
+	return owned[:len(owned)-share]
+}
+
+// unheld returns the enabled shows no node holds a lease of, highest
+// priority first.
+func unheld(enabled []show.Show, taken []db.Lease) []show.Show {
+	busy := make(map[string]bool, len(taken))
+	for _, lease := range taken {
+		busy[lease.ShowID] = true
+	}
+	var list []show.Show
+	for _, s := range enabled {
+		if !busy[s.ID] {
+			list = append(list, s)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Priority > list[j].Priority
+	})
+	return list
+}
+
+// Share returns the number of shows each node holds at most.
+func Share(shows int, nodes int) int {
+	if nodes < 1 {
+		nodes = 1
+	}
+	return (shows + nodes - 1) / nodes
+}
+
+// claim takes the lease of the show and starts it.
+func (c *Cluster) claim(ctx context.Context, s show.Show) bool {
+	ok, err := c.store.Claim(ctx, s.ID, c.cfg.NodeID, c.cfg.LeaseTTL)
+	if err != nil {
+		c.log.Errorw("cluster", "status", "claim", "show", s.ID, "ERROR", err)
+		return false
+	}
+	if ok {
+		c.log.Infow("cluster", "status", "claimed", "show", s.ID)
+		c.start(s)
+	}
+	return ok
+}
+
+// release stops the show and gives up its lease.
+func (c *Cluster) release(ctx context.Context, showID string) {
+	c.stop(showID)
+	if err := c.store.Release(ctx, showID, c.cfg.NodeID); err != nil {
+		c.log.Errorw("cluster", "status", "release", "show", showID, "ERROR", err)
+	}
+}
+
+// watchExpiry expires the leases until the context is done, apart from
+// balance for a database hanging not to hold it up.
+func (c *Cluster) watchExpiry(ctx context.Context) {
+	t := time.NewTicker(c.cfg.LeaseTTL / 6)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			c.expire()
+		}
+	}
+}
+
+// expire stops every show once the leases could not be renewed for a while,
+// well before the other nodes are free to take them over at the ttl.
+func (c *Cluster) expire() {
+	c.mu.Lock()
+	expired := c.expired(time.Now())
+	c.mu.Unlock()
+	if !expired {
+		return
+	}
+	for _, id := range c.ownedIDs() {
+		c.log.Infow("cluster", "status", "lease expired", "show", id)
+		c.stop(id)
+	}
+}
+
+// expired reports whether the leases are to be given up at t, two thirds of
+// the ttl after they were renewed. Checked every sixth of the ttl, the shows
+// stop at the latest five sixths of it in. c.mu must be held.
+func (c *Cluster) expired(t time.Time) bool {
+	return t.Sub(c.renewed) > c.cfg.LeaseTTL*2/3
+}
+
+// leave removes the node from the cluster.
+func (c *Cluster) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.store.DeleteNode(ctx, c.cfg.NodeID); err != nil {
+		c.log.Errorw("cluster", "status", "leave", "ERROR", err)
+		return
+	}
+	c.log.Infow("cluster", "status", "left", "node", c.cfg.NodeID)
+}
+
+func (c *Cluster) start(s show.Show) {
+	c.mu.Lock()
+	c.owned[s.ID] = true
+	c.mu.Unlock()
+	c.k.HandleShow(s)
+}
+
+func (c *Cluster) stop(showID string) {
+	c.mu.Lock()
+	owned := c.owned[showID]
+	delete(c.owned, showID)
+	c.mu.Unlock()
+	if !owned {
+		return
+	}
+	c.k.HandleShow(kernel.Show{ID: showID, Enable: false})
+}
+
+func (c *Cluster) recording(showID string) bool {
+	s, ok := c.k.ShowStatus(showID)
+	return ok && s.Recording
+}
+
+func (c *Cluster) ownedIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.owned))
+	for id := range c.owned {
+		ids = append(ids, id)
+	}
+	return ids
+}
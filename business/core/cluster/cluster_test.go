@@ -0,0 +1,24 @@
+package cluster_test
+
+import (
+	"testing"
+
+	"github.com/go-olive/olive/business/core/cluster"
+)
+
+func TestShare(t *testing.T) {
+	for _, tt := range []struct {
+		shows, nodes, want int
+	}{
+		{0, 3, 0},
+		{500, 1, 500},
+		{500, 3, 167},
+		{501, 3, 167},
+		{502, 3, 168},
+		{10, 0, 10},
+	} {
+		if got := cluster.Share(tt.shows, tt.nodes); got != tt.want {
+			t.Errorf("Share(%d, %d) = %d, want %d", tt.shows, tt.nodes, got, tt.want)
+		}
+	}
+}
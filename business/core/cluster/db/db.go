@@ -0,0 +1,233 @@
+// Package db contains cluster related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for node and lease access. Times are taken
+// from the database clock, so the nodes need not agree on theirs.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Heartbeat marks the node as alive.
+func (s Store) Heartbeat(ctx context.Context, nodeID string, apiHost string) error {
+	data := struct {
+		NodeID  string `db:"node_id"`
+		APIHost string `db:"api_host"`
+	}{
+		NodeID:  nodeID,
+		APIHost: apiHost,
+	}
+
+	const q = `
+	INSERT INTO nodes
+		(node_id, api_host, last_seen)
+	VALUES
+		(:node_id, :api_host, now())
+	ON CONFLICT (node_id) DO UPDATE SET
+		api_host = EXCLUDED.api_host,
+		last_seen = EXCLUDED.last_seen`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("heartbeat nodeID[%s]: %w", nodeID, err)
+	}
+
+	return nil
+}
+
+// DeleteNode removes the node and releases its leases.
+func (s Store) DeleteNode(ctx context.Context, nodeID string) error {
+	data := struct {
+		NodeID string `db:"node_id"`
+	}{
+		NodeID: nodeID,
+	}
+
+	const q = `
+	WITH released AS (
+		DELETE FROM leases WHERE node_id = :node_id
+	)
+	DELETE FROM
+		nodes
+	WHERE
+		node_id = :node_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting nodeID[%s]: %w", nodeID, err)
+	}
+
+	return nil
+}
+
+// QueryNodes retrieves the nodes seen within the ttl.
+func (s Store) QueryNodes(ctx context.Context, ttl time.Duration) ([]Node, error) {
+	data := struct {
+		TTL int64 `db:"ttl"`
+	}{
+		TTL: int64(ttl / time.Second),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		nodes
+	WHERE
+		last_seen > now() - :ttl * interval '1 second'
+	ORDER BY
+		node_id`
+
+	var nodes []Node
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &nodes); err != nil {
+		return nil, fmt.Errorf("selecting nodes: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// QueryLeases retrieves the leases which have not expired.
+func (s Store) QueryLeases(ctx context.Context) ([]Lease, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		leases
+	WHERE
+		expires_at > now()
+	ORDER BY
+		show_id`
+
+	var leases []Lease
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &leases); err != nil {
+		return nil, fmt.Errorf("selecting leases: %w", err)
+	}
+
+	return leases, nil
+}
+
+// Renew extends the leases of the node by the ttl, returning the leases it
+// still holds.
+func (s Store) Renew(ctx context.Context, nodeID string, ttl time.Duration) ([]Lease, error) {
+	data := struct {
+		NodeID string `db:"node_id"`
+		TTL    int64  `db:"ttl"`
+	}{
+		NodeID: nodeID,
+		TTL:    int64(ttl / time.Second),
+	}
+
+	const q = `
+	UPDATE
+		leases
+	SET
+		"expires_at" = now() + :ttl * interval '1 second'
+	WHERE
+		node_id = :node_id
+	RETURNING *`
+
+	var leases []Lease
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &leases); err != nil {
+		return nil, fmt.Errorf("renewing leases nodeID[%s]: %w", nodeID, err)
+	}
+
+	return leases, nil
+}
+
+// Claim takes the lease of the show for the node, unless another node holds
+// it. It reports whether the node holds the lease afterwards.
+func (s Store) Claim(ctx context.Context, showID string, nodeID string, ttl time.Duration) (bool, error) {
+	data := struct {
+		ShowID string `db:"show_id"`
+		NodeID string `db:"node_id"`
+		TTL    int64  `db:"ttl"`
+	}{
+		ShowID: showID,
+		NodeID: nodeID,
+		TTL:    int64(ttl / time.Second),
+	}
+
+	const q = `
+	INSERT INTO leases
+		(show_id, node_id, expires_at)
+	VALUES
+		(:show_id, :node_id, now() + :ttl * interval '1 second')
+	ON CONFLICT (show_id) DO UPDATE SET
+		node_id = EXCLUDED.node_id,
+		expires_at = EXCLUDED.expires_at
+	WHERE
+		leases.node_id = EXCLUDED.node_id OR leases.expires_at <= now()
+	RETURNING *`
+
+	var leases []Lease
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &leases); err != nil {
+		return false, fmt.Errorf("claiming showID[%s]: %w", showID, err)
+	}
+
+	return len(leases) == 1, nil
+}
+
+// Release gives up the lease of the show held by the node.
+func (s Store) Release(ctx context.Context, showID string, nodeID string) error {
+	data := struct {
+		ShowID string `db:"show_id"`
+		NodeID string `db:"node_id"`
+	}{
+		ShowID: showID,
+		NodeID: nodeID,
+	}
+
+	const q = `
+	DELETE FROM
+		leases
+	WHERE
+		show_id = :show_id AND node_id = :node_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("releasing showID[%s]: %w", showID, err)
+	}
+
+	return nil
+}
+
+// QueryOwner gets the node holding the lease of the show.
+func (s Store) QueryOwner(ctx context.Context, showID string) (Node, error) {
+	data := struct {
+		ShowID string `db:"show_id"`
+	}{
+		ShowID: showID,
+	}
+
+	const q = `
+	SELECT
+		n.*
+	FROM
+		leases AS l
+	JOIN
+		nodes AS n ON n.node_id = l.node_id
+	WHERE
+		l.show_id = :show_id AND l.expires_at > now()`
+
+	var node Node
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &node); err != nil {
+		return Node{}, fmt.Errorf("selecting owner showID[%s]: %w", showID, err)
+	}
+
+	return node, nil
+}
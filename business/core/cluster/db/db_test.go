@@ -0,0 +1,164 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/business/core/cluster/db"
+	"github.com/go-olive/olive/business/data/dbtest"
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/go-olive/olive/foundation/docker"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Lease(t *testing.T) {
+	log, sqlxDB, teardown := dbtest.NewUnit(t, c, "testlease")
+	t.Cleanup(teardown)
+
+	store := db.NewStore(log, sqlxDB)
+	ttl := time.Minute
+
+	t.Log("Given the need to share the leases of the shows among the nodes.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen two nodes claim the same show.", testID)
+		{
+			ctx := context.Background()
+			showID := uuid.NewString()
+
+			for _, node := range []string{"a", "b"} {
+				if err := store.Heartbeat(ctx, node, node+":3000"); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to heartbeat node %s : %s.", dbtest.Failed, testID, node, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to heartbeat the nodes.", dbtest.Success, testID)
+
+			ok, err := store.Claim(ctx, showID, "a", ttl)
+			if err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to claim a free show : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to claim a free show.", dbtest.Success, testID)
+
+			ok, err = store.Claim(ctx, showID, "b", ttl)
+			if err != nil || ok {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to claim a show held by another node : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to claim a show held by another node.", dbtest.Success, testID)
+
+			ok, err = store.Claim(ctx, showID, "a", ttl)
+			if err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to claim a show again : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to claim a show again.", dbtest.Success, testID)
+
+			node, err := store.QueryOwner(ctx, showID)
+			if err != nil || node.ID != "a" || node.APIHost != "a:3000" {
+				t.Fatalf("\t%s\tTest %d:\tShould route the show to its owner : %+v %s.", dbtest.Failed, testID, node, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould route the show to its owner.", dbtest.Success, testID)
+
+			leases, err := store.Renew(ctx, "a", ttl)
+			if err != nil || len(leases) != 1 || leases[0].ShowID != showID {
+				t.Fatalf("\t%s\tTest %d:\tShould renew the lease held : %+v %s.", dbtest.Failed, testID, leases, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould renew the lease held.", dbtest.Success, testID)
+
+			leases, err = store.Renew(ctx, "b", ttl)
+			if err != nil || len(leases) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould renew no lease of another node : %+v %s.", dbtest.Failed, testID, leases, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould renew no lease of another node.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen the lease of a show expires.", testID)
+		{
+			ctx := context.Background()
+			showID := uuid.NewString()
+
+			// a zero ttl expires at once.
+			if ok, err := store.Claim(ctx, showID, "a", 0); err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to claim a free show : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+
+			if _, err := store.QueryOwner(ctx, showID); !errors.Is(err, database.ErrDBNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT route the show to a node : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT route the show to a node.", dbtest.Success, testID)
+
+			leases, err := store.QueryLeases(ctx)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query the leases : %s.", dbtest.Failed, testID, err)
+			}
+			for _, lease := range leases {
+				if lease.ShowID == showID {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT list the expired lease.", dbtest.Failed, testID)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT list the expired lease.", dbtest.Success, testID)
+
+			if ok, err := store.Claim(ctx, showID, "b", ttl); err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to take the expired lease over : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to take the expired lease over.", dbtest.Success, testID)
+
+			if err := store.Release(ctx, showID, "a"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to release : %s.", dbtest.Failed, testID, err)
+			}
+			if node, err := store.QueryOwner(ctx, showID); err != nil || node.ID != "b" {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT release the lease of another node : %+v %s.", dbtest.Failed, testID, node, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT release the lease of another node.", dbtest.Success, testID)
+
+			if err := store.Release(ctx, showID, "b"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to release : %s.", dbtest.Failed, testID, err)
+			}
+			if _, err := store.QueryOwner(ctx, showID); !errors.Is(err, database.ErrDBNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould release the lease held : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould release the lease held.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen a node leaves.", testID)
+		{
+			ctx := context.Background()
+			showID := uuid.NewString()
+
+			if ok, err := store.Claim(ctx, showID, "a", ttl); err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to claim a free show : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+			if err := store.DeleteNode(ctx, "a"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete the node : %s.", dbtest.Failed, testID, err)
+			}
+
+			nodes, err := store.QueryNodes(ctx, ttl)
+			if err != nil || len(nodes) != 1 || nodes[0].ID != "b" {
+				t.Fatalf("\t%s\tTest %d:\tShould list the nodes left : %+v %s.", dbtest.Failed, testID, nodes, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the nodes left.", dbtest.Success, testID)
+
+			if ok, err := store.Claim(ctx, showID, "b", ttl); err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to claim the shows of the node left : %v %s.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to claim the shows of the node left.", dbtest.Success, testID)
+		}
+	}
+}
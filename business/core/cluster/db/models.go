@@ -0,0 +1,21 @@
+package db
+
+import (
+	"time"
+)
+
+// Node represent the structure we need for moving data
+// between the app and the database.
+type Node struct {
+	ID       string    `db:"node_id"`
+	APIHost  string    `db:"api_host"`
+	LastSeen time.Time `db:"last_seen"`
+}
+
+// Lease represent the structure we need for moving data
+// between the app and the database.
+type Lease struct {
+	ShowID    string    `db:"show_id"`
+	NodeID    string    `db:"node_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
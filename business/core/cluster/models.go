@@ -0,0 +1,22 @@
+package cluster
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/go-olive/olive/business/core/cluster/db"
+)
+
+// Node represents an olive-api node of the cluster.
+type Node struct {
+	ID       string    `json:"node_id"`
+	APIHost  string    `json:"api_host"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// =============================================================================
+
+func toNode(dbNode db.Node) Node {
+	n := (*Node)(unsafe.Pointer(&dbNode))
+	return *n
+}
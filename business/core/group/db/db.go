@@ -0,0 +1,150 @@
+// Package db contains show group related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for show group access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new group into the database.
+func (s Store) Create(ctx context.Context, group Group) error {
+	const q = `
+	INSERT INTO show_groups
+		(group_id, name, save_dir, out_tmpl, post_cmds, split_rule, parser, date_created, date_updated)
+	VALUES
+		(:group_id, :name, :save_dir, :out_tmpl, :post_cmds, :split_rule, :parser, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, group); err != nil {
+		return fmt.Errorf("inserting group: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a group document in the database.
+func (s Store) Update(ctx context.Context, group Group) error {
+	const q = `
+	UPDATE
+		show_groups
+	SET
+		"name" = :name,
+		"save_dir" = :save_dir,
+		"out_tmpl" = :out_tmpl,
+		"post_cmds" = :post_cmds,
+		"split_rule" = :split_rule,
+		"parser" = :parser,
+		"date_updated" = :date_updated
+	WHERE
+		group_id = :group_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, group); err != nil {
+		return fmt.Errorf("updating groupID[%s]: %w", group.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes a group from the database, its shows leaving it.
+func (s Store) Delete(ctx context.Context, groupID string) error {
+	data := struct {
+		GroupID string `db:"group_id"`
+	}{
+		GroupID: groupID,
+	}
+
+	const q = `
+	WITH members AS (
+		UPDATE shows SET group_id = '' WHERE group_id = :group_id
+	)
+	DELETE FROM
+		show_groups
+	WHERE
+		group_id = :group_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting groupID[%s]: %w", groupID, err)
+	}
+
+	return nil
+}
+
+// Query retrieves every group from the database.
+func (s Store) Query(ctx context.Context) ([]Group, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		show_groups
+	ORDER BY
+		name`
+
+	var groups []Group
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &groups); err != nil {
+		return nil, fmt.Errorf("selecting groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// QueryByID gets the specified group from the database.
+func (s Store) QueryByID(ctx context.Context, groupID string) (Group, error) {
+	data := struct {
+		GroupID string `db:"group_id"`
+	}{
+		GroupID: groupID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		show_groups
+	WHERE
+		group_id = :group_id`
+
+	var group Group
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &group); err != nil {
+		return Group{}, fmt.Errorf("selecting groupID[%q]: %w", groupID, err)
+	}
+
+	return group, nil
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"time"
+)
+
+// Group represent the structure we need for moving data
+// between the app and the database.
+type Group struct {
+	ID          string    `db:"group_id"`
+	Name        string    `db:"name"`
+	SaveDir     string    `db:"save_dir"`
+	OutTmpl     string    `db:"out_tmpl"`
+	PostCmds    string    `db:"post_cmds"`
+	SplitRule   string    `db:"split_rule"`
+	Parser      string    `db:"parser"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
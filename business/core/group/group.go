@@ -0,0 +1,181 @@
+// Package group provides business API for show groups.
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-olive/olive/business/core/group/db"
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/go-olive/olive/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("group not found")
+	ErrInvalidID        = errors.New("ID is not in its proper form")
+	ErrInvalidPostCmds  = errors.New("PostCmds is not valid")
+	ErrInvalidSplitRule = errors.New("SplitRule is not valid")
+	ErrInvalidOutTmpl   = errors.New("OutTmpl is not valid")
+	ErrInvalidSaveDir   = errors.New("SaveDir is not valid")
+)
+
+// Core manages the set of APIs for group access.
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for group api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Create inserts a new group into the database.
+func (c Core) Create(ctx context.Context, newGroup NewGroup, now time.Time) (Group, error) {
+	if err := validate.Check(newGroup); err != nil {
+		return Group{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if err := validate.CheckPostCmds(newGroup.PostCmds); err != nil {
+		return Group{}, ErrInvalidPostCmds
+	}
+	if err := validate.CheckSplitRule(newGroup.SplitRule); err != nil {
+		return Group{}, ErrInvalidSplitRule
+	}
+	if err := validate.CheckTemplate(newGroup.OutTmpl); err != nil {
+		return Group{}, fmt.Errorf("%w: %s", ErrInvalidOutTmpl, err)
+	}
+	if err := validate.CheckTemplate(newGroup.SaveDir); err != nil {
+		return Group{}, fmt.Errorf("%w: %s", ErrInvalidSaveDir, err)
+	}
+
+	dbGroup := db.Group{
+		ID:          validate.GenerateID(),
+		Name:        newGroup.Name,
+		SaveDir:     newGroup.SaveDir,
+		OutTmpl:     newGroup.OutTmpl,
+		PostCmds:    newGroup.PostCmds,
+		SplitRule:   newGroup.SplitRule,
+		Parser:      newGroup.Parser,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.store.Tran(tx).Create(ctx, dbGroup); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Group{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toGroup(dbGroup), nil
+}
+
+// Update replaces a group document in the database.
+func (c Core) Update(ctx context.Context, groupID string, updateGroup UpdateGroup, now time.Time) error {
+	if err := validate.CheckID(groupID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(updateGroup); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	dbGroup, err := c.store.QueryByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("updating group groupID[%s]: %w", groupID, err)
+	}
+
+	if updateGroup.Name != nil {
+		dbGroup.Name = *updateGroup.Name
+	}
+	if updateGroup.SaveDir != nil {
+		dbGroup.SaveDir = *updateGroup.SaveDir
+	}
+	if updateGroup.OutTmpl != nil {
+		dbGroup.OutTmpl = *updateGroup.OutTmpl
+	}
+	if updateGroup.PostCmds != nil {
+		dbGroup.PostCmds = *updateGroup.PostCmds
+	}
+	if updateGroup.SplitRule != nil {
+		dbGroup.SplitRule = *updateGroup.SplitRule
+	}
+	if updateGroup.Parser != nil {
+		dbGroup.Parser = *updateGroup.Parser
+	}
+	dbGroup.DateUpdated = now
+
+	if err := validate.CheckPostCmds(dbGroup.PostCmds); err != nil {
+		return ErrInvalidPostCmds
+	}
+	if err := validate.CheckSplitRule(dbGroup.SplitRule); err != nil {
+		return ErrInvalidSplitRule
+	}
+	if err := validate.CheckTemplate(dbGroup.OutTmpl); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidOutTmpl, err)
+	}
+	if err := validate.CheckTemplate(dbGroup.SaveDir); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSaveDir, err)
+	}
+
+	if err := c.store.Update(ctx, dbGroup); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a group from the database, its shows keeping only their
+// own settings.
+func (c Core) Delete(ctx context.Context, groupID string) error {
+	if err := validate.CheckID(groupID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := c.store.Delete(ctx, groupID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves every group from the database.
+func (c Core) Query(ctx context.Context) ([]Group, error) {
+	dbGroups, err := c.store.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toGroupSlice(dbGroups), nil
+}
+
+// QueryByID gets the specified group from the database.
+func (c Core) QueryByID(ctx context.Context, groupID string) (Group, error) {
+	if err := validate.CheckID(groupID); err != nil {
+		return Group{}, ErrInvalidID
+	}
+
+	dbGroup, err := c.store.QueryByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Group{}, ErrNotFound
+		}
+		return Group{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toGroup(dbGroup), nil
+}
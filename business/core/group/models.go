@@ -0,0 +1,59 @@
+package group
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/go-olive/olive/business/core/group/db"
+)
+
+// Group represents a group of shows, carrying the defaults of the fields its
+// shows leave unset.
+type Group struct {
+	ID          string    `json:"group_id"`
+	Name        string    `json:"name"`
+	SaveDir     string    `json:"save_dir"`
+	OutTmpl     string    `json:"out_tmpl"`
+	PostCmds    string    `json:"post_cmds"`
+	SplitRule   string    `json:"split_rule"`
+	Parser      string    `json:"parser"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// NewGroup contains information needed to create a new Group.
+type NewGroup struct {
+	Name      string `json:"name" validate:"required"`
+	SaveDir   string `json:"save_dir"`
+	OutTmpl   string `json:"out_tmpl"`
+	PostCmds  string `json:"post_cmds"`
+	SplitRule string `json:"split_rule"`
+	Parser    string `json:"parser"`
+}
+
+// UpdateGroup defines what information may be provided to modify an existing
+// Group. All fields are optional so clients can send just the fields they
+// want changed.
+type UpdateGroup struct {
+	Name      *string `json:"name"`
+	SaveDir   *string `json:"save_dir"`
+	OutTmpl   *string `json:"out_tmpl"`
+	PostCmds  *string `json:"post_cmds"`
+	SplitRule *string `json:"split_rule"`
+	Parser    *string `json:"parser"`
+}
+
+// =============================================================================
+
+func toGroup(dbGroup db.Group) Group {
+	g := (*Group)(unsafe.Pointer(&dbGroup))
+	return *g
+}
+
+func toGroupSlice(dbGroups []db.Group) []Group {
+	groups := make([]Group, len(dbGroups))
+	for i, dbGroup := range dbGroups {
+		groups[i] = toGroup(dbGroup)
+	}
+	return groups
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-olive/olive/business/sys/database"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -50,9 +51,9 @@ func (s Store) Tran(tx sqlx.ExtContext) Store {
 func (s Store) Create(ctx context.Context, show Show) error {
 	const q = `
 	INSERT INTO shows
-		(show_id, enable, platform, room_id, streamer_name, out_tmpl, parser, save_dir, post_cmds, split_rule, date_created, date_updated)
+		(show_id, enable, platform, room_id, streamer_name, out_tmpl, parser, save_dir, post_cmds, split_rule, ffmpeg_profile, relay, audio_only, schedule, snap_rest_seconds, priority, filter_rule, tags, group_id, streamer_id, push_watch, date_created, date_updated)
 	VALUES
-		(:show_id, :enable, :platform, :room_id, :streamer_name, :out_tmpl, :parser, :save_dir, :post_cmds, :split_rule, :date_created, :date_updated)`
+		(:show_id, :enable, :platform, :room_id, :streamer_name, :out_tmpl, :parser, :save_dir, :post_cmds, :split_rule, :ffmpeg_profile, :relay, :audio_only, :schedule, :snap_rest_seconds, :priority, :filter_rule, :tags, :group_id, :streamer_id, :push_watch, :date_created, :date_updated)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, show); err != nil {
 		return fmt.Errorf("inserting show: %w", err)
@@ -76,6 +77,17 @@ func (s Store) Update(ctx context.Context, show Show) error {
 		"save_dir" = :save_dir,
 		"post_cmds" = :post_cmds,
 		"split_rule" = :split_rule,
+		"ffmpeg_profile" = :ffmpeg_profile,
+		"relay" = :relay,
+		"audio_only" = :audio_only,
+		"schedule" = :schedule,
+		"snap_rest_seconds" = :snap_rest_seconds,
+		"priority" = :priority,
+		"filter_rule" = :filter_rule,
+		"tags" = :tags,
+		"group_id" = :group_id,
+		"streamer_id" = :streamer_id,
+		"push_watch" = :push_watch,
 		"date_updated" = :date_updated
 	WHERE
 		show_id = :show_id`
@@ -172,21 +184,110 @@ func (s Store) TotalNum(ctx context.Context) (int64, error) {
 	return tmp.Count, nil
 }
 
-// QueryAllEnabled retrieves all shows which `enable` equals true from the database.
-func (s Store) QueryAllEnabled(ctx context.Context) ([]Show, error) {
+// effective selects the shows with the fields they leave unset taken from
+// their group.
+const effective = `
+	SELECT
+		s.show_id, s.enable, s.platform, s.room_id, s.streamer_name,
+		COALESCE(NULLIF(s.out_tmpl, ''), g.out_tmpl, '') AS out_tmpl,
+		COALESCE(NULLIF(s.parser, ''), g.parser, '') AS parser,
+		COALESCE(NULLIF(s.save_dir, ''), g.save_dir, '') AS save_dir,
+		COALESCE(NULLIF(NULLIF(s.post_cmds, ''), '[]'), g.post_cmds, s.post_cmds) AS post_cmds,
+		COALESCE(NULLIF(s.split_rule, ''), g.split_rule, '') AS split_rule,
+		s.ffmpeg_profile, s.relay, s.audio_only, s.push_watch, s.schedule, s.snap_rest_seconds,
+		s.priority, s.filter_rule, s.tags, s.group_id, s.streamer_id,
+		s.date_created, s.date_updated
+	FROM
+		shows AS s
+	LEFT JOIN
+		show_groups AS g ON CAST(g.group_id AS TEXT) = s.group_id`
+
+// QueryEffectiveByID gets the specified show from the database, with the
+// defaults of its group applied.
+func (s Store) QueryEffectiveByID(ctx context.Context, showID string) (Show, error) {
 	data := struct {
-		Enable bool `db:"enable"`
+		ShowID string `db:"show_id"`
 	}{
-		Enable: true,
+		ShowID: showID,
 	}
 
-	const q = `
+	const q = effective + `
+	WHERE
+		s.show_id = :show_id`
+
+	var show Show
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &show); err != nil {
+		return Show{}, fmt.Errorf("selecting showID[%q]: %w", showID, err)
+	}
+
+	return show, nil
+}
+
+// selector selects the shows matching every criterion given, an empty
+// criterion matching every show.
+const selector = `
 	SELECT
 		*
 	FROM
 		shows
-	WHERE 
-		enable = :enable`
+	WHERE
+		(:all_ids OR CAST(show_id AS TEXT) = ANY(:show_ids)) AND
+		(:tag = '' OR :tag = ANY(string_to_array(tags, ','))) AND
+		(:group_id = '' OR group_id = :group_id) AND
+		(:streamer_id = '' OR streamer_id = :streamer_id)
+	ORDER BY
+		show_id`
+
+// QueryBySelector retrieves the shows matching every criterion given, an
+// empty criterion matching every show.
+func (s Store) QueryBySelector(ctx context.Context, showIDs []string, tag string, groupID string, streamerID string) ([]Show, error) {
+	return s.queryBySelector(ctx, selector, showIDs, tag, groupID, streamerID)
+}
+
+// LockBySelector retrieves the shows matching every criterion given like
+// QueryBySelector, locking them until the end of the transaction.
+func (s Store) LockBySelector(ctx context.Context, showIDs []string, tag string, groupID string, streamerID string) ([]Show, error) {
+	const q = selector + `
+	FOR UPDATE`
+
+	return s.queryBySelector(ctx, q, showIDs, tag, groupID, streamerID)
+}
+
+func (s Store) queryBySelector(ctx context.Context, q string, showIDs []string, tag string, groupID string, streamerID string) ([]Show, error) {
+	data := struct {
+		AllIDs     bool           `db:"all_ids"`
+		ShowIDs    pq.StringArray `db:"show_ids"`
+		Tag        string         `db:"tag"`
+		GroupID    string         `db:"group_id"`
+		StreamerID string         `db:"streamer_id"`
+	}{
+		AllIDs:     len(showIDs) == 0,
+		ShowIDs:    showIDs,
+		Tag:        tag,
+		GroupID:    groupID,
+		StreamerID: streamerID,
+	}
+
+	var shows []Show
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &shows); err != nil {
+		return nil, fmt.Errorf("selecting shows: %w", err)
+	}
+
+	return shows, nil
+}
+
+// QueryAllEnabled retrieves all shows which `enable` equals true from the
+// database, with the defaults of their groups applied.
+func (s Store) QueryAllEnabled(ctx context.Context) ([]Show, error) {
+	data := struct {
+		Enable bool `db:"enable"`
+	}{
+		Enable: true,
+	}
+
+	const q = effective + `
+	WHERE
+		s.enable = :enable`
 	var shows []Show
 	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &shows); err != nil {
 		return nil, fmt.Errorf("selecting shows: %w", err)
@@ -7,16 +7,27 @@ import (
 // Show represent the structure we need for moving data
 // between the app and the database.
 type Show struct {
-	ID           string    `db:"show_id"`
-	Enable       bool      `db:"enable"`
-	Platform     string    `db:"platform"`
-	RoomID       string    `db:"room_id"`
-	StreamerName string    `db:"streamer_name"`
-	OutTmpl      string    `db:"out_tmpl"`
-	Parser       string    `db:"parser"`
-	SaveDir      string    `db:"save_dir"`
-	PostCmds     string    `db:"post_cmds"`
-	SplitRule    string    `db:"split_rule"`
-	DateCreated  time.Time `db:"date_created"`
-	DateUpdated  time.Time `db:"date_updated"`
+	ID              string    `db:"show_id"`
+	Enable          bool      `db:"enable"`
+	Platform        string    `db:"platform"`
+	RoomID          string    `db:"room_id"`
+	StreamerName    string    `db:"streamer_name"`
+	OutTmpl         string    `db:"out_tmpl"`
+	Parser          string    `db:"parser"`
+	SaveDir         string    `db:"save_dir"`
+	PostCmds        string    `db:"post_cmds"`
+	SplitRule       string    `db:"split_rule"`
+	FfmpegProfile   string    `db:"ffmpeg_profile"`
+	Relay           string    `db:"relay"`
+	AudioOnly       bool      `db:"audio_only"`
+	PushWatch       bool      `db:"push_watch"`
+	Schedule        string    `db:"schedule"`
+	SnapRestSeconds uint      `db:"snap_rest_seconds"`
+	Priority        int       `db:"priority"`
+	FilterRule      string    `db:"filter_rule"`
+	Tags            string    `db:"tags"`
+	GroupID         string    `db:"group_id"`
+	StreamerID      string    `db:"streamer_id"`
+	DateCreated     time.Time `db:"date_created"`
+	DateUpdated     time.Time `db:"date_updated"`
 }
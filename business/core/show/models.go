@@ -12,15 +12,26 @@ type Show = kernel.Show
 
 // NewShow contains information needed to create a new Show.
 type NewShow struct {
-	Enable       bool   `json:"enable"`
-	Platform     string `json:"platform" validate:"required"`
-	RoomID       string `json:"room_id" validate:"required"`
-	StreamerName string `json:"streamer_name"`
-	OutTmpl      string `json:"out_tmpl"`
-	Parser       string `json:"parser"`
-	SaveDir      string `json:"save_dir"`
-	PostCmds     string `json:"post_cmds"`
-	SplitRule    string `json:"split_rule"`
+	Enable          bool   `json:"enable"`
+	Platform        string `json:"platform" validate:"required"`
+	RoomID          string `json:"room_id" validate:"required"`
+	StreamerName    string `json:"streamer_name"`
+	OutTmpl         string `json:"out_tmpl"`
+	Parser          string `json:"parser"`
+	SaveDir         string `json:"save_dir"`
+	PostCmds        string `json:"post_cmds"`
+	SplitRule       string `json:"split_rule"`
+	FfmpegProfile   string `json:"ffmpeg_profile"`
+	Relay           string `json:"relay"`
+	AudioOnly       bool   `json:"audio_only"`
+	PushWatch       bool   `json:"push_watch"`
+	Schedule        string `json:"schedule"`
+	SnapRestSeconds uint   `json:"snap_rest_seconds"`
+	Priority        int    `json:"priority"`
+	FilterRule      string `json:"filter_rule"`
+	Tags            string `json:"tags"`
+	GroupID         string `json:"group_id" validate:"omitempty,uuid"`
+	StreamerID      string `json:"streamer_id" validate:"omitempty,uuid"`
 }
 
 // UpdateShow defines what information may be provided to modify an existing
@@ -30,15 +41,40 @@ type NewShow struct {
 // we do not want to use pointers to basic types but we make exceptions around
 // marshalling/unmarshalling.
 type UpdateShow struct {
-	Enable       *bool   `json:"enable"`
-	Platform     *string `json:"platform"`
-	RoomID       *string `json:"room_id"`
-	StreamerName *string `json:"streamer_name"`
-	OutTmpl      *string `json:"out_tmpl"`
-	Parser       *string `json:"parser"`
-	SaveDir      *string `json:"save_dir"`
-	PostCmds     *string `json:"post_cmds"`
-	SplitRule    *string `json:"split_rule"`
+	Enable          *bool   `json:"enable"`
+	Platform        *string `json:"platform"`
+	RoomID          *string `json:"room_id"`
+	StreamerName    *string `json:"streamer_name"`
+	OutTmpl         *string `json:"out_tmpl"`
+	Parser          *string `json:"parser"`
+	SaveDir         *string `json:"save_dir"`
+	PostCmds        *string `json:"post_cmds"`
+	SplitRule       *string `json:"split_rule"`
+	FfmpegProfile   *string `json:"ffmpeg_profile"`
+	Relay           *string `json:"relay"`
+	AudioOnly       *bool   `json:"audio_only"`
+	PushWatch       *bool   `json:"push_watch"`
+	Schedule        *string `json:"schedule"`
+	SnapRestSeconds *uint   `json:"snap_rest_seconds"`
+	Priority        *int    `json:"priority"`
+	FilterRule      *string `json:"filter_rule"`
+	Tags            *string `json:"tags"`
+	GroupID         *string `json:"group_id" validate:"omitempty,uuid"`
+	StreamerID      *string `json:"streamer_id" validate:"omitempty,uuid"`
+}
+
+// Selector selects the shows matching every criterion given.
+type Selector struct {
+	ShowIDs    []string `json:"show_ids"`
+	Tag        string   `json:"tag"`
+	GroupID    string   `json:"group_id" validate:"omitempty,uuid"`
+	StreamerID string   `json:"streamer_id" validate:"omitempty,uuid"`
+}
+
+// IsEmpty reports whether the selector has no criterion, selecting every
+// show.
+func (s Selector) IsEmpty() bool {
+	return len(s.ShowIDs) == 0 && s.Tag == "" && s.GroupID == "" && s.StreamerID == ""
 }
 
 // =============================================================================
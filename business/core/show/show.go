@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-olive/olive/business/core/config"
 	"github.com/go-olive/olive/business/core/show/db"
 	"github.com/go-olive/olive/business/sys/database"
 	"github.com/go-olive/olive/business/sys/validate"
@@ -21,17 +22,26 @@ var (
 	ErrInvalidID        = errors.New("ID is not in its proper form")
 	ErrInvalidPostCmds  = errors.New("PostCmds is not valid")
 	ErrInvalidSplitRule = errors.New("SplitRule is not valid")
+	ErrInvalidRelay     = errors.New("Relay is not valid")
+	ErrInvalidSchedule  = errors.New("Schedule is not valid")
+	ErrInvalidFilter    = errors.New("FilterRule is not valid")
+	ErrInvalidOutTmpl   = errors.New("OutTmpl is not valid")
+	ErrInvalidSaveDir   = errors.New("SaveDir is not valid")
+	ErrInvalidProfile   = errors.New("FfmpegProfile is not valid")
+	ErrEmptySelector    = errors.New("selector is empty")
 )
 
 // Core manages the set of APIs for show access.
 type Core struct {
-	store db.Store
+	store   db.Store
+	configs config.Core
 }
 
 // NewCore constructs a core for show api access.
 func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
 	return Core{
-		store: db.NewStore(log, sqlxDB),
+		store:   db.NewStore(log, sqlxDB),
+		configs: config.NewCore(log, sqlxDB),
 	}
 }
 
@@ -44,23 +54,52 @@ func (c Core) Create(ctx context.Context, newShow NewShow, now time.Time) (Show,
 	if err := validate.CheckPostCmds(newShow.PostCmds); err != nil {
 		return Show{}, ErrInvalidPostCmds
 	}
+	if err := validate.CheckTemplate(newShow.OutTmpl); err != nil {
+		return Show{}, fmt.Errorf("%w: %s", ErrInvalidOutTmpl, err)
+	}
+	if err := validate.CheckTemplate(newShow.SaveDir); err != nil {
+		return Show{}, fmt.Errorf("%w: %s", ErrInvalidSaveDir, err)
+	}
 	if err := validate.CheckSplitRule(newShow.SplitRule); err != nil {
 		return Show{}, ErrInvalidSplitRule
 	}
+	if err := validate.CheckRelay(newShow.Relay); err != nil {
+		return Show{}, ErrInvalidRelay
+	}
+	if err := validate.CheckSchedule(newShow.Schedule); err != nil {
+		return Show{}, ErrInvalidSchedule
+	}
+	if err := validate.CheckFilterRule(newShow.FilterRule); err != nil {
+		return Show{}, ErrInvalidFilter
+	}
+	if err := c.checkFfmpegProfile(ctx, newShow.FfmpegProfile); err != nil {
+		return Show{}, err
+	}
 
 	dbShow := db.Show{
-		ID:           validate.GenerateID(),
-		Enable:       newShow.Enable,
-		Platform:     newShow.Platform,
-		RoomID:       newShow.RoomID,
-		StreamerName: newShow.StreamerName,
-		OutTmpl:      newShow.OutTmpl,
-		Parser:       newShow.Parser,
-		SaveDir:      newShow.SaveDir,
-		PostCmds:     newShow.PostCmds,
-		SplitRule:    newShow.SplitRule,
-		DateCreated:  now,
-		DateUpdated:  now,
+		ID:              validate.GenerateID(),
+		Enable:          newShow.Enable,
+		Platform:        newShow.Platform,
+		RoomID:          newShow.RoomID,
+		StreamerName:    newShow.StreamerName,
+		OutTmpl:         newShow.OutTmpl,
+		Parser:          newShow.Parser,
+		SaveDir:         newShow.SaveDir,
+		PostCmds:        newShow.PostCmds,
+		SplitRule:       newShow.SplitRule,
+		FfmpegProfile:   newShow.FfmpegProfile,
+		Relay:           newShow.Relay,
+		AudioOnly:       newShow.AudioOnly,
+		PushWatch:       newShow.PushWatch,
+		Schedule:        newShow.Schedule,
+		SnapRestSeconds: newShow.SnapRestSeconds,
+		Priority:        newShow.Priority,
+		FilterRule:      newShow.FilterRule,
+		Tags:            normalizeTags(newShow.Tags),
+		GroupID:         newShow.GroupID,
+		StreamerID:      newShow.StreamerID,
+		DateCreated:     now,
+		DateUpdated:     now,
 	}
 
 	tran := func(tx sqlx.ExtContext) error {
@@ -122,14 +161,68 @@ func (c Core) Update(ctx context.Context, showID string, updateShow UpdateShow,
 	if updateShow.SplitRule != nil {
 		dbShow.SplitRule = *updateShow.SplitRule
 	}
+	if updateShow.FfmpegProfile != nil {
+		dbShow.FfmpegProfile = *updateShow.FfmpegProfile
+	}
+	if updateShow.Relay != nil {
+		dbShow.Relay = *updateShow.Relay
+	}
+	if updateShow.AudioOnly != nil {
+		dbShow.AudioOnly = *updateShow.AudioOnly
+	}
+	if updateShow.PushWatch != nil {
+		dbShow.PushWatch = *updateShow.PushWatch
+	}
+	if updateShow.Schedule != nil {
+		dbShow.Schedule = *updateShow.Schedule
+	}
+	if updateShow.SnapRestSeconds != nil {
+		dbShow.SnapRestSeconds = *updateShow.SnapRestSeconds
+	}
+	if updateShow.Priority != nil {
+		dbShow.Priority = *updateShow.Priority
+	}
+	if updateShow.FilterRule != nil {
+		dbShow.FilterRule = *updateShow.FilterRule
+	}
+	if updateShow.Tags != nil {
+		dbShow.Tags = normalizeTags(*updateShow.Tags)
+	}
+	if updateShow.GroupID != nil {
+		dbShow.GroupID = *updateShow.GroupID
+	}
+	if updateShow.StreamerID != nil {
+		dbShow.StreamerID = *updateShow.StreamerID
+	}
 	dbShow.DateUpdated = now
 
 	if err := validate.CheckPostCmds(dbShow.PostCmds); err != nil {
 		return ErrInvalidPostCmds
 	}
+	if err := validate.CheckTemplate(dbShow.OutTmpl); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidOutTmpl, err)
+	}
+	if err := validate.CheckTemplate(dbShow.SaveDir); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSaveDir, err)
+	}
 	if err := validate.CheckSplitRule(dbShow.SplitRule); err != nil {
 		return ErrInvalidSplitRule
 	}
+	if err := validate.CheckRelay(dbShow.Relay); err != nil {
+		return ErrInvalidRelay
+	}
+	if err := validate.CheckSchedule(dbShow.Schedule); err != nil {
+		return ErrInvalidSchedule
+	}
+	if err := validate.CheckFilterRule(dbShow.FilterRule); err != nil {
+		return ErrInvalidFilter
+	}
+	// a profile removed from the config since is left to the engine.
+	if updateShow.FfmpegProfile != nil {
+		if err := c.checkFfmpegProfile(ctx, dbShow.FfmpegProfile); err != nil {
+			return err
+		}
+	}
 
 	if err := c.store.Update(ctx, dbShow); err != nil {
 		return fmt.Errorf("update: %w", err)
@@ -191,7 +284,8 @@ func (c Core) TotalNum(ctx context.Context) (int64, error) {
 	return num, nil
 }
 
-// QueryAllEnabled retrieves all shows which `enable` equals true from the database and saves them in cache.
+// QueryAllEnabled retrieves all shows which `enable` equals true from the database,
+// with the defaults of their groups applied.
 func (c *Core) QueryAllEnabled(ctx context.Context) ([]Show, error) {
 	dbShows, err := c.store.QueryAllEnabled(ctx)
 	if err != nil {
@@ -199,3 +293,141 @@ func (c *Core) QueryAllEnabled(ctx context.Context) ([]Show, error) {
 	}
 	return toShowSlice(dbShows), nil
 }
+
+// QueryEffectiveByID gets the specified show from the database, the fields it
+// leaves unset being taken from its group, as the kernel is to run it.
+func (c Core) QueryEffectiveByID(ctx context.Context, showID string) (Show, error) {
+	if err := validate.CheckID(showID); err != nil {
+		return Show{}, ErrInvalidID
+	}
+
+	dbShow, err := c.store.QueryEffectiveByID(ctx, showID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Show{}, ErrNotFound
+		}
+		return Show{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toShow(dbShow), nil
+}
+
+// QueryBySelector retrieves the shows matching the selector.
+func (c Core) QueryBySelector(ctx context.Context, sel Selector) ([]Show, error) {
+	if err := checkSelector(sel); err != nil {
+		return nil, err
+	}
+
+	dbShows, err := c.store.QueryBySelector(ctx, sel.ShowIDs, sel.Tag, sel.GroupID, sel.StreamerID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toShowSlice(dbShows), nil
+}
+
+// BulkEnable enables or disables the shows matching the selector, returning
+// them.
+func (c Core) BulkEnable(ctx context.Context, sel Selector, enable bool, now time.Time) ([]Show, error) {
+	return c.bulkUpdate(ctx, sel, now, func(dbShow *db.Show) {
+		dbShow.Enable = enable
+	})
+}
+
+// BulkTag adds and removes tags to the shows matching the selector, returning
+// them.
+func (c Core) BulkTag(ctx context.Context, sel Selector, add []string, remove []string, now time.Time) ([]Show, error) {
+	return c.bulkUpdate(ctx, sel, now, func(dbShow *db.Show) {
+		dbShow.Tags = retag(dbShow.Tags, add, remove)
+	})
+}
+
+// BulkDelete removes the shows matching the selector, returning them.
+func (c Core) BulkDelete(ctx context.Context, sel Selector) ([]Show, error) {
+	shows, err := c.QueryBySelector(ctx, sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(shows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(shows))
+	for i, show := range shows {
+		ids[i] = show.ID
+	}
+	if err := c.store.Delete(ctx, ids); err != nil {
+		return nil, fmt.Errorf("delete: %w", err)
+	}
+
+	return shows, nil
+}
+
+// bulkUpdate applies fn to the shows matching the selector within one
+// transaction, the shows being selected and locked within it so that no
+// concurrent change is written over.
+func (c Core) bulkUpdate(ctx context.Context, sel Selector, now time.Time, fn func(*db.Show)) ([]Show, error) {
+	if err := checkSelector(sel); err != nil {
+		return nil, err
+	}
+
+	var shows []Show
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+		dbShows, err := store.LockBySelector(ctx, sel.ShowIDs, sel.Tag, sel.GroupID, sel.StreamerID)
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		for i := range dbShows {
+			fn(&dbShows[i])
+			dbShows[i].DateUpdated = now
+			if err := store.Update(ctx, dbShows[i]); err != nil {
+				return fmt.Errorf("update: %w", err)
+			}
+		}
+		shows = toShowSlice(dbShows)
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return nil, fmt.Errorf("tran: %w", err)
+	}
+
+	return shows, nil
+}
+
+// checkSelector validates the selector, which must not select every show.
+func checkSelector(sel Selector) error {
+	if sel.IsEmpty() {
+		return ErrEmptySelector
+	}
+	if err := validate.Check(sel); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+	for _, id := range sel.ShowIDs {
+		if err := validate.CheckID(id); err != nil {
+			return fmt.Errorf("query: %w showID:%s", ErrInvalidID, id)
+		}
+	}
+	return nil
+}
+
+// checkFfmpegProfile validates that the profile is one of the engine config.
+func (c Core) checkFfmpegProfile(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	cfg, err := c.configs.QueryEngineConfig(ctx)
+	if err != nil {
+		if !errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("query config: %w", err)
+		}
+		// no profile exists before the engine config is stored.
+		return fmt.Errorf("%w: ffmpeg profile[%s] does not exist", ErrInvalidProfile, name)
+	}
+	if err := validate.CheckFfmpegProfile(name, cfg); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidProfile, err)
+	}
+	return nil
+}
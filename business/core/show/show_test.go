@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/go-olive/olive/business/core/group"
 	"github.com/go-olive/olive/business/core/show"
 	"github.com/go-olive/olive/business/data/dbtest"
 	"github.com/go-olive/olive/foundation/docker"
@@ -104,6 +106,171 @@ func Test_Show(t *testing.T) {
 	}
 }
 
+func Test_Selector(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testselector")
+	t.Cleanup(teardown)
+
+	core := show.NewCore(log, db)
+	groupCore := group.NewCore(log, db)
+
+	t.Log("Given the need to select Show records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen selecting among 3 shows.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+			g, err := groupCore.Create(ctx, group.NewGroup{Name: "vtubers"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create group : %s.", dbtest.Failed, testID, err)
+			}
+			streamerID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			news := []show.NewShow{
+				{Platform: "bilibili", RoomID: "1", PostCmds: "[]", Tags: "vtuber,game", GroupID: g.ID},
+				{Platform: "bilibili", RoomID: "2", PostCmds: "[]", Tags: "game", StreamerID: streamerID},
+				{Platform: "huya", RoomID: "3", PostCmds: "[]", GroupID: g.ID, StreamerID: streamerID},
+			}
+			shows := make([]show.Show, len(news))
+			for i, nu := range news {
+				if shows[i], err = core.Create(ctx, nu, now); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to create show : %s.", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create shows.", dbtest.Success, testID)
+
+			tests := []struct {
+				name string
+				sel  show.Selector
+				want []show.Show
+			}{
+				{"ids", show.Selector{ShowIDs: []string{shows[0].ID, shows[2].ID}}, []show.Show{shows[0], shows[2]}},
+				{"tag", show.Selector{Tag: "game"}, []show.Show{shows[0], shows[1]}},
+				{"group", show.Selector{GroupID: g.ID}, []show.Show{shows[0], shows[2]}},
+				{"streamer", show.Selector{StreamerID: streamerID}, []show.Show{shows[1], shows[2]}},
+				{"every criterion", show.Selector{Tag: "vtuber", GroupID: g.ID}, []show.Show{shows[0]}},
+				{"no match", show.Selector{Tag: "music"}, nil},
+			}
+			for _, tt := range tests {
+				got, err := core.QueryBySelector(ctx, tt.sel)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to select shows by %s : %s.", dbtest.Failed, testID, tt.name, err)
+				}
+				if diff := cmp.Diff(sortShows(tt.want), sortShows(got)); diff != "" {
+					t.Fatalf("\t%s\tTest %d:\tShould select the shows by %s. Diff:\n%s", dbtest.Failed, testID, tt.name, diff)
+				}
+				t.Logf("\t%s\tTest %d:\tShould select the shows by %s.", dbtest.Success, testID, tt.name)
+			}
+
+			if _, err := core.QueryBySelector(ctx, show.Selector{}); !errors.Is(err, show.ErrEmptySelector) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to select every show : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to select every show.", dbtest.Success, testID)
+
+			tagged, err := core.BulkTag(ctx, show.Selector{GroupID: g.ID}, []string{"music"}, []string{"game"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to tag shows : %s.", dbtest.Failed, testID, err)
+			}
+			tags := make(map[string]string)
+			for _, s := range tagged {
+				tags[s.ID] = s.Tags
+			}
+			if len(tags) != 2 || tags[shows[0].ID] != "vtuber,music" || tags[shows[2].ID] != "music" {
+				t.Fatalf("\t%s\tTest %d:\tShould tag the selected shows : %v.", dbtest.Failed, testID, tagged)
+			}
+			saved, err := core.QueryByID(ctx, shows[1].ID)
+			if err != nil || saved.Tags != "game" {
+				t.Fatalf("\t%s\tTest %d:\tShould leave the other shows untagged : %v %s.", dbtest.Failed, testID, saved.Tags, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to tag shows.", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_Effective(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testeffective")
+	t.Cleanup(teardown)
+
+	core := show.NewCore(log, db)
+	groupCore := group.NewCore(log, db)
+
+	t.Log("Given the need to apply the defaults of groups to Show records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a show leaves fields to its group.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+			ng := group.NewGroup{
+				Name:      "vtubers",
+				SaveDir:   "/data/vtubers",
+				OutTmpl:   "[{{ .StreamerName }}]{{ .RoomName }}.flv",
+				PostCmds:  `[{"Path":"olivetrash"}]`,
+				SplitRule: `{"Duration": "1h"}`,
+				Parser:    "streamlink",
+			}
+			g, err := groupCore.Create(ctx, ng, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create group : %s.", dbtest.Failed, testID, err)
+			}
+
+			inherit, err := core.Create(ctx, show.NewShow{Enable: true, Platform: "bilibili", RoomID: "1", PostCmds: "[]", GroupID: g.ID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create show : %s.", dbtest.Failed, testID, err)
+			}
+			own, err := core.Create(ctx, show.NewShow{Enable: true, Platform: "bilibili", RoomID: "2", PostCmds: `[{"Path":"olivebiliup"}]`, SaveDir: "/data/own", Parser: "flv", GroupID: g.ID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create show : %s.", dbtest.Failed, testID, err)
+			}
+			alone, err := core.Create(ctx, show.NewShow{Enable: true, Platform: "huya", RoomID: "3", PostCmds: "[]"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create show : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create shows.", dbtest.Success, testID)
+
+			got, err := core.QueryEffectiveByID(ctx, inherit.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the show : %s.", dbtest.Failed, testID, err)
+			}
+			if got.SaveDir != ng.SaveDir || got.OutTmpl != ng.OutTmpl || got.PostCmds != ng.PostCmds || got.SplitRule != ng.SplitRule || got.Parser != ng.Parser {
+				t.Fatalf("\t%s\tTest %d:\tShould inherit the unset fields from its group : %+v.", dbtest.Failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould inherit the unset fields from its group.", dbtest.Success, testID)
+
+			got, err = core.QueryEffectiveByID(ctx, own.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the show : %s.", dbtest.Failed, testID, err)
+			}
+			if got.SaveDir != own.SaveDir || got.Parser != own.Parser || got.PostCmds != own.PostCmds || got.OutTmpl != ng.OutTmpl {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the fields it sets : %+v.", dbtest.Failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the fields it sets.", dbtest.Success, testID)
+
+			enabled, err := core.QueryAllEnabled(ctx)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the enabled shows : %s.", dbtest.Failed, testID, err)
+			}
+			if len(enabled) != 3 {
+				t.Fatalf("\t%s\tTest %d:\tShould retrieve the shows without a group : got %d shows.", dbtest.Failed, testID, len(enabled))
+			}
+			for _, s := range enabled {
+				if s.ID == alone.ID && (s.SaveDir != "" || s.PostCmds != "[]") {
+					t.Fatalf("\t%s\tTest %d:\tShould leave the shows without a group as they are : %+v.", dbtest.Failed, testID, s)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould leave the shows without a group as they are.", dbtest.Success, testID)
+		}
+	}
+}
+
+// sortShows orders the shows by ID, as the selector does.
+func sortShows(shows []show.Show) []show.Show {
+	sort.Slice(shows, func(i, j int) bool { return shows[i].ID < shows[j].ID })
+	return shows
+}
+
 // func Test_PagingShow(t *testing.T) {
 // 	log, db, teardown := dbtest.NewUnit(t, c, "testpaging")
 // 	t.Cleanup(teardown)
@@ -0,0 +1,41 @@
+package show
+
+import (
+	"strings"
+)
+
+// normalizeTags trims the comma separated tags, dropping the empty and
+// duplicated ones.
+func normalizeTags(tags string) string {
+	return strings.Join(splitTags(tags), ",")
+}
+
+// retag adds and removes tags from the comma separated tags.
+func retag(tags string, add []string, remove []string) string {
+	removed := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		removed[strings.TrimSpace(tag)] = true
+	}
+
+	var list []string
+	for _, tag := range splitTags(tags + "," + strings.Join(add, ",")) {
+		if !removed[tag] {
+			list = append(list, tag)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+func splitTags(tags string) []string {
+	seen := make(map[string]bool)
+	var list []string
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		list = append(list, tag)
+	}
+	return list
+}
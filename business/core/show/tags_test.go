@@ -0,0 +1,43 @@
+package show
+
+import "testing"
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		tags string
+		want string
+	}{
+		{"", ""},
+		{" , ,", ""},
+		{"vtuber", "vtuber"},
+		{" vtuber , game ", "vtuber,game"},
+		{"vtuber,game,vtuber,,game", "vtuber,game"},
+	}
+	for _, tt := range tests {
+		if got := normalizeTags(tt.tags); got != tt.want {
+			t.Errorf("normalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestRetag(t *testing.T) {
+	tests := []struct {
+		tags   string
+		add    []string
+		remove []string
+		want   string
+	}{
+		{"", nil, nil, ""},
+		{"", []string{"vtuber"}, nil, "vtuber"},
+		{"vtuber", []string{" game ", "vtuber"}, nil, "vtuber,game"},
+		{"vtuber,game", nil, []string{" vtuber"}, "game"},
+		{"vtuber,game", nil, []string{"music"}, "vtuber,game"},
+		{"vtuber", []string{"game"}, []string{"game"}, "vtuber"},
+		{"vtuber,game", nil, []string{"vtuber", "game"}, ""},
+	}
+	for _, tt := range tests {
+		if got := retag(tt.tags, tt.add, tt.remove); got != tt.want {
+			t.Errorf("retag(%q, %q, %q) = %q, want %q", tt.tags, tt.add, tt.remove, got, tt.want)
+		}
+	}
+}
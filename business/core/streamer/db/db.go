@@ -0,0 +1,146 @@
+// Package db contains streamer related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for streamer access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new streamer into the database.
+func (s Store) Create(ctx context.Context, streamer Streamer) error {
+	const q = `
+	INSERT INTO streamers
+		(streamer_id, name, policy, date_created, date_updated)
+	VALUES
+		(:streamer_id, :name, :policy, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, streamer); err != nil {
+		return fmt.Errorf("inserting streamer: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a streamer document in the database.
+func (s Store) Update(ctx context.Context, streamer Streamer) error {
+	const q = `
+	UPDATE
+		streamers
+	SET
+		"name" = :name,
+		"policy" = :policy,
+		"date_updated" = :date_updated
+	WHERE
+		streamer_id = :streamer_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, streamer); err != nil {
+		return fmt.Errorf("updating streamerID[%s]: %w", streamer.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes a streamer from the database, its shows leaving it.
+func (s Store) Delete(ctx context.Context, streamerID string) error {
+	data := struct {
+		StreamerID string `db:"streamer_id"`
+	}{
+		StreamerID: streamerID,
+	}
+
+	const q = `
+	WITH members AS (
+		UPDATE shows SET streamer_id = '' WHERE streamer_id = :streamer_id
+	)
+	DELETE FROM
+		streamers
+	WHERE
+		streamer_id = :streamer_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting streamerID[%s]: %w", streamerID, err)
+	}
+
+	return nil
+}
+
+// Query retrieves every streamer from the database.
+func (s Store) Query(ctx context.Context) ([]Streamer, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		streamers
+	ORDER BY
+		name`
+
+	var streamers []Streamer
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &streamers); err != nil {
+		return nil, fmt.Errorf("selecting streamers: %w", err)
+	}
+
+	return streamers, nil
+}
+
+// QueryByID gets the specified streamer from the database.
+func (s Store) QueryByID(ctx context.Context, streamerID string) (Streamer, error) {
+	data := struct {
+		StreamerID string `db:"streamer_id"`
+	}{
+		StreamerID: streamerID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		streamers
+	WHERE
+		streamer_id = :streamer_id`
+
+	var streamer Streamer
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &streamer); err != nil {
+		return Streamer{}, fmt.Errorf("selecting streamerID[%q]: %w", streamerID, err)
+	}
+
+	return streamer, nil
+}
@@ -0,0 +1,15 @@
+package db
+
+import (
+	"time"
+)
+
+// Streamer represent the structure we need for moving data
+// between the app and the database.
+type Streamer struct {
+	ID          string    `db:"streamer_id"`
+	Name        string    `db:"name"`
+	Policy      string    `db:"policy"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
@@ -0,0 +1,56 @@
+package streamer
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/go-olive/olive/business/core/streamer/db"
+	"github.com/go-olive/olive/engine/kernel"
+)
+
+// Streamer represents the person behind several shows.
+type Streamer struct {
+	ID          string    `json:"streamer_id"`
+	Name        string    `json:"name"`
+	Policy      string    `json:"policy"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// Kernel returns the streamer as the kernel runs it.
+func (s Streamer) Kernel() kernel.Streamer {
+	return kernel.Streamer{
+		ID:     s.ID,
+		Name:   s.Name,
+		Policy: s.Policy,
+	}
+}
+
+// NewStreamer contains information needed to create a new Streamer.
+type NewStreamer struct {
+	Name   string `json:"name" validate:"required"`
+	Policy string `json:"policy"`
+}
+
+// UpdateStreamer defines what information may be provided to modify an
+// existing Streamer. All fields are optional so clients can send just the
+// fields they want changed.
+type UpdateStreamer struct {
+	Name   *string `json:"name"`
+	Policy *string `json:"policy"`
+}
+
+// =============================================================================
+
+func toStreamer(dbStreamer db.Streamer) Streamer {
+	s := (*Streamer)(unsafe.Pointer(&dbStreamer))
+	return *s
+}
+
+func toStreamerSlice(dbStreamers []db.Streamer) []Streamer {
+	streamers := make([]Streamer, len(dbStreamers))
+	for i, dbStreamer := range dbStreamers {
+		streamers[i] = toStreamer(dbStreamer)
+	}
+	return streamers
+}
@@ -0,0 +1,145 @@
+// Package streamer provides business API for streamers.
+package streamer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-olive/olive/business/core/streamer/db"
+	"github.com/go-olive/olive/business/sys/database"
+	"github.com/go-olive/olive/business/sys/validate"
+	"github.com/go-olive/olive/engine/config"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound      = errors.New("streamer not found")
+	ErrInvalidID     = errors.New("ID is not in its proper form")
+	ErrInvalidPolicy = errors.New("Policy is not valid")
+)
+
+// Core manages the set of APIs for streamer access.
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for streamer api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Create inserts a new streamer into the database.
+func (c Core) Create(ctx context.Context, newStreamer NewStreamer, now time.Time) (Streamer, error) {
+	if err := validate.Check(newStreamer); err != nil {
+		return Streamer{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if !config.ValidStreamerPolicy(newStreamer.Policy) {
+		return Streamer{}, ErrInvalidPolicy
+	}
+
+	dbStreamer := db.Streamer{
+		ID:          validate.GenerateID(),
+		Name:        newStreamer.Name,
+		Policy:      newStreamer.Policy,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.store.Tran(tx).Create(ctx, dbStreamer); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Streamer{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toStreamer(dbStreamer), nil
+}
+
+// Update replaces a streamer document in the database.
+func (c Core) Update(ctx context.Context, streamerID string, updateStreamer UpdateStreamer, now time.Time) (Streamer, error) {
+	if err := validate.CheckID(streamerID); err != nil {
+		return Streamer{}, ErrInvalidID
+	}
+
+	if err := validate.Check(updateStreamer); err != nil {
+		return Streamer{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbStreamer, err := c.store.QueryByID(ctx, streamerID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Streamer{}, ErrNotFound
+		}
+		return Streamer{}, fmt.Errorf("updating streamer streamerID[%s]: %w", streamerID, err)
+	}
+
+	if updateStreamer.Name != nil {
+		dbStreamer.Name = *updateStreamer.Name
+	}
+	if updateStreamer.Policy != nil {
+		dbStreamer.Policy = *updateStreamer.Policy
+	}
+	dbStreamer.DateUpdated = now
+
+	if !config.ValidStreamerPolicy(dbStreamer.Policy) {
+		return Streamer{}, ErrInvalidPolicy
+	}
+
+	if err := c.store.Update(ctx, dbStreamer); err != nil {
+		return Streamer{}, fmt.Errorf("update: %w", err)
+	}
+
+	return toStreamer(dbStreamer), nil
+}
+
+// Delete removes a streamer from the database, its shows being recorded on
+// their own from then on.
+func (c Core) Delete(ctx context.Context, streamerID string) error {
+	if err := validate.CheckID(streamerID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := c.store.Delete(ctx, streamerID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves every streamer from the database.
+func (c Core) Query(ctx context.Context) ([]Streamer, error) {
+	dbStreamers, err := c.store.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toStreamerSlice(dbStreamers), nil
+}
+
+// QueryByID gets the specified streamer from the database.
+func (c Core) QueryByID(ctx context.Context, streamerID string) (Streamer, error) {
+	if err := validate.CheckID(streamerID); err != nil {
+		return Streamer{}, ErrInvalidID
+	}
+
+	dbStreamer, err := c.store.QueryByID(ctx, streamerID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Streamer{}, ErrNotFound
+		}
+		return Streamer{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toStreamer(dbStreamer), nil
+}
@@ -0,0 +1,28 @@
+package dbschema_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ardanlabs/darwin"
+)
+
+// darwin compares the versions as floats, a migration numbered below the
+// last applied one is never run.
+func TestMigrationOrder(t *testing.T) {
+	doc, err := os.ReadFile("sql/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations := darwin.ParseMigrations(string(doc))
+	if len(migrations) == 0 {
+		t.Fatal("Should parse the migrations")
+	}
+	for i := 1; i < len(migrations); i++ {
+		prev, cur := migrations[i-1], migrations[i]
+		if cur.Version <= prev.Version {
+			t.Errorf("Should number %q after %v, got %v", cur.Description, prev.Version, cur.Version)
+		}
+	}
+}
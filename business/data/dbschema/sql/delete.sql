@@ -1,2 +1,6 @@
+DELETE FROM streamers;
+DELETE FROM show_groups;
 DELETE FROM shows;
-DELETE FROM configs;
\ No newline at end of file
+DELETE FROM configs;
+DELETE FROM leases;
+DELETE FROM nodes;
@@ -25,3 +25,86 @@ CREATE TABLE configs (
 	
 	PRIMARY KEY (key)
 );
+
+-- Version: 0.6
+-- Description: Add ffmpeg profile to shows
+ALTER TABLE shows ADD COLUMN ffmpeg_profile TEXT NOT NULL DEFAULT '';
+
+-- Version: 0.7
+-- Description: Add relay targets to shows
+ALTER TABLE shows ADD COLUMN relay TEXT NOT NULL DEFAULT '';
+
+-- Version: 0.8
+-- Description: Add audio only recording to shows
+ALTER TABLE shows ADD COLUMN audio_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Version: 0.9
+-- Description: Add recording schedule to shows
+ALTER TABLE shows ADD COLUMN schedule TEXT NOT NULL DEFAULT '';
+
+-- Version: 0.91
+-- Description: Add per show polling interval
+ALTER TABLE shows ADD COLUMN snap_rest_seconds INT NOT NULL DEFAULT 0;
+
+-- Version: 0.92
+-- Description: Add recording priority to shows
+ALTER TABLE shows ADD COLUMN priority INT NOT NULL DEFAULT 0;
+
+-- Version: 0.93
+-- Description: Add recording filter rule to shows
+ALTER TABLE shows ADD COLUMN filter_rule TEXT NOT NULL DEFAULT '';
+
+-- Version: 0.94
+-- Description: Create tables for cluster nodes and show leases
+CREATE TABLE nodes (
+	node_id    TEXT,
+	api_host   TEXT,
+	last_seen  TIMESTAMP,
+
+	PRIMARY KEY (node_id)
+);
+
+CREATE TABLE leases (
+	show_id    UUID,
+	node_id    TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (show_id)
+);
+
+-- Version: 0.95
+-- Description: Add tags and groups to shows
+ALTER TABLE shows ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE shows ADD COLUMN group_id TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE show_groups (
+	group_id      UUID,
+	name          TEXT NOT NULL,
+	save_dir      TEXT NOT NULL DEFAULT '',
+	out_tmpl      TEXT NOT NULL DEFAULT '',
+	post_cmds     TEXT NOT NULL DEFAULT '',
+	split_rule    TEXT NOT NULL DEFAULT '',
+	parser        TEXT NOT NULL DEFAULT '',
+	date_created  TIMESTAMP,
+	date_updated  TIMESTAMP,
+
+	PRIMARY KEY (group_id)
+);
+
+-- Version: 0.96
+-- Description: Add streamers owning shows across platforms
+ALTER TABLE shows ADD COLUMN streamer_id TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE streamers (
+	streamer_id   UUID,
+	name          TEXT NOT NULL,
+	policy        TEXT NOT NULL DEFAULT '',
+	date_created  TIMESTAMP,
+	date_updated  TIMESTAMP,
+
+	PRIMARY KEY (streamer_id)
+);
+
+-- Version: 0.97
+-- Description: Add the opt-in to push watching per show
+ALTER TABLE shows ADD COLUMN push_watch BOOLEAN NOT NULL DEFAULT FALSE;
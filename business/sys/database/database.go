@@ -38,6 +38,39 @@ type Config struct {
 
 // Open knows how to open a database connection based on the configuration.
 func Open(cfg Config) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", dsn(cfg))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+
+	return db, nil
+}
+
+// Listen opens a dedicated connection listening on the channel for
+// notifications. A nil notification is delivered after the connection is
+// re-established, notifications sent in between being lost.
+func Listen(cfg Config, channel string) (*pq.Listener, error) {
+	l := pq.NewListener(dsn(cfg), time.Second, time.Minute, nil)
+	if err := l.Listen(channel); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Notify sends a notification with the payload on the channel, delivered to
+// the listeners once the current transaction, if any, commits.
+func Notify(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtContext, channel string, payload string) error {
+	log.Infow("database.Notify", "traceid", web.GetTraceID(ctx), "channel", channel, "payload", payload)
+
+	_, err := db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+// dsn builds the connection string of the configuration.
+func dsn(cfg Config) string {
 	sslMode := "require"
 	if cfg.DisableTLS {
 		sslMode = "disable"
@@ -55,14 +88,7 @@ func Open(cfg Config) (*sqlx.DB, error) {
 		RawQuery: q.Encode(),
 	}
 
-	db, err := sqlx.Open("postgres", u.String())
-	if err != nil {
-		return nil, err
-	}
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-
-	return db, nil
+	return u.String()
 }
 
 // StatusCheck returns nil if it can successfully talk to the database. It
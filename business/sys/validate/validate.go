@@ -4,12 +4,15 @@ package validate
 import (
 	"errors"
 	"fmt"
-	"os/exec"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
 
 	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/foundation/filter"
+	"github.com/go-olive/olive/foundation/schedule"
+	"github.com/go-olive/olive/foundation/tmpl"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -98,13 +101,18 @@ func CheckEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// CheckPostCmds validates that the PostCmds format is valid.
+// CheckPostCmds validates that the PostCmds format is valid, either a list
+// of commands or a pipeline whose steps need known steps without cycles.
 func CheckPostCmds(postCmds string) error {
-	if postCmds == "" {
-		return nil
-	}
-	var tmp []exec.Cmd
-	return jsoniter.UnmarshalFromString(postCmds, &tmp)
+	_, err := config.ParsePipeline(postCmds)
+	return err
+}
+
+// CheckTemplate validates that the OutTmpl or SaveDir template parses and
+// only refers to known fields.
+func CheckTemplate(text string) error {
+	_, err := tmpl.RenderSaveDir(text, tmpl.Info{})
+	return err
 }
 
 // CheckSplitRule validates that the SplitRule format is valid.
@@ -119,6 +127,63 @@ func CheckSplitRule(splitRule string) error {
 	return jsoniter.UnmarshalFromString(splitRule, &tmp)
 }
 
+// CheckFilterRule validates that the FilterRule format is valid.
+func CheckFilterRule(filterRule string) error {
+	if filterRule == "" {
+		return nil
+	}
+	_, err := filter.Parse(filterRule)
+	return err
+}
+
+// CheckRelay validates that the Relay is a json list of rtmp, rtmps or srt
+// urls.
+func CheckRelay(relay string) error {
+	if relay == "" {
+		return nil
+	}
+	var targets []string
+	if err := jsoniter.UnmarshalFromString(relay, &targets); err != nil {
+		return err
+	}
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil {
+			return err
+		}
+		switch u.Scheme {
+		case "rtmp", "rtmps", "srt":
+		default:
+			return fmt.Errorf("relay scheme[%s] is not supported", u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("relay[%s] misses the host", target)
+		}
+	}
+	return nil
+}
+
+// CheckFfmpegProfile validates that the FfmpegProfile is one of the profiles
+// of the engine config, an empty one selecting none.
+func CheckFfmpegProfile(name string, cfg *config.Config) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := cfg.FfmpegProfiles[name]; !ok {
+		return fmt.Errorf("ffmpeg profile[%s] does not exist", name)
+	}
+	return nil
+}
+
+// CheckSchedule validates that the Schedule format is valid.
+func CheckSchedule(sched string) error {
+	if sched == "" {
+		return nil
+	}
+	_, err := schedule.Parse(sched)
+	return err
+}
+
 // CheckConfig validates that the Config format is valid.
 func CheckConfig(key, value string) error {
 	switch key {
@@ -0,0 +1,30 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/go-olive/olive/business/sys/validate"
+	"github.com/go-olive/olive/engine/config"
+)
+
+func TestCheckFfmpegProfile(t *testing.T) {
+	cfg := &config.Config{
+		FfmpegProfiles: map[string]config.FfmpegProfile{"hevc": {Container: "mkv"}},
+	}
+
+	tests := []struct {
+		name  string
+		cfg   *config.Config
+		valid bool
+	}{
+		{"", cfg, true},
+		{"hevc", cfg, true},
+		{"av1", cfg, false},
+		{"hevc", &config.Config{}, false},
+	}
+	for _, tt := range tests {
+		if err := validate.CheckFfmpegProfile(tt.name, tt.cfg); (err == nil) != tt.valid {
+			t.Errorf("Should validate profile[%s] as %v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
@@ -35,6 +35,8 @@ func (b *commandsBuilder) addAll() *commandsBuilder {
 		b.newServerCmd(),
 		b.newAdminCmd(),
 		b.newBiliupCmd(),
+		b.newFixCmd(),
+		b.newTmplCmd(),
 	)
 
 	return b
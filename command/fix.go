@@ -0,0 +1,55 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-olive/olive/foundation/flvfix"
+	"github.com/spf13/cobra"
+)
+
+var _ cmder = (*fixCmd)(nil)
+
+type fixCmd struct {
+	out string
+
+	*baseBuilderCmd
+}
+
+func (b *commandsBuilder) newFixCmd() *fixCmd {
+	cc := &fixCmd{}
+	cmd := &cobra.Command{
+		Use:   "fix [flv files]",
+		Short: "Fix repairs flv recordings so that they can be seeked.",
+		Long: `Fix repairs flv recordings so that they can be seeked.
+It normalizes the timestamps, drops the broken tags and writes the duration,
+filesize and keyframes metadata. Files are fixed in place unless an output is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cc.run(args)
+		},
+	}
+	cc.baseBuilderCmd = b.newBuilderCmd(cmd)
+
+	cmd.Flags().StringVarP(&cc.out, "output", "o", "", "output filepath, only for a single file")
+
+	return cc
+}
+
+func (c *fixCmd) run(files []string) error {
+	switch {
+	case len(files) == 0:
+		return errors.New("need to specify the flv files")
+	case c.out != "" && len(files) > 1:
+		return errors.New("output is only supported for a single file")
+	case c.out != "":
+		return flvfix.Fix(files[0], c.out)
+	}
+
+	for _, file := range files {
+		if err := flvfix.FixFile(file); err != nil {
+			return fmt.Errorf("fix %s: %w", file, err)
+		}
+		fmt.Println("fixed", file)
+	}
+	return nil
+}
@@ -7,7 +7,9 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"reflect"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/go-olive/olive/foundation/olivetv"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -60,15 +63,39 @@ func (b *commandsBuilder) newRunCmd() *runCmd {
 }
 
 type CompositeConfig struct {
-	Config config.Config
-	Shows  []kernel.Show
+	Config    config.Config
+	Shows     []kernel.Show
+	Streamers []kernel.Streamer
 }
 
 func (cfg *CompositeConfig) checkAndFix() {
 	cfg.Config.CheckAndFix()
+	for i := range cfg.Shows {
+		// shows without an ID are keyed by their room, for reloads to tell
+		// them apart.
+		if cfg.Shows[i].ID == "" {
+			cfg.Shows[i].ID = cfg.Shows[i].Platform + "-" + cfg.Shows[i].RoomID
+		}
+		cfg.Shows[i].CheckAndFix(&cfg.Config)
+	}
+}
+
+// enabledShows returns the shows to run.
+func (cfg *CompositeConfig) enabledShows() []kernel.Show {
+	var shows []kernel.Show
 	for _, show := range cfg.Shows {
-		show.CheckAndFix(&cfg.Config)
+		if show.Enable {
+			shows = append(shows, show)
+		}
 	}
+	return shows
+}
+
+// newKernel creates the kernel running the config.
+func (cfg *CompositeConfig) newKernel(log *logrus.Logger) *kernel.Kernel {
+	k := kernel.New(log, &cfg.Config, cfg.enabledShows())
+	k.HandleStreamer(cfg.Streamers...)
+	return k
 }
 
 func (cfg *CompositeConfig) autosave() error {
@@ -102,7 +129,11 @@ func (c *runCmd) run() error {
 	cfg.checkAndFix()
 
 	log := l.InitLogger(cfg.Config.LogDir)
-	k := kernel.New(log, &cfg.Config, cfg.Shows)
+	r := &reconciler{
+		log:     log,
+		desired: cfg,
+		k:       cfg.newKernel(log),
+	}
 
 	// =========================================================================
 	// Watch config change
@@ -110,14 +141,12 @@ func (c *runCmd) run() error {
 		log.Infof("config file[%s] is changed", e.Name)
 
 		compoCfg := new(CompositeConfig)
-		viper.Unmarshal(compoCfg)
-		compoCfg.checkAndFix()
-
-		cfgStr, _ := jsoniter.MarshalToString(compoCfg.Config)
-		k.UpdateConfig(config.CoreConfigKey, cfgStr)
-		for _, show := range compoCfg.Shows {
-			k.UpdateShow(show)
+		if err := viper.Unmarshal(compoCfg); err != nil {
+			log.Errorf("config file[%s] is not valid, ignored: %s", e.Name, err)
+			return
 		}
+		compoCfg.checkAndFix()
+		r.reconcile(compoCfg)
 	})
 	viper.WatchConfig()
 
@@ -125,7 +154,7 @@ func (c *runCmd) run() error {
 	// Start
 
 	go func() {
-		k.Run()
+		r.kernel().Run()
 	}()
 
 	// =========================================================================
@@ -139,6 +168,7 @@ func (c *runCmd) run() error {
 		Info("handle request")
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*10))
 	defer cancel()
+	k := r.kernel()
 	go func(ctx context.Context) {
 		k.Shutdown(ctx)
 	}(ctx)
@@ -151,6 +181,77 @@ func (c *runCmd) run() error {
 	}
 }
 
+// reconciler brings the running kernel to the desired state of the config
+// file as it changes.
+type reconciler struct {
+	log *logrus.Logger
+
+	mu      sync.Mutex
+	desired *CompositeConfig
+	k       *kernel.Kernel
+}
+
+func (r *reconciler) kernel() *kernel.Kernel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.k
+}
+
+// reconcile applies the difference between the desired state and the new
+// one. Core config changes which are only read on start restart the kernel,
+// the recordings in progress starting new sessions.
+func (r *reconciler) reconcile(next *CompositeConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields, restart := config.Changes(&r.desired.Config, &next.Config)
+	if restart {
+		r.log.Infof("config reconciled: core config changed %v, restarting the kernel", fields)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		r.k.Shutdown(ctx)
+
+		if next.Config.LogDir != r.desired.Config.LogDir {
+			r.log = l.InitLogger(next.Config.LogDir)
+		}
+		prerun := r.desired.Config.BiliupEnable && r.desired.Config.CookieFilepath != ""
+		r.desired = next
+		r.k = next.newKernel(r.log)
+		// the recordings left in the save dir were uploaded when the first
+		// kernel with biliup enabled started.
+		if prerun {
+			r.k.SkipPrerun()
+		}
+		go r.k.Run()
+		return
+	}
+
+	if len(fields) > 0 {
+		r.log.Infof("config reconciled: core config changed %v", fields)
+		cfgStr, _ := jsoniter.MarshalToString(next.Config)
+		// the kernel keeps pointing at the desired config, which this
+		// updates.
+		r.k.UpdateConfig(config.CoreConfigKey, cfgStr)
+	}
+
+	if !reflect.DeepEqual(r.desired.Streamers, next.Streamers) {
+		r.log.Infof("config reconciled: streamers changed")
+		for _, s := range r.desired.Streamers {
+			r.k.DeleteStreamer(s.ID)
+		}
+		r.k.HandleStreamer(next.Streamers...)
+		r.desired.Streamers = next.Streamers
+	}
+
+	diff := kernel.DiffShows(r.desired.Shows, next.Shows)
+	r.desired.Shows = next.Shows
+	if diff.Empty() {
+		return
+	}
+	r.log.Infof("config reconciled: shows %s", diff)
+	r.k.ApplyShows(diff)
+}
+
 func (c *runCmd) runWithURL() error {
 	cc, err := newCompositeConfig(c.roomURL, c.cookie)
 	if err != nil {
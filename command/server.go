@@ -17,6 +17,7 @@ import (
 	"github.com/go-olive/olive/app/tooling/olive-admin/commands"
 	"github.com/go-olive/olive/business/core/config"
 	"github.com/go-olive/olive/business/core/show"
+	"github.com/go-olive/olive/business/core/streamer"
 	"github.com/go-olive/olive/business/sys/database"
 	"github.com/go-olive/olive/engine/kernel"
 	l "github.com/go-olive/olive/engine/log"
@@ -62,7 +63,7 @@ func (b *commandsBuilder) newServerCmd() *serverCmd {
 	cmd := &cobra.Command{
 		Use:   "server",
 		Short: "Server provides olive-api support.",
-		Long:  "Server provides olive-api support.",
+		Long:  "Server provides olive-api support, running a single node. Clustering needs the olive-api service.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cc.run()
 		},
@@ -210,7 +211,18 @@ func (c *serverCmd) serve(log *zap.SugaredLogger, cfg cfg) (err error) {
 		return fmt.Errorf("query shows enabled: %w", err)
 	}
 
+	streamerCore := streamer.NewCore(log, db)
+	ctx3, cancel := context.WithTimeout(context.Background(), cfg.Web.ReadTimeout)
+	defer cancel()
+	streamers, err := streamerCore.Query(ctx3)
+	if err != nil {
+		return fmt.Errorf("query streamers: %w", err)
+	}
+
 	k := kernel.New(engineLogger, engineConfig, showsEnabled)
+	for _, s := range streamers {
+		k.HandleStreamer(s.Kernel())
+	}
 	go func() {
 		k.Run()
 	}()
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/olivetv"
+	"github.com/go-olive/olive/foundation/tmpl"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var _ cmder = (*tmplCmd)(nil)
+
+type tmplCmd struct {
+	cfgFilepath string
+	showID      string
+	roomURL     string
+	cookie      string
+	outTmpl     string
+	saveDir     string
+
+	*baseBuilderCmd
+}
+
+func (b *commandsBuilder) newTmplCmd() *tmplCmd {
+	cc := &tmplCmd{}
+	cmd := &cobra.Command{
+		Use:   "tmpl",
+		Short: "Tmpl renders the OutTmpl and SaveDir of a show against a live snap.",
+		Long: `Tmpl renders the OutTmpl and SaveDir of a show against a live snap.
+The show is either one of the config file, by its ID, or given by its room url.
+The templates are the ones of the show unless given, and their errors are
+reported instead of falling back to the default ones.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cc.run()
+		},
+	}
+	cc.baseBuilderCmd = b.newBuilderCmd(cmd)
+
+	cmd.Flags().StringVarP(&cc.cfgFilepath, "filepath", "f", "", "set config.toml filepath")
+	cmd.Flags().StringVarP(&cc.showID, "id", "i", "", "show ID in the config file")
+	cmd.Flags().StringVarP(&cc.roomURL, "url", "u", "", "room url")
+	cmd.Flags().StringVarP(&cc.cookie, "cookie", "c", "", "site cookie")
+	cmd.Flags().StringVarP(&cc.outTmpl, "outtmpl", "o", "", "OutTmpl to render")
+	cmd.Flags().StringVarP(&cc.saveDir, "savedir", "s", "", "SaveDir to render")
+
+	return cc
+}
+
+func (c *tmplCmd) run() error {
+	cfg := &CompositeConfig{Config: config.DefaultConfig}
+	if c.cfgFilepath != "" {
+		viper.SetConfigFile(c.cfgFilepath)
+		if err := viper.ReadInConfig(); err != nil {
+			return err
+		}
+		if err := viper.Unmarshal(cfg); err != nil {
+			return err
+		}
+		cfg.checkAndFix()
+	}
+
+	var show *kernel.Show
+	switch {
+	case c.showID != "":
+		for i := range cfg.Shows {
+			if cfg.Shows[i].ID == c.showID {
+				show = &cfg.Shows[i]
+				break
+			}
+		}
+		if show == nil {
+			return fmt.Errorf("show[%s] is not in the config file", c.showID)
+		}
+	case c.roomURL != "":
+		t, err := olivetv.NewWithURL(c.roomURL)
+		if err != nil {
+			return err
+		}
+		show = &kernel.Show{Platform: t.SiteID, RoomID: t.RoomID}
+	default:
+		return errors.New("need to specify [show id and config file] or [room url]")
+	}
+
+	if c.cookie != "" {
+		switch show.Platform {
+		case "douyin":
+			cfg.Config.DouyinCookie = c.cookie
+		case "kuaishou":
+			cfg.Config.KuaishouCookie = c.cookie
+		}
+	}
+	var streamer *kernel.Streamer
+	for i := range cfg.Streamers {
+		if cfg.Streamers[i].ID == show.StreamerID {
+			streamer = &cfg.Streamers[i]
+		}
+	}
+
+	p, err := kernel.PreviewTemplate(logrus.New(), &cfg.Config, *show, streamer, c.outTmpl, c.saveDir)
+	if p.Info.ShowID != "" {
+		printTemplateInfo(p.Info)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  %-14s%s\n", "OutFilename", p.OutFilename)
+	fmt.Printf("  %-14s%s\n", "SaveDir", p.SaveDir)
+	return nil
+}
+
+func printTemplateInfo(info tmpl.Info) {
+	for _, kv := range [][2]string{
+		{"ShowID", info.ShowID},
+		{"Platform", info.Platform},
+		{"SiteName", info.SiteName},
+		{"RoomID", info.RoomID},
+		{"StreamerName", info.StreamerName},
+		{"RoomName", info.RoomName},
+		{"Category", info.Category},
+		{"SessionID", info.SessionID},
+		{"SessionStart", info.SessionStart.Format(time.RFC3339)},
+		{"SegmentIndex", strconv.Itoa(info.SegmentIndex)},
+		{"Quality", info.Quality},
+		{"Parser", info.Parser},
+	} {
+		fmt.Printf("  %-14s%s\n", kv[0], kv[1])
+	}
+}
@@ -3,9 +3,10 @@ package config
 
 import (
 	"os"
-	"os/exec"
 	"time"
 
+	"github.com/go-olive/olive/foundation/olivetv"
+	"github.com/go-olive/olive/foundation/schedule"
 	"github.com/imdario/mergo"
 )
 
@@ -23,7 +24,13 @@ var DefaultConfig = Config{
 	SnapRestSeconds:          15,
 	SplitRestSeconds:         60,
 	CommanderPoolSize:        1,
-	ParserMonitorRestSeconds: 300,
+	ParserMonitorRestSeconds: 60,
+	ParserFallbackFailures:   3,
+	SessionGraceSeconds:      60,
+	SnapBackoffMaxSeconds:    600,
+	LikelyRestSeconds:        5,
+	DormantDays:              14,
+	DormantRestSeconds:       120,
 
 	// tv
 	DouyinCookie:   "default:__ac_nonce=06245c89100e7ab2dd536; __ac_signature=_02B4Z6wo00f01LjBMSAAAIDBwA.aJ.c4z1C44TWAAEx696;",
@@ -44,11 +51,33 @@ type Config struct {
 	SplitRestSeconds         uint
 	CommanderPoolSize        uint
 	ParserMonitorRestSeconds uint
+	ParserFallbackFailures   uint
+	SessionGraceSeconds      uint
+	PushWatchEnable          bool
+	MaxRecorders             uint
+	MaxRecordKbps            uint
+
+	// adaptive polling
+	HistoryFilepath       string
+	SnapBackoffMaxSeconds uint
+	LikelyRestSeconds     uint
+	DormantDays           uint
+	DormantRestSeconds    uint
 
 	// tv
 	DouyinCookie   string
 	KuaishouCookie string
 
+	// merge
+	MergeEnable    bool
+	MergeKeepParts bool
+
+	// ffmpeg
+	FfmpegProfiles map[string]FfmpegProfile
+
+	// external parsers
+	ExternalParsers map[string]ExternalParser
+
 	// biliup
 	BiliupEnable      bool
 	CookieFilepath    string
@@ -56,6 +85,34 @@ type Config struct {
 	MaxBytesPerSecond float64
 }
 
+// FfmpegProfile is a named set of ffmpeg arguments shows can select.
+// Options and header values are templates which may use .StreamURL, .Out
+// and .Referer.
+type FfmpegProfile struct {
+	InputOptions  []string
+	OutputOptions []string
+	// Container is one of mp4, mkv, ts and flv.
+	Container string
+	Headers   map[string]string
+}
+
+// Session identifies a live session of a show and the segment of it being
+// recorded.
+type Session struct {
+	ID           string
+	StartTime    time.Time
+	SegmentIndex int
+	// Parser is the parser recording the segment.
+	Parser string
+}
+
+// ExternalParser is an executable driven as a parser, shows select it by
+// name. See the parser package for the protocol it speaks.
+type ExternalParser struct {
+	Path string
+	Args []string
+}
+
 func (cfg *Config) CheckAndFix() {
 	wd, _ := os.Getwd()
 	DefaultConfig.LogDir = wd
@@ -73,18 +130,34 @@ type Bout interface {
 	GetPlatform() string
 	GetRoomID() string
 	GetStreamerName() string
-	GetOutFilename() string
+	GetOutFilename(Session) string
 	GetOutTmpl() string
-	GetSaveDir() string
+	GetSaveDir(Session) string
 	GetParser() string
-	GetPostCmds() []*exec.Cmd
+	GetParsers() []string
+	GetFfmpegProfile() *FfmpegProfile
+	GetExternalParser(name string) *ExternalParser
+	GetReferer() string
+	GetRelays() []string
+	GetAudioOnly() bool
+	GetPushWatch() bool
+	GetSchedule() *schedule.Schedule
+	GetSnapRestSeconds() uint
+	GetPriority() int
+	GetStreamer() *Streamer
+	GetPipeline() *Pipeline
 	SatisfySplitRule(time.Time, string) bool
+	SatisfyFilterRule() bool
+	RecheckFilterRule() bool
 
 	// show events
 	AddMonitor() error
 	RemoveMonitor() error
 	AddRecorder() error
 	RemoveRecorder() error
+	// DequeueRecorder takes the show out of the queue of the live shows
+	// waiting for a recorder, leaving a running recorder alone.
+	DequeueRecorder() error
 	RestartRecorder()
 
 	// tv
@@ -93,4 +166,5 @@ type Bout interface {
 	RoomName() (string, bool)
 	StreamerName() (string, bool)
 	SiteName() string
+	Watch(stop <-chan struct{}) (<-chan olivetv.LiveEvent, error)
 }
@@ -0,0 +1,30 @@
+package config
+
+import "reflect"
+
+// restartFields are read once, when the kernel starts.
+var restartFields = map[string]bool{
+	"LogDir":                   true,
+	"SplitRestSeconds":         true,
+	"CommanderPoolSize":        true,
+	"ParserMonitorRestSeconds": true,
+	"HistoryFilepath":          true,
+	"BiliupEnable":             true,
+	"CookieFilepath":           true,
+}
+
+// Changes returns the names of the fields which differ between the configs,
+// and whether any of them takes a kernel restart to apply.
+func Changes(old, new *Config) (fields []string, restart bool) {
+	va, vb := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	t := va.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			continue
+		}
+		name := t.Field(i).Name
+		fields = append(fields, name)
+		restart = restart || restartFields[name]
+	}
+	return fields, restart
+}
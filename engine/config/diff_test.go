@@ -0,0 +1,44 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-olive/olive/engine/config"
+)
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		change      func(*config.Config)
+		wantFields  []string
+		wantRestart bool
+	}{
+		{"nothing", func(*config.Config) {}, nil, false},
+		{"read on each use", func(c *config.Config) { c.SaveDir = "/data" }, []string{"SaveDir"}, false},
+		{"map", func(c *config.Config) {
+			c.FfmpegProfiles = map[string]config.FfmpegProfile{"copy": {Container: "mp4"}}
+		}, []string{"FfmpegProfiles"}, false},
+		{"read on start", func(c *config.Config) { c.CommanderPoolSize = 4 }, []string{"CommanderPoolSize"}, true},
+		{"biliup", func(c *config.Config) { c.BiliupEnable = true }, []string{"BiliupEnable"}, true},
+		{"both", func(c *config.Config) {
+			c.SaveDir = "/data"
+			c.LogDir = "/logs"
+		}, []string{"LogDir", "SaveDir"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := config.DefaultConfig
+			new := config.DefaultConfig
+			tt.change(&new)
+
+			fields, restart := config.Changes(&old, &new)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("got fields %v, want %v", fields, tt.wantFields)
+			}
+			if restart != tt.wantRestart {
+				t.Errorf("got restart %v, want %v", restart, tt.wantRestart)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Pipeline is the post processing of a recorded file, a graph of steps run
+// as soon as the steps they need are done, so that independent branches run
+// at the same time.
+//
+// PostCmds is either a pipeline object, or a list of commands run one after
+// another as before:
+//
+//	{"Steps":[
+//	  {"Name":"fix","Path":"olivefix"},
+//	  {"Name":"bili","Path":"olivebiliup","Needs":["fix"],"If":{"MinFileSize":100000000}},
+//	  {"Name":"s3","Path":"oliveshell","Args":["rclone","copy","$FILE_PATH","s3:olive"],"Needs":["fix"]},
+//	  {"Name":"trash","Path":"olivetrash","Final":true}
+//	]}
+type Pipeline struct {
+	Steps []Step
+}
+
+// Step is a post command of a pipeline.
+type Step struct {
+	Name string
+	Path string
+	Args []string
+	Env  []string
+	Dir  string
+	// Needs are the names of the steps to succeed or be skipped first.
+	Needs []string
+	// If skips the step unless the file satisfies it.
+	If *Condition
+	// Final steps run once every other step is done, and only when none of
+	// them failed, e.g. to clean the file up after the uploads.
+	Final bool
+}
+
+// Condition is what a file must satisfy for a step to run, zero fields
+// being ignored.
+type Condition struct {
+	MinFileSize int64
+	MaxFileSize int64
+	MinDuration string
+	MaxDuration string
+	// SessionEnd runs the step only on the files handed over once the live
+	// session ended, which are the last segments of the sessions, or the
+	// merged files with MergeEnable.
+	SessionEnd bool
+}
+
+// File is what the conditions of the steps are checked against.
+type File struct {
+	Size       int64
+	Duration   time.Duration
+	SessionEnd bool
+}
+
+// Cmd returns the command of the step.
+func (s Step) Cmd() *exec.Cmd {
+	return &exec.Cmd{
+		Path: s.Path,
+		Args: s.Args,
+		Env:  s.Env,
+		Dir:  s.Dir,
+	}
+}
+
+// Satisfy reports whether the file satisfies the condition, nil being
+// satisfied by any file.
+func (c *Condition) Satisfy(f File) bool {
+	if c == nil {
+		return true
+	}
+	if c.MinFileSize > 0 && f.Size < c.MinFileSize {
+		return false
+	}
+	if c.MaxFileSize > 0 && f.Size > c.MaxFileSize {
+		return false
+	}
+	if d, err := time.ParseDuration(c.MinDuration); err == nil && f.Duration < d {
+		return false
+	}
+	if d, err := time.ParseDuration(c.MaxDuration); err == nil && f.Duration > d {
+		return false
+	}
+	if c.SessionEnd && !f.SessionEnd {
+		return false
+	}
+	return true
+}
+
+func (c *Condition) check() error {
+	if c == nil {
+		return nil
+	}
+	if c.MinFileSize < 0 || c.MaxFileSize < 0 {
+		return errors.New("negative file size")
+	}
+	if c.MaxFileSize > 0 && c.MinFileSize > c.MaxFileSize {
+		return errors.New("MinFileSize is greater than MaxFileSize")
+	}
+	var min, max time.Duration
+	for _, v := range []struct {
+		s string
+		d *time.Duration
+	}{{c.MinDuration, &min}, {c.MaxDuration, &max}} {
+		if v.s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v.s)
+		if err != nil {
+			return err
+		}
+		*v.d = d
+	}
+	if max > 0 && min > max {
+		return errors.New("MinDuration is greater than MaxDuration")
+	}
+	return nil
+}
+
+// ParsePipeline parses and checks the PostCmds, an empty one having no steps.
+// The steps of a list of commands are named after their position and each
+// needs the previous one.
+func ParsePipeline(postCmds string) (*Pipeline, error) {
+	postCmds = strings.TrimSpace(postCmds)
+	if postCmds == "" {
+		return &Pipeline{}, nil
+	}
+
+	var p Pipeline
+	if strings.HasPrefix(postCmds, "[") {
+		if err := jsoniter.UnmarshalFromString(postCmds, &p.Steps); err != nil {
+			return nil, err
+		}
+		for i := range p.Steps {
+			p.Steps[i].Name = strconv.Itoa(i + 1)
+			if i > 0 {
+				p.Steps[i].Needs = []string{p.Steps[i-1].Name}
+			}
+		}
+	} else if err := jsoniter.UnmarshalFromString(postCmds, &p); err != nil {
+		return nil, err
+	}
+
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// check makes sure the steps are uniquely named and have a path, only need
+// known steps and do not need each other in a cycle.
+func (p *Pipeline) check() error {
+	steps := make(map[string]Step, len(p.Steps))
+	for _, s := range p.Steps {
+		if s.Name == "" {
+			return errors.New("step without a name")
+		}
+		if s.Path == "" {
+			return fmt.Errorf("step[%s] without a path", s.Name)
+		}
+		if _, ok := steps[s.Name]; ok {
+			return fmt.Errorf("step[%s] is defined twice", s.Name)
+		}
+		if err := s.If.check(); err != nil {
+			return fmt.Errorf("step[%s]: %w", s.Name, err)
+		}
+		steps[s.Name] = s
+	}
+
+	for _, s := range p.Steps {
+		for _, need := range s.Needs {
+			dep, ok := steps[need]
+			if !ok {
+				return fmt.Errorf("step[%s] needs unknown step[%s]", s.Name, need)
+			}
+			if dep.Final && !s.Final {
+				return fmt.Errorf("step[%s] needs final step[%s]", s.Name, need)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int, len(p.Steps))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("step[%s] needs itself", name)
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		for _, need := range steps[name].Needs {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+	for _, s := range p.Steps {
+		if err := visit(s.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Empty reports whether the pipeline has no steps.
+func (p *Pipeline) Empty() bool {
+	return p == nil || len(p.Steps) == 0
+}
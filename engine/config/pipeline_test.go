@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		postCmds string
+		ok       bool
+	}{
+		{"empty", "", true},
+		{"list", `[{"Path":"olivebiliup"},{"Path":"olivetrash"}]`, true},
+		{"graph", `{"Steps":[{"Name":"a","Path":"olivefix"},{"Name":"b","Path":"olivebiliup","Needs":["a"]},{"Name":"c","Path":"oliveshell","Needs":["a"]},{"Name":"d","Path":"olivetrash","Final":true}]}`, true},
+		{"unnamed", `{"Steps":[{"Path":"olivefix"}]}`, false},
+		{"no path", `{"Steps":[{"Name":"a","Path":"olivefix"},{"Name":"b","Needs":["a"]}]}`, false},
+		{"no path in list", `[{"Path":"olivebiliup"},{"Args":["x"]}]`, false},
+		{"twice", `{"Steps":[{"Name":"a","Path":"olivefix"},{"Name":"a","Path":"olivefix"}]}`, false},
+		{"unknown", `{"Steps":[{"Name":"a","Path":"olivefix","Needs":["b"]}]}`, false},
+		{"cycle", `{"Steps":[{"Name":"a","Path":"olivefix","Needs":["c"]},{"Name":"b","Path":"olivefix","Needs":["a"]},{"Name":"c","Path":"olivefix","Needs":["b"]}]}`, false},
+		{"needs final", `{"Steps":[{"Name":"a","Path":"olivetrash","Final":true},{"Name":"b","Path":"olivefix","Needs":["a"]}]}`, false},
+		{"bad duration", `{"Steps":[{"Name":"a","Path":"olivefix","If":{"MinDuration":"1 hour"}}]}`, false},
+		{"bad size", `{"Steps":[{"Name":"a","Path":"olivefix","If":{"MinFileSize":10,"MaxFileSize":1}}]}`, false},
+		{"bad json", `{"Steps":`, false},
+	}
+	for _, tt := range tests {
+		_, err := config.ParsePipeline(tt.postCmds)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got err %v", tt.name, err)
+		}
+	}
+
+	p, err := config.ParsePipeline(`[{"Path":"olivebiliup"},{"Path":"olivetrash"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Steps) != 2 || len(p.Steps[1].Needs) != 1 || p.Steps[1].Needs[0] != p.Steps[0].Name {
+		t.Errorf("list is not run in order: %+v", p.Steps)
+	}
+}
+
+func TestConditionSatisfy(t *testing.T) {
+	c := &config.Condition{MinFileSize: 100, MinDuration: "10m", SessionEnd: true}
+	tests := []struct {
+		file config.File
+		want bool
+	}{
+		{config.File{Size: 200, Duration: time.Hour, SessionEnd: true}, true},
+		{config.File{Size: 50, Duration: time.Hour, SessionEnd: true}, false},
+		{config.File{Size: 200, Duration: time.Minute, SessionEnd: true}, false},
+		{config.File{Size: 200, Duration: time.Hour}, false},
+	}
+	for i, tt := range tests {
+		if got := c.Satisfy(tt.file); got != tt.want {
+			t.Errorf("%d: got %v, want %v", i, got, tt.want)
+		}
+	}
+	if !(*config.Condition)(nil).Satisfy(config.File{}) {
+		t.Error("nil condition is not satisfied")
+	}
+}
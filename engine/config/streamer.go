@@ -0,0 +1,43 @@
+package config
+
+// Set of policies deciding which shows of a streamer are recorded when
+// several of them are live at once.
+const (
+	// StreamerPolicyAll records every live show.
+	StreamerPolicyAll = "all"
+	// StreamerPolicyPreferred records the live show of the highest priority
+	// only, switching to a higher one as soon as it goes live.
+	StreamerPolicyPreferred = "preferred"
+	// StreamerPolicyFallback records one live show at a time, keeping it
+	// until it ends, then falling back to the next live one by priority.
+	StreamerPolicyFallback = "fallback"
+)
+
+// Streamer is the person behind several shows, usually on different
+// platforms, which are recorded following its policy. The shows refer to it
+// by ID and are ordered by their priority.
+type Streamer struct {
+	ID     string `json:"streamer_id"`
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+// ValidStreamerPolicy reports whether the policy is known, empty meaning
+// StreamerPolicyAll.
+func ValidStreamerPolicy(policy string) bool {
+	switch policy {
+	case "", StreamerPolicyAll, StreamerPolicyPreferred, StreamerPolicyFallback:
+		return true
+	default:
+		return false
+	}
+}
+
+// HistoryKey returns the key the live history of the show is kept under,
+// shared by the shows of a streamer.
+func HistoryKey(b Bout) string {
+	if s := b.GetStreamer(); s != nil {
+		return "streamer:" + s.ID
+	}
+	return string(b.GetID())
+}
@@ -7,8 +7,6 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var SharedManager *Manager
-
 func NewManager(log *logrus.Logger) *Manager {
 	return &Manager{
 		savers:             make(map[enum.DispatcherTypeID]Dispatcher),
@@ -6,14 +6,16 @@ var EventType = struct {
 	AddMonitor    EventTypeID
 	RemoveMonitor EventTypeID
 
-	AddRecorder    EventTypeID
-	RemoveRecorder EventTypeID
+	AddRecorder     EventTypeID
+	RemoveRecorder  EventTypeID
+	DequeueRecorder EventTypeID
 }{
 	AddMonitor:    101,
 	RemoveMonitor: 102,
 
-	AddRecorder:    201,
-	RemoveRecorder: 202,
+	AddRecorder:     201,
+	RemoveRecorder:  202,
+	DequeueRecorder: 203,
 }
 
 func (et EventTypeID) String() string {
@@ -26,6 +28,8 @@ func (et EventTypeID) String() string {
 		return "add recorder"
 	case EventType.RemoveRecorder:
 		return "remove recorder"
+	case EventType.DequeueRecorder:
+		return "dequeue recorder"
 	}
 	return "undefined"
 }
@@ -0,0 +1,192 @@
+// Package events publishes what happens to the shows of a kernel, for
+// embedders to observe.
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+)
+
+// Type is the kind of an event.
+type Type string
+
+const (
+	// MonitorStart is published when a show starts being monitored.
+	MonitorStart Type = "monitor_start"
+	// MonitorStop is published when a show stops being monitored.
+	MonitorStop Type = "monitor_stop"
+	// LiveStart is published when the monitor sees the room go live.
+	LiveStart Type = "live_start"
+	// LiveEnd is published when the monitor sees the room go offline.
+	LiveEnd Type = "live_end"
+	// Queued is published when a live show waits for a recorder, Message
+	// telling why.
+	Queued Type = "queued"
+	// RecordStart is published when a segment starts recording to Out.
+	RecordStart Type = "record_start"
+	// RecordStop is published when a segment stops recording, Message
+	// holding the parser error if any.
+	RecordStop Type = "record_stop"
+	// SessionEnd is published when a live session ends, Out being the
+	// merged file if the segments were merged, the last segment otherwise.
+	SessionEnd Type = "session_end"
+	// Split is published when a recording is restarted by its split rule.
+	Split Type = "split"
+	// ParserRestart is published when a recording is restarted because its
+	// parser stalled.
+	ParserRestart Type = "parser_restart"
+	// StepStart is published when a post processing Step starts on Out.
+	StepStart Type = "step_start"
+	// StepEnd is published when a post processing Step ends, Message holding
+	// the error if it failed.
+	StepEnd Type = "step_end"
+	// StepSkip is published when a post processing Step is skipped, its
+	// condition not being satisfied.
+	StepSkip Type = "step_skip"
+)
+
+// backlog is the number of past events kept for Replay.
+const backlog = 1024
+
+// Event is something which happened to a show.
+type Event struct {
+	// ID increases with every event published on the bus, starting over
+	// with each bus, see Bus.Epoch.
+	ID        uint64    `json:"id"`
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	ShowID    string    `json:"show_id"`
+	Platform  string    `json:"platform"`
+	RoomID    string    `json:"room_id"`
+	SessionID string    `json:"session_id,omitempty"`
+	Out       string    `json:"out,omitempty"`
+	Step      string    `json:"step,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// New returns an event of the show happening now.
+func New(typ Type, bout config.Bout) Event {
+	return Event{
+		Type:     typ,
+		Time:     time.Now(),
+		ShowID:   string(bout.GetID()),
+		Platform: bout.GetPlatform(),
+		RoomID:   bout.GetRoomID(),
+	}
+}
+
+// Bus fans the events out to the subscribers. Publishing never blocks, the
+// events are dropped for the subscribers which fall behind. The last events
+// are kept in a ring for the subscribers to catch up with.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[chan Event]struct{}
+	closed bool
+
+	epoch  string
+	lastID uint64
+	ring   []Event
+	next   int
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs:  make(map[chan Event]struct{}),
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		ring:  make([]Event, 0, backlog),
+	}
+}
+
+// Epoch tells the bus apart from the ones of previous runs, whose event IDs
+// it reuses.
+func (b *Bus) Epoch() string {
+	return b.epoch
+}
+
+// Publish numbers the event and sends it to every subscriber. A nil bus
+// drops it.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	e.ID = b.lastID
+	if len(b.ring) < cap(b.ring) {
+		b.ring = append(b.ring, e)
+	} else {
+		b.ring[b.next] = e
+		b.next = (b.next + 1) % len(b.ring)
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of the events published from now on, buffering
+// up to size events, and the func to unsubscribe. The channel is closed on
+// unsubscribing or when the bus closes.
+func (b *Bus) Subscribe(size int) (<-chan Event, func()) {
+	ch := make(chan Event, size)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subs[ch]; ok {
+				delete(b.subs, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Replay returns the kept events published after the one of lastID, oldest
+// first, and the ID it replayed after. All of them are returned when lastID
+// is unknown to the bus, i.e. given by a client of another epoch, the ID
+// being 0 then.
+func (b *Bus) Replay(epoch string, lastID uint64) ([]Event, uint64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if epoch != b.epoch || lastID > b.lastID {
+		lastID = 0
+	}
+	var list []Event
+	for i := range b.ring {
+		e := b.ring[(b.next+i)%len(b.ring)]
+		if e.ID > lastID {
+			list = append(list, e)
+		}
+	}
+	return list, lastID
+}
+
+// Close closes the channels of every subscriber.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
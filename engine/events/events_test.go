@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/go-olive/olive/engine/events"
+)
+
+func TestBus(t *testing.T) {
+	bus := events.NewBus()
+
+	fast, cancelFast := bus.Subscribe(4)
+	defer cancelFast()
+	slow, _ := bus.Subscribe(1)
+
+	for _, typ := range []events.Type{events.LiveStart, events.RecordStart, events.RecordStop} {
+		bus.Publish(events.Event{Type: typ, ShowID: "a"})
+	}
+
+	for _, want := range []events.Type{events.LiveStart, events.RecordStart, events.RecordStop} {
+		if e := <-fast; e.Type != want {
+			t.Errorf("Should receive %s, got %s", want, e.Type)
+		}
+	}
+	if e := <-slow; e.Type != events.LiveStart {
+		t.Errorf("Should receive the first event, got %s", e.Type)
+	}
+
+	bus.Close()
+	if _, ok := <-slow; ok {
+		t.Error("Should drop the events past the buffer and close on Close")
+	}
+	if _, ok := <-fast; ok {
+		t.Error("Should close the channels on Close")
+	}
+	cancelFast()
+
+	late, _ := bus.Subscribe(1)
+	if _, ok := <-late; ok {
+		t.Error("Should close the channels subscribed after Close")
+	}
+}
+
+func TestBusReplay(t *testing.T) {
+	bus := events.NewBus()
+	defer bus.Close()
+
+	const n = 1500
+	for i := 0; i < n; i++ {
+		bus.Publish(events.Event{Type: events.LiveStart, ShowID: "a"})
+	}
+
+	list, _ := bus.Replay(bus.Epoch(), n-3)
+	if len(list) != 3 || list[0].ID != n-2 || list[2].ID != n {
+		t.Errorf("Should replay the events after the last ID, got %d events", len(list))
+	}
+
+	all, _ := bus.Replay(bus.Epoch(), 0)
+	if len(all) == 0 || len(all) == n {
+		t.Fatalf("Should keep a bounded number of events, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].ID != all[i-1].ID+1 {
+			t.Fatalf("Should replay the events in order, got %d after %d", all[i].ID, all[i-1].ID)
+		}
+	}
+	if all[len(all)-1].ID != n {
+		t.Errorf("Should keep the last events, got %d last", all[len(all)-1].ID)
+	}
+
+	if got, id := bus.Replay(bus.Epoch(), n+10); len(got) != len(all) || id != 0 {
+		t.Errorf("Should replay every event after 0 for an unknown ID, got %d after %d", len(got), id)
+	}
+
+	// a bus of a previous run numbered its events from 1 again.
+	prev := events.NewBus()
+	defer prev.Close()
+	if prev.Epoch() == bus.Epoch() {
+		t.Fatal("Should tell the buses apart")
+	}
+	if got, id := bus.Replay(prev.Epoch(), n-3); len(got) != len(all) || id != 0 {
+		t.Errorf("Should replay every event after 0 for another epoch, got %d after %d", len(got), id)
+	}
+}
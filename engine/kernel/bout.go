@@ -2,29 +2,26 @@
 package kernel
 
 import (
-	"bytes"
 	"fmt"
-	"os/exec"
 	"strings"
-	"text/template"
 	"time"
 
-	"github.com/go-dora/filenamify"
 	"github.com/go-olive/olive/engine/config"
 	"github.com/go-olive/olive/engine/dispatcher"
 	"github.com/go-olive/olive/engine/enum"
-	l "github.com/go-olive/olive/engine/log"
-	"github.com/go-olive/olive/engine/util"
+	"github.com/go-olive/olive/foundation/filter"
 	"github.com/go-olive/olive/foundation/olivetv"
+	"github.com/go-olive/olive/foundation/schedule"
 	"github.com/go-olive/olive/foundation/syncmap"
+	"github.com/go-olive/olive/foundation/tmpl"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
 )
 
 var (
 	_ config.Bout = (*bout)(nil)
 
-	defaultOutTmpl = template.Must(template.New("filename").Funcs(util.NameFuncMap).
-			Parse(`[{{ .StreamerName }}][{{ .RoomName }}][{{ now | date "2006-01-02 15-04-05"}}].flv`))
+	defaultOutTmpl = `[{{ .StreamerName }}][{{ .RoomName }}][{{ now | date "2006-01-02 15-04-05"}}].flv`
 )
 
 // bout represents one live show.
@@ -34,10 +31,15 @@ type bout struct {
 	showMap *syncmap.RWMap[string, Show]
 	cfg     *config.Config
 
+	streamerMap *syncmap.RWMap[string, Streamer]
+
+	log        *logrus.Logger
+	dispatcher *dispatcher.Manager
+
 	*olivetv.TV
 }
 
-func NewBout(showID string, showMap *syncmap.RWMap[string, Show], cfg *config.Config) (*bout, error) {
+func NewBout(log *logrus.Logger, d *dispatcher.Manager, showID string, showMap *syncmap.RWMap[string, Show], streamerMap *syncmap.RWMap[string, Streamer], cfg *config.Config) (*bout, error) {
 	showCfg, ok := showMap.Get(showID)
 	if !ok {
 		return nil, fmt.Errorf("show[ID = %s] config does not exist", showID)
@@ -53,6 +55,11 @@ func NewBout(showID string, showMap *syncmap.RWMap[string, Show], cfg *config.Co
 		show:    showCfg,
 		showMap: showMap,
 		cfg:     cfg,
+
+		streamerMap: streamerMap,
+
+		log:        log,
+		dispatcher: d,
 	}, nil
 }
 
@@ -105,7 +112,18 @@ func (b *bout) GetRoomID() string {
 func (b *bout) GetStreamerName() string {
 	b.Snap()
 
+	return b.streamerName()
+}
+
+// streamerName returns the streamer name of the show, of its streamer, or
+// the one last snapped.
+func (b *bout) streamerName() string {
 	streamerName := b.show.StreamerName
+	if streamerName == "" {
+		if s := b.GetStreamer(); s != nil {
+			streamerName = s.Name
+		}
+	}
 	if streamerName == "" {
 		streamerName, _ = b.StreamerName()
 	}
@@ -125,93 +143,182 @@ func (b *bout) GetOutTmpl() string {
 }
 
 // GetOutFilename generate output filename
-func (b *bout) GetOutFilename() (out string) {
+func (b *bout) GetOutFilename(session config.Session) string {
+	info := b.templateInfo(session)
+	out, err := tmpl.RenderOutTmpl(b.show.OutTmpl, info)
+	if err != nil {
+		b.log.WithFields(logrus.Fields{
+			"pf": b.SiteID,
+			"id": b.RoomID,
+		}).Errorf("render OutTmpl failed, using the default one: %s", err.Error())
+		out, _ = tmpl.RenderOutTmpl(defaultOutTmpl, info)
+	}
+	return out
+}
+
+func (b *bout) GetParser() string {
 	b.Refresh()
 
-	roomName, _ := b.RoomName()
+	return b.show.Parser
+}
 
-	// generate template info
-	info := &struct {
-		StreamerName string
-		RoomName     string
-		SiteName     string
-	}{
-		StreamerName: b.GetStreamerName(),
-		RoomName:     roomName,
-		SiteName:     b.SiteName(),
-	}
+// GetFfmpegProfile returns the ffmpeg profile selected by the show, nil if
+// none is selected or it does not exist.
+func (b *bout) GetFfmpegProfile() *config.FfmpegProfile {
+	b.Refresh()
 
-	// generate file name
-	tmpl, err := template.New("user_defined_filename").Funcs(util.NameFuncMap).Parse(b.show.OutTmpl)
-	if err != nil {
-		l.Logger.Error(err)
-		tmpl = defaultOutTmpl
+	name := b.show.FfmpegProfile
+	if name == "" {
+		return nil
+	}
+	profile, ok := b.cfg.FfmpegProfiles[name]
+	if !ok {
+		b.log.Errorf("ffmpeg profile[%s] does not exist", name)
+		return nil
 	}
+	return &profile
+}
+
+// GetRelays returns the urls the show is relayed to while recording.
+func (b *bout) GetRelays() []string {
+	b.Refresh()
 
-	buf := new(bytes.Buffer)
-	if err := tmpl.Execute(buf, info); err != nil {
-		l.Logger.Error(err)
-		const format = "2006-01-02 15-04-05"
-		out = fmt.Sprintf("[%s][%s][%s].flv", info.StreamerName, roomName, time.Now().Format(format))
-	} else {
-		out = buf.String()
+	if b.show.Relay == "" {
+		return nil
+	}
+	var relays []string
+	if err := jsoniter.UnmarshalFromString(b.show.Relay, &relays); err != nil {
+		b.log.Errorf("relay[%s] is not valid: %s", b.show.Relay, err)
+		return nil
 	}
+	return relays
+}
 
-	out = filenamify.FilenamifyMustCompile(out)
+func (b *bout) GetAudioOnly() bool {
+	b.Refresh()
 
-	return
+	return b.show.AudioOnly
 }
 
-func (b *bout) GetParser() string {
+// GetPushWatch reports whether the show is watched for pushed lives on top
+// of being polled, either opting in itself or by PushWatchEnable.
+func (b *bout) GetPushWatch() bool {
 	b.Refresh()
 
-	return b.show.Parser
+	return b.show.PushWatch || b.cfg.PushWatchEnable
 }
 
-// GetSaveDir generate save dir
-func (b *bout) GetSaveDir() string {
+// GetParsers returns the parsers of the show in the order they are tried,
+// the Parser setting being a comma separated list.
+func (b *bout) GetParsers() []string {
+	b.Refresh()
+
+	var parsers []string
+	for _, name := range strings.Split(b.show.Parser, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			parsers = append(parsers, name)
+		}
+	}
+	return parsers
+}
+
+// GetExternalParser returns the external parser of the given name, nil if
+// none is declared.
+func (b *bout) GetExternalParser(name string) *config.ExternalParser {
 	b.Refresh()
 
-	defaultSaveDir := strings.TrimSpace(b.show.SaveDir)
+	ext, ok := b.cfg.ExternalParsers[name]
+	if !ok {
+		return nil
+	}
+	return &ext
+}
+
+// GetSchedule returns the recording windows of the show, nil if it records
+// whenever it is live.
+func (b *bout) GetSchedule() *schedule.Schedule {
+	b.Refresh()
 
-	roomName, _ := b.RoomName()
-	// generate template info
-	info := &struct {
-		StreamerName string
-		RoomName     string
-		SiteName     string
-	}{
-		StreamerName: b.GetStreamerName(),
-		RoomName:     roomName,
-		SiteName:     b.SiteName(),
-	}
-
-	tmpl, err := template.New("user_defined_savedir_tmpl").Funcs(util.NameFuncMap).Parse(b.show.SaveDir)
+	if b.show.Schedule == "" {
+		return nil
+	}
+	s, err := schedule.Parse(b.show.Schedule)
 	if err != nil {
-		l.Logger.Error(err)
-		return defaultSaveDir
+		b.log.Errorf("schedule[%s] is not valid: %s", b.show.Schedule, err)
+		return nil
+	}
+	return s
+}
+
+// GetSnapRestSeconds returns the polling interval of the show, falling back
+// to the global one.
+func (b *bout) GetSnapRestSeconds() uint {
+	b.Refresh()
+
+	if b.show.SnapRestSeconds > 0 {
+		return b.show.SnapRestSeconds
+	}
+	return b.cfg.SnapRestSeconds
+}
+
+// GetPriority returns the recording priority of the show, higher ones
+// pre-empting lower ones when the recorder limits are reached.
+func (b *bout) GetPriority() int {
+	b.Refresh()
+
+	return b.show.Priority
+}
+
+// GetStreamer returns the streamer the show belongs to, nil if none.
+func (b *bout) GetStreamer() *config.Streamer {
+	b.Refresh()
+
+	if b.show.StreamerID == "" {
+		return nil
 	}
-	buf := new(bytes.Buffer)
-	if err := tmpl.Execute(buf, info); err != nil {
-		l.Logger.Error(err)
-		return defaultSaveDir
+	s, ok := b.streamerMap.Get(b.show.StreamerID)
+	if !ok {
+		return nil
 	}
+	return &s
+}
+
+func (b *bout) GetReferer() string {
+	b.Refresh()
+
+	return b.RoomURL()
+}
 
-	return buf.String()
+// GetSaveDir generate save dir
+func (b *bout) GetSaveDir(session config.Session) string {
+	info := b.templateInfo(session)
+	dir, err := tmpl.RenderSaveDir(b.show.SaveDir, info)
+	if err != nil {
+		b.log.WithFields(logrus.Fields{
+			"pf": b.SiteID,
+			"id": b.RoomID,
+		}).Errorf("render SaveDir failed, using it as is: %s", err.Error())
+		return strings.TrimSpace(b.show.SaveDir)
+	}
+	return dir
 }
 
-func (b *bout) GetPostCmds() []*exec.Cmd {
+func (b *bout) GetPipeline() *config.Pipeline {
 	b.Refresh()
 
 	s, ok := b.showMap.Get(b.showID)
 	if !ok {
 		return nil
 	}
-	var cmds []*exec.Cmd
-	if err := jsoniter.UnmarshalFromString(s.PostCmds, &cmds); err != nil {
+	p, err := config.ParsePipeline(s.PostCmds)
+	if err != nil {
+		b.log.WithFields(logrus.Fields{
+			"pf": s.Platform,
+			"id": s.RoomID,
+		}).Errorf("invalid post cmds, %s", err.Error())
 		return nil
 	}
-	return cmds
+	return p
 }
 
 func (b *bout) SatisfySplitRule(startTime time.Time, out string) bool {
@@ -224,28 +331,65 @@ func (b *bout) SatisfySplitRule(startTime time.Time, out string) bool {
 	return sr.Satisfy(startTime, out)
 }
 
+// SatisfyFilterRule reports whether the room, as of the last snap, is to be
+// recorded. Shows without a valid rule are always recorded.
+func (b *bout) SatisfyFilterRule() bool {
+	b.Refresh()
+
+	if b.show.FilterRule == "" {
+		return true
+	}
+	fr, err := filter.Parse(b.show.FilterRule)
+	if err != nil {
+		return true
+	}
+	roomName, _ := b.RoomName()
+	category, _ := b.Category()
+	return fr.Satisfy(roomName, category, b.Tags())
+}
+
+// RecheckFilterRule reports whether the filter rule is evaluated while
+// recording too.
+func (b *bout) RecheckFilterRule() bool {
+	b.Refresh()
+
+	if b.show.FilterRule == "" {
+		return false
+	}
+	fr, err := filter.Parse(b.show.FilterRule)
+	if err != nil {
+		return false
+	}
+	return fr.Recheck
+}
+
 func (b *bout) AddMonitor() error {
 	b.Refresh()
 
 	e := dispatcher.NewEvent(enum.EventType.AddMonitor, b)
-	return dispatcher.SharedManager.Dispatch(e)
+	return b.dispatcher.Dispatch(e)
 }
 
 func (b *bout) RemoveMonitor() error {
 	e := dispatcher.NewEvent(enum.EventType.RemoveMonitor, b)
-	return dispatcher.SharedManager.Dispatch(e)
+	return b.dispatcher.Dispatch(e)
 }
 
 func (b *bout) AddRecorder() error {
 	b.Refresh()
 
 	e := dispatcher.NewEvent(enum.EventType.AddRecorder, b)
-	return dispatcher.SharedManager.Dispatch(e)
+	return b.dispatcher.Dispatch(e)
 }
 
 func (b *bout) RemoveRecorder() error {
 	e := dispatcher.NewEvent(enum.EventType.RemoveRecorder, b)
-	return dispatcher.SharedManager.Dispatch(e)
+	return b.dispatcher.Dispatch(e)
+}
+
+func (b *bout) DequeueRecorder() error {
+	e := dispatcher.NewEvent(enum.EventType.DequeueRecorder, b)
+	return b.dispatcher.Dispatch(e)
 }
 
 func (b *bout) RestartRecorder() {
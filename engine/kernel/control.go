@@ -0,0 +1,148 @@
+package kernel
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrShowNotRunning is returned when controlling a show the kernel does not
+// run, because it does not exist or is disabled.
+var ErrShowNotRunning = errors.New("show is not running")
+
+// pause is a show suspended until a time.
+type pause struct {
+	until time.Time
+	timer *time.Timer
+}
+
+// ForceStart starts recording the show right away, whatever the monitor
+// makes of its live status, schedule and filter rule. A paused show is
+// resumed, a recording one left as is. The recorder limits still apply.
+func (k *Kernel) ForceStart(showID string) error {
+	bout, err := k.bout(showID)
+	if err != nil {
+		return err
+	}
+	k.resume(showID)
+	if _, ok := k.recorderManager.Recorder(bout.GetID()); ok {
+		return nil
+	}
+
+	k.showLog(bout).Info("force start")
+	return bout.AddRecorder()
+}
+
+// ForceStop ends the recording of the show but keeps monitoring it, skipping
+// the live session until the room goes offline and live again. The skip does
+// not outlive the kernel, a restart recording the session again.
+func (k *Kernel) ForceStop(showID string) error {
+	bout, err := k.bout(showID)
+	if err != nil {
+		return err
+	}
+
+	k.showLog(bout).Info("force stop")
+	bout.RemoveMonitor()
+	bout.RemoveRecorder()
+	k.monitorManager.SkipSession(bout.GetID())
+	return bout.AddMonitor()
+}
+
+// PauseUntil stops recording and monitoring the show until t, when it is
+// monitored again. A zero t resumes the show now. Pauses are kept in the
+// history book, surviving restarts when it has a file.
+func (k *Kernel) PauseUntil(showID string, t time.Time) error {
+	bout, err := k.bout(showID)
+	if err != nil {
+		return err
+	}
+	if !k.resume(showID) && t.IsZero() {
+		return nil
+	}
+	if t.IsZero() || !t.After(time.Now()) {
+		k.showLog(bout).Info("resume")
+		return bout.AddMonitor()
+	}
+
+	k.showLog(bout).Infof("pause until %s", t.Format(time.RFC3339))
+	bout.RemoveMonitor()
+	bout.RemoveRecorder()
+	k.pause(bout, t)
+	return nil
+}
+
+// pause monitors the show again at t.
+func (k *Kernel) pause(bout config.Bout, t time.Time) {
+	showID := string(bout.GetID())
+	if k.history != nil {
+		k.history.Pause(showID, t)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if p, ok := k.pauses[showID]; ok {
+		p.timer.Stop()
+	}
+	k.pauses[showID] = pause{
+		until: t,
+		timer: time.AfterFunc(time.Until(t), func() {
+			if k.resume(showID) {
+				k.showLog(bout).Info("resume")
+				bout.AddMonitor()
+			}
+		}),
+	}
+}
+
+// PausedUntil returns when the show resumes, if it is paused.
+func (k *Kernel) PausedUntil(showID string) (time.Time, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.pauses[showID]
+	return p.until, ok
+}
+
+// resume cancels the pause of the show, reporting whether it was paused.
+func (k *Kernel) resume(showID string) bool {
+	k.mu.Lock()
+	p, ok := k.pauses[showID]
+	if ok {
+		p.timer.Stop()
+		delete(k.pauses, showID)
+	}
+	k.mu.Unlock()
+
+	if ok && k.history != nil {
+		k.history.Pause(showID, time.Time{})
+	}
+	return ok
+}
+
+// monitor starts monitoring the show, unless it was paused before a restart
+// of the kernel and still is.
+func (k *Kernel) monitor(bout config.Bout) error {
+	if k.history != nil {
+		if until, ok := k.history.PausedUntil(string(bout.GetID()), time.Now()); ok {
+			k.pause(bout, until)
+			return nil
+		}
+	}
+	return bout.AddMonitor()
+}
+
+func (k *Kernel) showLog(bout config.Bout) *logrus.Entry {
+	return k.log.WithFields(logrus.Fields{
+		"pf": bout.GetPlatform(),
+		"id": bout.GetRoomID(),
+	})
+}
+
+func (k *Kernel) bout(showID string) (config.Bout, error) {
+	if _, ok := k.showMap.Get(showID); !ok {
+		return nil, ErrShowNotRunning
+	}
+	return NewBout(k.log, k.dispatcher, showID, k.showMap, k.streamerMap, k.cfg)
+}
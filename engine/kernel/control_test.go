@@ -0,0 +1,127 @@
+package kernel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestForceStart(t *testing.T) {
+	k, d := newTestKernel(Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"})
+
+	if err := k.ForceStart("b"); !errors.Is(err, ErrShowNotRunning) {
+		t.Fatalf("got %v, want %v", err, ErrShowNotRunning)
+	}
+
+	if err := k.PauseUntil("a", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	d.take()
+	if err := k.ForceStart("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := k.PausedUntil("a"); ok {
+		t.Error("got the show paused, want it resumed")
+	}
+	if got, want := d.take(), []string{"add recorder a/1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+}
+
+func TestForceStop(t *testing.T) {
+	k, d := newTestKernel(Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"})
+
+	if err := k.ForceStop("b"); !errors.Is(err, ErrShowNotRunning) {
+		t.Fatalf("got %v, want %v", err, ErrShowNotRunning)
+	}
+	if err := k.ForceStop("a"); err != nil {
+		t.Fatal(err)
+	}
+	// the show is monitored again, skipping the live session.
+	want := []string{"remove monitor a/1", "remove recorder a/1", "add monitor a/1"}
+	if got := d.take(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+}
+
+func TestPauseUntil(t *testing.T) {
+	k, d := newTestKernel(Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"})
+
+	// resuming a show which is not paused does nothing.
+	if err := k.PauseUntil("a", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.take(); len(got) != 0 {
+		t.Errorf("got events %q, want none", got)
+	}
+
+	until := time.Now().Add(time.Hour)
+	if err := k.PauseUntil("a", until); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.take(), []string{"remove monitor a/1", "remove recorder a/1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+	if got, ok := k.PausedUntil("a"); !ok || !got.Equal(until) {
+		t.Errorf("got paused until %s %v, want %s", got, ok, until)
+	}
+	if _, ok := k.history.PausedUntil("a", time.Now()); !ok {
+		t.Error("got the pause unrecorded, want it kept in the history")
+	}
+
+	if err := k.PauseUntil("a", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.take(), []string{"add monitor a/1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+	if _, ok := k.PausedUntil("a"); ok {
+		t.Error("got the show paused, want it resumed")
+	}
+	if _, ok := k.history.PausedUntil("a", time.Now()); ok {
+		t.Error("got the pause still recorded, want it cleared")
+	}
+}
+
+func TestPauseExpires(t *testing.T) {
+	k, d := newTestKernel(Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"})
+
+	if err := k.PauseUntil("a", time.Now().Add(100*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	d.take()
+
+	var got []string
+	deadline := time.Now().Add(3 * time.Second)
+	for len(got) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the show was not resumed")
+		}
+		time.Sleep(10 * time.Millisecond)
+		got = d.take()
+	}
+	if want := []string{"add monitor a/1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+	if _, ok := k.PausedUntil("a"); ok {
+		t.Error("got the show paused, want it resumed")
+	}
+}
+
+func TestPauseRestart(t *testing.T) {
+	k, d := newTestKernel()
+
+	// the show was paused before the kernel restarted.
+	until := time.Now().Add(time.Hour)
+	k.history.Pause("a", until)
+
+	k.UpdateShow(Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"})
+	if got := d.take(); len(got) != 0 {
+		t.Errorf("got events %q, want the show left paused", got)
+	}
+	if got, ok := k.PausedUntil("a"); !ok || !got.Equal(until) {
+		t.Errorf("got paused until %s %v, want %s", got, ok, until)
+	}
+	k.resume("a")
+}
@@ -2,25 +2,44 @@ package kernel
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/go-olive/olive/engine/config"
 	"github.com/go-olive/olive/engine/dispatcher"
+	"github.com/go-olive/olive/engine/events"
 	"github.com/go-olive/olive/engine/monitor"
+	"github.com/go-olive/olive/engine/preview"
 	"github.com/go-olive/olive/engine/recorder"
 	"github.com/go-olive/olive/engine/uploader"
+	"github.com/go-olive/olive/foundation/history"
 	"github.com/go-olive/olive/foundation/syncmap"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
 )
 
+// historyFlushInterval is how often the history book is saved.
+const historyFlushInterval = time.Minute
+
 type Kernel struct {
 	log     *logrus.Logger
 	cfg     *config.Config
 	showMap *syncmap.RWMap[string, Show]
 
+	streamerMap *syncmap.RWMap[string, Streamer]
+
+	dispatcher      *dispatcher.Manager
 	recorderManager *recorder.Manager
 	monitorManager  *monitor.Manager
 	workerPool      *uploader.WorkerPool
+	hub             *preview.Hub
+	events          *events.Bus
+	history         *history.Book
+
+	mu     sync.Mutex
+	pauses map[string]pause
+
+	skipPrerun bool
 
 	done chan struct{}
 }
@@ -31,22 +50,37 @@ func New(log *logrus.Logger, cfg *config.Config, shows []Show) *Kernel {
 		showMap.Set(show.ID, show)
 	}
 
-	recorderManager := recorder.NewManager(log, cfg)
-	monitorManager := monitor.NewManager(log, cfg)
-	dispatcher.SharedManager = dispatcher.NewManager(log)
-	dispatcher.SharedManager.Register(recorderManager, monitorManager)
+	book, err := history.Open(cfg.HistoryFilepath)
+	if err != nil {
+		log.Errorf("load history failed, starting afresh: %s", err)
+		book, _ = history.Open("")
+	}
+
+	bus := events.NewBus()
+	hub := preview.NewHub()
+	workerPool := uploader.NewWorkerPool(log, cfg.CommanderPoolSize, cfg, bus)
 
-	workerPool := uploader.NewWorkerPool(log, cfg.CommanderPoolSize, cfg)
-	uploader.UploaderWorkerPool = workerPool
+	recorderManager := recorder.NewManager(log, cfg, book, workerPool, hub, bus)
+	monitorManager := monitor.NewManager(log, cfg, book, bus)
+	d := dispatcher.NewManager(log)
+	d.Register(recorderManager, monitorManager)
 
 	return &Kernel{
 		log:     log,
 		cfg:     cfg,
 		showMap: showMap,
 
+		streamerMap: syncmap.NewRWMap[string, Streamer](0),
+
+		dispatcher:      d,
 		recorderManager: recorderManager,
 		monitorManager:  monitorManager,
 		workerPool:      workerPool,
+		hub:             hub,
+		events:          bus,
+		history:         book,
+
+		pauses: make(map[string]pause),
 
 		done: make(chan struct{}),
 	}
@@ -75,25 +109,26 @@ func (k *Kernel) UpdateShow(shows ...Show) {
 			k.showMap.Set(show.ID, show)
 		} else {
 			k.showMap.Set(show.ID, show)
-			bout, err := NewBout(show.ID, k.showMap, k.cfg)
+			bout, err := NewBout(k.log, k.dispatcher, show.ID, k.showMap, k.streamerMap, k.cfg)
 			if err != nil {
 				k.log.Error(err)
 				k.showMap.Delete(show.ID)
 				continue
 			}
-			bout.AddMonitor()
+			k.monitor(bout)
 		}
 	}
 }
 
 func (k *Kernel) DeleteShow(shows ...Show) {
 	for _, show := range shows {
-		bout, err := NewBout(show.ID, k.showMap, k.cfg)
+		bout, err := NewBout(k.log, k.dispatcher, show.ID, k.showMap, k.streamerMap, k.cfg)
 		if err != nil {
 			k.showMap.Delete(show.ID)
 			k.log.Error(err)
 			continue
 		}
+		k.resume(show.ID)
 		bout.RemoveMonitor()
 		bout.RemoveRecorder()
 		k.showMap.Delete(show.ID)
@@ -116,29 +151,95 @@ func (k *Kernel) IsValidPortalUser(un, pw string) bool {
 
 func (k *Kernel) Run() {
 	k.showMap.Each(func(showID string, _ Show) bool {
-		bout, err := NewBout(showID, k.showMap, k.cfg)
+		bout, err := NewBout(k.log, k.dispatcher, showID, k.showMap, k.streamerMap, k.cfg)
 		if err != nil {
 			k.log.Error(err)
+			return true
 		}
-		bout.AddMonitor()
+		k.monitor(bout)
 		return true
 	})
 
 	go k.recorderManager.Split()
 	go k.recorderManager.MonitorParserStatus()
+	go k.recorderManager.WatchSchedule()
+	go k.recorderManager.WatchQueue()
+	go k.recorderManager.WatchFilter()
+	go k.flushHistory()
 
-	if k.cfg.BiliupEnable && k.cfg.CookieFilepath != "" {
+	if k.cfg.BiliupEnable && k.cfg.CookieFilepath != "" && !k.skipPrerun {
 		k.workerPool.BiliupPrerun()
 	}
 	k.workerPool.Run()
 }
 
+// SkipPrerun makes Run skip uploading the recordings left in the save dir,
+// for a kernel taking over from another one which already did.
+func (k *Kernel) SkipPrerun() {
+	k.skipPrerun = true
+}
+
 func (k *Kernel) Shutdown(ctx context.Context) {
 	k.recorderManager.Stop()
 	k.monitorManager.Stop()
+
+	// the uploads in progress are stopped, waiting for them as long as ctx
+	// allows.
+	stopped := make(chan struct{})
+	go func() {
+		k.workerPool.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		k.log.Warn("uploads did not stop in time")
+	}
+
+	if err := k.history.Flush(); err != nil {
+		k.log.Errorf("save history failed: %s", err)
+	}
+	k.events.Close()
 	close(k.done)
 }
 
+// flushHistory saves the history book every historyFlushInterval until
+// Shutdown, which saves it a last time.
+func (k *Kernel) flushHistory() {
+	t := time.NewTicker(historyFlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := k.history.Flush(); err != nil {
+				k.log.Warnf("save history failed: %s", err)
+			}
+		case <-k.done:
+			return
+		}
+	}
+}
+
+// Replay returns the last events published after the one of lastID in
+// epoch, for subscribers to catch up with, and the ID it replayed after, see
+// events.Bus.Replay.
+func (k *Kernel) Replay(epoch string, lastID uint64) ([]events.Event, uint64) {
+	return k.events.Replay(epoch, lastID)
+}
+
+// EventEpoch tells the events of the kernel apart from the ones of previous
+// runs, see events.Bus.Epoch.
+func (k *Kernel) EventEpoch() string {
+	return k.events.Epoch()
+}
+
+// Subscribe returns a channel of the events of the shows, buffering up to
+// size events, and the func to unsubscribe. Events are dropped while the
+// buffer is full. The channel is closed on unsubscribing or on Shutdown.
+func (k *Kernel) Subscribe(size int) (<-chan events.Event, func()) {
+	return k.events.Subscribe(size)
+}
+
 func (k *Kernel) Done() <-chan struct{} {
 	return k.done
 }
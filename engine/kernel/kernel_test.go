@@ -0,0 +1,179 @@
+package kernel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/dispatcher"
+	"github.com/go-olive/olive/engine/enum"
+	"github.com/go-olive/olive/engine/monitor"
+	"github.com/go-olive/olive/engine/preview"
+	"github.com/go-olive/olive/engine/recorder"
+	"github.com/go-olive/olive/foundation/history"
+	"github.com/go-olive/olive/foundation/syncmap"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeDispatcher records the events of the bouts instead of monitoring and
+// recording them.
+type fakeDispatcher struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (d *fakeDispatcher) Dispatch(e *dispatcher.Event) error {
+	b := e.Object.(*bout)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, fmt.Sprintf("%s %s/%s", e.Type, b.showID, b.RoomID))
+	return nil
+}
+
+func (d *fakeDispatcher) DispatcherType() enum.DispatcherTypeID {
+	return enum.DispatcherType.Monitor
+}
+
+func (d *fakeDispatcher) DispatchTypes() []enum.EventTypeID {
+	return []enum.EventTypeID{
+		enum.EventType.AddMonitor,
+		enum.EventType.RemoveMonitor,
+		enum.EventType.AddRecorder,
+		enum.EventType.RemoveRecorder,
+		enum.EventType.DequeueRecorder,
+	}
+}
+
+// take returns the events recorded so far, forgetting them.
+func (d *fakeDispatcher) take() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events := d.events
+	d.events = nil
+	return events
+}
+
+// newTestKernel returns a kernel of the enabled shows, dispatching to the
+// returned dispatcher.
+func newTestKernel(shows ...Show) (*Kernel, *fakeDispatcher) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	fake := new(fakeDispatcher)
+	d := dispatcher.NewManager(log)
+	d.Register(fake)
+
+	showMap := syncmap.NewRWMap[string, Show](len(shows))
+	for _, show := range shows {
+		if show.Enable {
+			showMap.Set(show.ID, show)
+		}
+	}
+	cfg := &config.Config{}
+	book, _ := history.Open("")
+	k := &Kernel{
+		log:             log,
+		cfg:             cfg,
+		showMap:         showMap,
+		streamerMap:     syncmap.NewRWMap[string, Streamer](0),
+		dispatcher:      d,
+		recorderManager: recorder.NewManager(log, cfg, book, nil, nil, nil),
+		monitorManager:  monitor.NewManager(log, cfg, book, nil),
+		history:         book,
+		pauses:          make(map[string]pause),
+		done:            make(chan struct{}),
+	}
+	return k, fake
+}
+
+func TestApplyShows(t *testing.T) {
+	old := []Show{
+		{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"},
+		{ID: "b", Enable: true, Platform: "huya", RoomID: "2"},
+		{ID: "c", Enable: false, Platform: "huya", RoomID: "3"},
+		{ID: "d", Enable: true, Platform: "huya", RoomID: "4"},
+	}
+	new := []Show{
+		{ID: "a", Enable: true, Platform: "bilibili", RoomID: "9"},
+		{ID: "c", Enable: true, Platform: "huya", RoomID: "3"},
+		{ID: "d", Enable: true, Platform: "huya", RoomID: "4", Parser: "streamlink"},
+		{ID: "e", Enable: true, Platform: "douyin", RoomID: "5"},
+		{ID: "f", Enable: false, Platform: "douyin", RoomID: "6"},
+	}
+	k, d := newTestKernel(old...)
+
+	k.ApplyShows(DiffShows(old, new))
+
+	want := []string{
+		// b is removed.
+		"remove monitor b/2", "remove recorder b/2",
+		// e is added, f being added disabled.
+		"add monitor e/5",
+		// c is enabled.
+		"add monitor c/3",
+		// a moves to another room, stopping in the old one.
+		"remove monitor a/1", "remove recorder a/1", "add monitor a/9",
+	}
+	if got := d.take(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+
+	// the other changes take effect on the next snap.
+	if show, _ := k.showMap.Get("d"); show.Parser != "streamlink" {
+		t.Errorf("got parser %q, want the changed show updated", show.Parser)
+	}
+	for _, id := range []string{"b", "f"} {
+		if _, ok := k.showMap.Get(id); ok {
+			t.Errorf("got show %s, want it gone", id)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	k, _ := newTestKernel(Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1"})
+	k.hub = preview.NewHub()
+
+	if _, err := k.Preview("a"); !errors.Is(err, ErrNoPreview) {
+		t.Errorf("got %v, want %v while not recording", err, ErrNoPreview)
+	}
+
+	s := k.hub.Open("a")
+	defer k.hub.Close(s)
+	s.WriteTag(9, 0, []byte{0x17, 0x00, 0x01})
+	if got, err := k.Preview("a"); err != nil || got != s {
+		t.Errorf("got %v, want the stream fed", err)
+	}
+}
+
+func TestGetFfmpegProfile(t *testing.T) {
+	k, _ := newTestKernel(
+		Show{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1", FfmpegProfile: "hevc"},
+		Show{ID: "b", Enable: true, Platform: "bilibili", RoomID: "2", FfmpegProfile: "gone"},
+		Show{ID: "c", Enable: true, Platform: "bilibili", RoomID: "3"},
+	)
+	hevc := config.FfmpegProfile{OutputOptions: []string{"-c:v", "libx265"}, Container: "mkv"}
+	k.cfg.FfmpegProfiles = map[string]config.FfmpegProfile{"hevc": hevc}
+
+	tests := []struct {
+		id   string
+		want *config.FfmpegProfile
+	}{
+		{"a", &hevc},
+		// a profile missing from the config selects none.
+		{"b", nil},
+		{"c", nil},
+	}
+	for _, tt := range tests {
+		b, err := k.bout(tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.GetFfmpegProfile(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("show %s: got %+v, want %+v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package kernel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ShowDiff is the change from a desired set of shows to another, shows
+// being matched by ID.
+type ShowDiff struct {
+	Added    []Show
+	Removed  []Show
+	Enabled  []Show
+	Disabled []Show
+	Changed  []ShowChange
+}
+
+// ShowChange is a show whose settings changed, Fields being their json
+// names.
+type ShowChange struct {
+	Show   Show
+	Fields []string
+}
+
+// DiffShows returns the change from the old shows to the new ones.
+func DiffShows(old, new []Show) ShowDiff {
+	var d ShowDiff
+
+	olds := make(map[string]Show, len(old))
+	for _, show := range old {
+		olds[show.ID] = show
+	}
+	news := make(map[string]bool, len(new))
+	for _, show := range new {
+		news[show.ID] = true
+		prev, ok := olds[show.ID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, show)
+		case prev.Enable && !show.Enable:
+			d.Disabled = append(d.Disabled, show)
+		case !prev.Enable && show.Enable:
+			d.Enabled = append(d.Enabled, show)
+		default:
+			if fields := changedFields(prev, show); len(fields) > 0 {
+				d.Changed = append(d.Changed, ShowChange{Show: show, Fields: fields})
+			}
+		}
+	}
+	for _, show := range old {
+		if !news[show.ID] {
+			d.Removed = append(d.Removed, show)
+		}
+	}
+	return d
+}
+
+// changedFields returns the json names of the settings which differ.
+func changedFields(a, b Show) []string {
+	var fields []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		switch name {
+		case "date_created", "date_updated":
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// Empty reports whether nothing changed.
+func (d ShowDiff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Enabled)+len(d.Disabled)+len(d.Changed) == 0
+}
+
+func (d ShowDiff) String() string {
+	ids := func(shows []Show) string {
+		list := make([]string, len(shows))
+		for i, show := range shows {
+			list[i] = show.ID
+		}
+		return "[" + strings.Join(list, " ") + "]"
+	}
+	changed := make([]string, len(d.Changed))
+	for i, c := range d.Changed {
+		changed[i] = fmt.Sprintf("%s(%s)", c.Show.ID, strings.Join(c.Fields, ","))
+	}
+	return fmt.Sprintf("added%s removed%s enabled%s disabled%s changed[%s]",
+		ids(d.Added), ids(d.Removed), ids(d.Enabled), ids(d.Disabled), strings.Join(changed, " "))
+}
+
+// ApplyShows applies the change through HandleShow. Shows moving to another
+// room are stopped and started again, the other changes taking effect on
+// the next snap or segment.
+func (k *Kernel) ApplyShows(d ShowDiff) {
+	for _, show := range d.Removed {
+		if show.Enable {
+			show.Enable = false
+			k.HandleShow(show)
+		}
+	}
+	k.HandleShow(d.Disabled...)
+	for _, show := range d.Added {
+		if show.Enable {
+			k.HandleShow(show)
+		}
+	}
+	k.HandleShow(d.Enabled...)
+	for _, c := range d.Changed {
+		if !c.Show.Enable {
+			continue
+		}
+		if movesRoom(c.Fields) {
+			stopped := c.Show
+			stopped.Enable = false
+			k.HandleShow(stopped)
+		}
+		k.HandleShow(c.Show)
+	}
+}
+
+func movesRoom(fields []string) bool {
+	for _, f := range fields {
+		if f == "platform" || f == "room_id" {
+			return true
+		}
+	}
+	return false
+}
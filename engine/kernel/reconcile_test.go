@@ -0,0 +1,54 @@
+package kernel_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-olive/olive/engine/kernel"
+)
+
+func TestDiffShows(t *testing.T) {
+	old := []kernel.Show{
+		{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1", Parser: "flv"},
+		{ID: "b", Enable: true, Platform: "huya", RoomID: "2"},
+		{ID: "c", Enable: false, Platform: "huya", RoomID: "3"},
+		{ID: "d", Enable: true, Platform: "douyin", RoomID: "4"},
+	}
+	new := []kernel.Show{
+		{ID: "a", Enable: true, Platform: "bilibili", RoomID: "1", Parser: "ffmpeg", SaveDir: "/tmp"},
+		{ID: "c", Enable: true, Platform: "huya", RoomID: "3"},
+		{ID: "d", Enable: false, Platform: "douyin", RoomID: "4"},
+		{ID: "e", Enable: true, Platform: "twitch", RoomID: "5"},
+	}
+
+	d := kernel.DiffShows(old, new)
+	ids := func(shows []kernel.Show) []string {
+		var list []string
+		for _, show := range shows {
+			list = append(list, show.ID)
+		}
+		return list
+	}
+	for _, tt := range []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"added", ids(d.Added), []string{"e"}},
+		{"removed", ids(d.Removed), []string{"b"}},
+		{"enabled", ids(d.Enabled), []string{"c"}},
+		{"disabled", ids(d.Disabled), []string{"d"}},
+	} {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("Should have %s %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Show.ID != "a" ||
+		!reflect.DeepEqual(d.Changed[0].Fields, []string{"parser", "save_dir"}) {
+		t.Errorf("Should have changed a(parser,save_dir), got %+v", d.Changed)
+	}
+
+	if !kernel.DiffShows(new, new).Empty() {
+		t.Error("Should be empty for the same shows")
+	}
+}
@@ -11,18 +11,29 @@ import (
 
 // Show represents an individual show.
 type Show struct {
-	ID           string    `json:"show_id"`
-	Enable       bool      `json:"enable"`
-	Platform     string    `json:"platform"`
-	RoomID       string    `json:"room_id"`
-	StreamerName string    `json:"streamer_name"`
-	OutTmpl      string    `json:"out_tmpl"`
-	Parser       string    `json:"parser"`
-	SaveDir      string    `json:"save_dir"`
-	PostCmds     string    `json:"post_cmds"`
-	SplitRule    string    `json:"split_rule"`
-	DateCreated  time.Time `json:"date_created"`
-	DateUpdated  time.Time `json:"date_updated"`
+	ID              string    `json:"show_id"`
+	Enable          bool      `json:"enable"`
+	Platform        string    `json:"platform"`
+	RoomID          string    `json:"room_id"`
+	StreamerName    string    `json:"streamer_name"`
+	OutTmpl         string    `json:"out_tmpl"`
+	Parser          string    `json:"parser"`
+	SaveDir         string    `json:"save_dir"`
+	PostCmds        string    `json:"post_cmds"`
+	SplitRule       string    `json:"split_rule"`
+	FfmpegProfile   string    `json:"ffmpeg_profile"`
+	Relay           string    `json:"relay"`
+	AudioOnly       bool      `json:"audio_only"`
+	PushWatch       bool      `json:"push_watch"`
+	Schedule        string    `json:"schedule"`
+	SnapRestSeconds uint      `json:"snap_rest_seconds"`
+	Priority        int       `json:"priority"`
+	FilterRule      string    `json:"filter_rule"`
+	Tags            string    `json:"tags"`
+	GroupID         string    `json:"group_id"`
+	StreamerID      string    `json:"streamer_id"`
+	DateCreated     time.Time `json:"date_created"`
+	DateUpdated     time.Time `json:"date_updated"`
 }
 
 func (s *Show) CheckAndFix(cfg *config.Config) {
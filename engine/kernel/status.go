@@ -0,0 +1,119 @@
+package kernel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/parser"
+	"github.com/go-olive/olive/engine/preview"
+	"github.com/go-olive/olive/engine/recorder"
+)
+
+// Status is the runtime status of a show.
+type Status struct {
+	ShowID       string             `json:"show_id"`
+	Platform     string             `json:"platform"`
+	RoomID       string             `json:"room_id"`
+	StreamerName string             `json:"streamer_name"`
+	StreamerID   string             `json:"streamer_id,omitempty"`
+	Priority     int                `json:"priority"`
+	Monitoring   bool               `json:"monitoring"`
+	Recording    bool               `json:"recording"`
+	Queued       *recorder.Decision `json:"queued,omitempty"`
+	PausedUntil  *time.Time         `json:"paused_until,omitempty"`
+	Parser       string             `json:"parser"`
+	Out          string             `json:"out,omitempty"`
+	StartTime    *time.Time         `json:"start_time,omitempty"`
+	SessionID    string             `json:"session_id,omitempty"`
+	SegmentIndex int                `json:"segment_index,omitempty"`
+	Progress     *parser.Progress   `json:"progress,omitempty"`
+}
+
+// Status returns the runtime status of every show, ordered by streamer name.
+func (k *Kernel) Status() []Status {
+	var list []Status
+	k.showMap.Each(func(id string, show Show) bool {
+		list = append(list, k.status(show))
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StreamerName != list[j].StreamerName {
+			return list[i].StreamerName < list[j].StreamerName
+		}
+		return list[i].ShowID < list[j].ShowID
+	})
+	return list
+}
+
+// ShowStatus returns the runtime status of a show.
+func (k *Kernel) ShowStatus(showID string) (Status, bool) {
+	show, ok := k.showMap.Get(showID)
+	if !ok {
+		return Status{}, false
+	}
+	return k.status(show), true
+}
+
+func (k *Kernel) status(show Show) Status {
+	s := Status{
+		ShowID:       show.ID,
+		Platform:     show.Platform,
+		RoomID:       show.RoomID,
+		StreamerName: show.StreamerName,
+		StreamerID:   show.StreamerID,
+		Parser:       show.Parser,
+		Priority:     show.Priority,
+		Monitoring:   k.monitorManager.Has(config.ID(show.ID)),
+	}
+	if until, ok := k.PausedUntil(show.ID); ok {
+		s.PausedUntil = &until
+	}
+	if d, ok := k.recorderManager.Queued(config.ID(show.ID)); ok {
+		s.Queued = &d
+	}
+	r, ok := k.recorderManager.Recorder(config.ID(show.ID))
+	if !ok {
+		return s
+	}
+	s.Recording = true
+	if name := r.Parser(); name != "" {
+		s.Parser = name
+	}
+	s.Out = r.Out()
+	startTime := r.StartTime()
+	s.StartTime = &startTime
+	if session, ok := r.Session(); ok {
+		s.SessionID = session.ID
+		s.SegmentIndex = session.SegmentIndex
+	}
+	if p, ok := r.Progress(); ok {
+		s.Progress = &p
+	}
+	return s
+}
+
+// ErrNoPreview is returned when previewing a show which is not being
+// recorded, or whose recording has not written anything yet.
+var ErrNoPreview = errors.New("show has no live preview")
+
+// ErrPreviewUnsupported is returned when previewing a show recorded by a
+// parser other than flv, the only one feeding the preview.
+var ErrPreviewUnsupported = errors.New("live preview is unsupported for the parser")
+
+// Preview returns the live preview of a show being recorded.
+func (k *Kernel) Preview(showID string) (*preview.Stream, error) {
+	if s, ok := k.hub.Stream(config.ID(showID)); ok {
+		return s, nil
+	}
+	r, ok := k.recorderManager.Recorder(config.ID(showID))
+	if !ok {
+		return nil, ErrNoPreview
+	}
+	if name := r.Parser(); name != "" && name != "flv" {
+		return nil, fmt.Errorf("%w[%s]", ErrPreviewUnsupported, name)
+	}
+	return nil, ErrNoPreview
+}
@@ -0,0 +1,46 @@
+package kernel
+
+import (
+	"sort"
+
+	"github.com/go-olive/olive/engine/config"
+)
+
+// Streamer is the person behind several shows, see config.Streamer.
+type Streamer = config.Streamer
+
+// HandleStreamer adds or replaces the streamers, their shows following the
+// new policy from their next recording on.
+func (k *Kernel) HandleStreamer(streamers ...Streamer) {
+	for _, s := range streamers {
+		if !config.ValidStreamerPolicy(s.Policy) {
+			k.log.Errorf("streamer[%s] policy[%s] is not valid, recording all", s.ID, s.Policy)
+			s.Policy = config.StreamerPolicyAll
+		}
+		k.streamerMap.Set(s.ID, s)
+	}
+}
+
+// DeleteStreamer removes the streamers, their shows being recorded on their
+// own from then on.
+func (k *Kernel) DeleteStreamer(ids ...string) {
+	for _, id := range ids {
+		k.streamerMap.Delete(id)
+	}
+}
+
+// Streamers returns the streamers, ordered by name.
+func (k *Kernel) Streamers() []Streamer {
+	var list []Streamer
+	k.streamerMap.Each(func(_ string, s Streamer) bool {
+		list = append(list, s)
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
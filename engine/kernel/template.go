@@ -0,0 +1,114 @@
+package kernel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/foundation/syncmap"
+	"github.com/go-olive/olive/foundation/tmpl"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidTemplate is reported when the OutTmpl or SaveDir template fails
+// to render.
+var ErrInvalidTemplate = errors.New("template is not valid")
+
+// TemplatePreview is the OutTmpl and SaveDir of a show rendered against a
+// snap of it.
+type TemplatePreview struct {
+	Info        tmpl.Info `json:"info"`
+	OutFilename string    `json:"out_filename"`
+	SaveDir     string    `json:"save_dir"`
+}
+
+// PreviewTemplate renders outTmpl and saveDir, the ones of the show when
+// empty, against a snap of the show taken as the first segment of a live
+// session. It fails with ErrInvalidTemplate when a template cannot be
+// rendered, instead of falling back to the default one as recordings do.
+func PreviewTemplate(log *logrus.Logger, cfg *config.Config, show Show, streamer *Streamer, outTmpl, saveDir string) (TemplatePreview, error) {
+	if outTmpl != "" {
+		show.OutTmpl = outTmpl
+	}
+	if saveDir != "" {
+		show.SaveDir = saveDir
+	}
+	show.CheckAndFix(cfg)
+
+	showMap := syncmap.NewRWMap[string, Show](1)
+	showMap.Set(show.ID, show)
+	streamerMap := syncmap.NewRWMap[string, Streamer](1)
+	if streamer != nil {
+		streamerMap.Set(streamer.ID, *streamer)
+	}
+	b, err := NewBout(log, nil, show.ID, showMap, streamerMap, cfg)
+	if err != nil {
+		return TemplatePreview{}, err
+	}
+	if err := b.Snap(); err != nil {
+		return TemplatePreview{}, fmt.Errorf("snap: %w", err)
+	}
+
+	session := config.Session{
+		ID:           uuid.NewString(),
+		StartTime:    time.Now(),
+		SegmentIndex: 1,
+	}
+	if parsers := b.GetParsers(); len(parsers) > 0 {
+		session.Parser = parsers[0]
+	}
+	p := TemplatePreview{
+		Info: b.templateInfo(session),
+	}
+	if p.OutFilename, err = tmpl.RenderOutTmpl(b.show.OutTmpl, p.Info); err != nil {
+		return p, fmt.Errorf("OutTmpl %w: %s", ErrInvalidTemplate, err)
+	}
+	if p.SaveDir, err = tmpl.RenderSaveDir(b.show.SaveDir, p.Info); err != nil {
+		return p, fmt.Errorf("SaveDir %w: %s", ErrInvalidTemplate, err)
+	}
+	return p, nil
+}
+
+// PreviewTemplate renders outTmpl and saveDir against a snap of the show,
+// see PreviewTemplate.
+func (k *Kernel) PreviewTemplate(show Show, outTmpl, saveDir string) (TemplatePreview, error) {
+	var streamer *Streamer
+	if s, ok := k.streamerMap.Get(show.StreamerID); ok {
+		streamer = &s
+	}
+	return PreviewTemplate(k.log, k.cfg, show, streamer, outTmpl, saveDir)
+}
+
+// templateInfo returns the details of the show as last snapped, recording
+// the segment of the session.
+func (b *bout) templateInfo(session config.Session) tmpl.Info {
+	b.Refresh()
+
+	roomName, _ := b.RoomName()
+	category, _ := b.Category()
+	quality := "source"
+	switch {
+	case b.show.AudioOnly:
+		quality = "audio"
+	case strings.TrimSpace(b.show.FfmpegProfile) != "":
+		quality = strings.TrimSpace(b.show.FfmpegProfile)
+	}
+
+	return tmpl.Info{
+		ShowID:       b.showID,
+		Platform:     b.SiteID,
+		SiteName:     b.SiteName(),
+		RoomID:       b.RoomID,
+		StreamerName: b.streamerName(),
+		RoomName:     roomName,
+		Category:     category,
+		SessionID:    session.ID,
+		SessionStart: session.StartTime,
+		SegmentIndex: session.SegmentIndex,
+		Quality:      quality,
+		Parser:       session.Parser,
+	}
+}
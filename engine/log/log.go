@@ -11,8 +11,6 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var Logger *logrus.Logger
-
 func InitLogger(logPath string) *logrus.Logger {
 	if err := os.MkdirAll(logPath, os.ModePerm); err != nil {
 		log.Fatal(err)
@@ -23,7 +21,7 @@ func InitLogger(logPath string) *logrus.Logger {
 		log.Fatal(err)
 	}
 
-	Logger = &logrus.Logger{
+	logger := &logrus.Logger{
 		Out: io.MultiWriter(os.Stderr),
 		Formatter: &logrus.TextFormatter{
 			ForceColors:     true,
@@ -35,7 +33,7 @@ func InitLogger(logPath string) *logrus.Logger {
 		// ReportCaller: true,
 	}
 
-	Logger.AddHook(lfshook.NewHook(
+	logger.AddHook(lfshook.NewHook(
 		f,
 		// &logrus.JSONFormatter{
 		// 	TimestampFormat: "2006-01-02 15:04:05",
@@ -46,5 +44,5 @@ func InitLogger(logPath string) *logrus.Logger {
 		},
 	))
 
-	return Logger
+	return logger
 }
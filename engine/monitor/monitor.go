@@ -2,29 +2,44 @@
 package monitor
 
 import (
+	"errors"
+	"math/rand"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-olive/olive/engine/config"
-	"github.com/go-olive/olive/engine/dispatcher"
 	"github.com/go-olive/olive/engine/enum"
-	"github.com/lthibault/jitterbug/v2"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/foundation/history"
+	"github.com/go-olive/olive/foundation/olivetv"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	jitterStdev = 3 * time.Second
+	minInterval = time.Second
+	// likelyWithin is how close to a usual start time polling speeds up.
+	likelyWithin = 15 * time.Minute
+)
+
 type Monitor interface {
 	Start() error
 	Stop()
 	Done() <-chan struct{}
 }
 
-func NewMonitor(log *logrus.Logger, bout config.Bout, cfg *config.Config) Monitor {
+func NewMonitor(log *logrus.Logger, bout config.Bout, cfg *config.Config, book *history.Book, bus *events.Bus) Monitor {
 	return &monitor{
+		history: book,
+		events:  bus,
+
 		status: enum.Status.Starting,
 		bout:   bout,
 		stop:   make(chan struct{}),
 		done:   make(chan struct{}),
 
+		liveEvents: make(chan olivetv.LiveEvent),
+
 		log: log,
 		cfg: cfg,
 	}
@@ -39,7 +54,17 @@ type monitor struct {
 	log *logrus.Logger
 	cfg *config.Config
 
-	roomOn bool
+	roomOn     bool
+	liveEvents chan olivetv.LiveEvent
+
+	history *history.Book
+	// failures counts the snaps failed in a row, rate limits counting twice.
+	failures uint
+
+	events *events.Bus
+	// live is the live status last seen, unlike roomOn which is reset to
+	// check the room again.
+	live bool
 }
 
 func (m *monitor) Start() error {
@@ -51,11 +76,16 @@ func (m *monitor) Start() error {
 		"pf": m.bout.GetPlatform(),
 		"id": m.bout.GetRoomID(),
 	}).Info("monitor start")
+	m.events.Publish(events.New(events.MonitorStart, m.bout))
 
 	defer atomic.CompareAndSwapUint32(&m.status, enum.Status.Pending, enum.Status.Running)
+	m.history.Watch(config.HistoryKey(m.bout), time.Now())
 	m.refresh()
 
 	go m.run()
+	if m.bout.GetPushWatch() {
+		go m.watch()
+	}
 
 	return nil
 }
@@ -73,21 +103,34 @@ func (m *monitor) refresh() {
 		return
 	}
 
+	sched := m.bout.GetSchedule()
+	if !polling(sched, time.Now()) {
+		// the room status is unknown until the next window.
+		m.roomOn = false
+		return
+	}
+
 	if err := m.bout.Snap(); err != nil {
+		m.failures++
+		if errors.Is(err, olivetv.ErrRateLimited) {
+			m.failures++
+		}
 		m.log.WithFields(logrus.Fields{
 			"pf": m.bout.GetPlatform(),
 			"id": m.bout.GetRoomID(),
 		}).Tracef("snap failed, %s", err.Error())
 		return
 	}
+	m.failures = 0
 	_, roomOn := m.bout.StreamURL()
+	m.seen(roomOn)
+	if roomOn {
+		m.history.Live(config.HistoryKey(m.bout), time.Now(), false)
+	}
 	defer func() {
 		m.roomOn = roomOn
 	}()
-	var eventType enum.EventTypeID
-	if !m.roomOn && roomOn {
-		eventType = enum.EventType.AddRecorder
-	} else {
+	if m.roomOn || !roomOn {
 		return
 	}
 
@@ -98,22 +141,190 @@ func (m *monitor) refresh() {
 		"new": roomOn,
 	}).Info("live status changed")
 
-	d, ok := dispatcher.SharedManager.Dispatcher(enum.DispatcherType.Recorder)
-	if !ok {
+	if !recording(sched, time.Now()) {
+		m.log.WithFields(logrus.Fields{
+			"pf": m.bout.GetPlatform(),
+			"id": m.bout.GetRoomID(),
+		}).Info("outside the recording windows")
+		// check again at the next poll, in case a window opens.
+		roomOn = false
+		return
+	}
+	if !m.bout.SatisfyFilterRule() {
+		m.log.WithFields(logrus.Fields{
+			"pf": m.bout.GetPlatform(),
+			"id": m.bout.GetRoomID(),
+		}).Info("filtered out by the filter rule")
+		// check again at the next poll, in case the room changes.
+		roomOn = false
+		return
+	}
+	m.addRecorder()
+}
+
+// seen publishes the changes of the live status. A show going offline no
+// longer waits for a recorder.
+func (m *monitor) seen(live bool) {
+	if live == m.live {
 		return
 	}
-	e := dispatcher.NewEvent(eventType, m.bout)
-	if err := d.Dispatch(e); err != nil {
+	m.live = live
+	typ := events.LiveEnd
+	if live {
+		typ = events.LiveStart
+	} else if err := m.bout.DequeueRecorder(); err != nil {
+		m.log.Error(err)
+	}
+	m.events.Publish(events.New(typ, m.bout))
+}
+
+func (m *monitor) addRecorder() {
+	if err := m.bout.AddRecorder(); err != nil {
 		m.log.Error(err)
 	}
+}
+
+// onLiveEvent handles the live status pushed by the watcher, it dispatches
+// right away instead of waiting for the next snap.
+func (m *monitor) onLiveEvent(e olivetv.LiveEvent) {
+	if !m.bout.IsConfigValid() {
+		m.Stop()
+		return
+	}
+
+	now := time.Now()
+	if sched := m.bout.GetSchedule(); !polling(sched, now) || !recording(sched, now) {
+		return
+	}
+
+	m.seen(e.RoomOn)
+	old := m.roomOn
+	m.roomOn = e.RoomOn
+	if old || !e.RoomOn {
+		return
+	}
+
+	m.log.WithFields(logrus.Fields{
+		"pf":  m.bout.GetPlatform(),
+		"id":  m.bout.GetRoomID(),
+		"old": old,
+		"new": e.RoomOn,
+	}).Info("live status pushed")
+
+	// the push carries no room details, the filter rule needs a fresh snap.
+	// Without one the next poll decides, as refresh does.
+	if err := m.bout.Snap(); err != nil {
+		m.log.WithFields(logrus.Fields{
+			"pf": m.bout.GetPlatform(),
+			"id": m.bout.GetRoomID(),
+		}).Tracef("snap failed, %s", err.Error())
+		m.roomOn = false
+		return
+	}
+	if !m.bout.SatisfyFilterRule() {
+		m.log.WithFields(logrus.Fields{
+			"pf": m.bout.GetPlatform(),
+			"id": m.bout.GetRoomID(),
+		}).Info("filtered out by the filter rule")
+		m.roomOn = false
+		return
+	}
+	m.addRecorder()
+}
+
+// watch keeps a push watcher running next to the poller, reconnecting
+// until the monitor stops. Sites without a push channel are left to the poller.
+func (m *monitor) watch() {
+	const retryInterval = 30 * time.Second
+
+	for {
+		events, err := m.bout.Watch(m.stop)
+		switch {
+		case errors.Is(err, olivetv.ErrWatchNotSupported):
+			return
+		case err != nil:
+			m.log.WithFields(logrus.Fields{
+				"pf": m.bout.GetPlatform(),
+				"id": m.bout.GetRoomID(),
+			}).Tracef("watch failed, %s", err.Error())
+		default:
+			for e := range events {
+				select {
+				case m.liveEvents <- e:
+				case <-m.stop:
+					return
+				}
+			}
+		}
+
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// interval returns how long to rest before the next snap, jittered so that
+// the shows of a site are not polled in lockstep.
+//
+// The rest is the one of the show, or of its schedule. Failed snaps back off
+// exponentially up to SnapBackoffMaxSeconds. Otherwise shows without a
+// schedule are polled faster around the times of day they usually go live,
+// and slower once they have not been seen live for DormantDays.
+func (m *monitor) interval() time.Duration {
+	now := time.Now()
+	rest := seconds(m.bout.GetSnapRestSeconds())
+	scheduled := false
+	if sched := m.bout.GetSchedule(); sched != nil {
+		n := sched.SlowRestSeconds
+		if sched.Active(now) {
+			n = sched.FastRestSeconds
+		}
+		if n > 0 {
+			rest, scheduled = seconds(n), true
+		}
+	}
+
+	id := config.HistoryKey(m.bout)
+	switch {
+	case m.failures > 0:
+		max := seconds(m.cfg.SnapBackoffMaxSeconds)
+		for i := uint(0); i < m.failures && rest < max; i++ {
+			rest *= 2
+		}
+		if rest > max {
+			rest = max
+		}
+	case scheduled:
+	case m.history.Likely(id, now, likelyWithin):
+		if likely := seconds(m.cfg.LikelyRestSeconds); likely > 0 && likely < rest {
+			rest = likely
+		}
+	case m.cfg.DormantDays > 0 && m.history.Dormant(id, now, time.Duration(m.cfg.DormantDays)*24*time.Hour):
+		if dormant := seconds(m.cfg.DormantRestSeconds); dormant > rest {
+			rest = dormant
+		}
+	}
+
+	// long rests get a proportionally wider jitter.
+	stdev := jitterStdev
+	if rest/10 > stdev {
+		stdev = rest / 10
+	}
+	rest += time.Duration(rand.NormFloat64() * float64(stdev))
+	if rest < minInterval {
+		rest = minInterval
+	}
+	return rest
+}
 
+func seconds(n uint) time.Duration {
+	return time.Second * time.Duration(n)
 }
 
 func (m *monitor) run() {
-	t := jitterbug.New(
-		time.Second*time.Duration(m.cfg.SnapRestSeconds),
-		&jitterbug.Norm{Stdev: time.Second * 3},
-	)
+	t := time.NewTimer(m.interval())
 	defer t.Stop()
 
 	for {
@@ -124,9 +335,13 @@ func (m *monitor) run() {
 				"pf": m.bout.GetPlatform(),
 				"id": m.bout.GetRoomID(),
 			}).Info("monitor stop")
+			m.events.Publish(events.New(events.MonitorStop, m.bout))
 			return
 		case <-t.C:
 			m.refresh()
+			t.Reset(m.interval())
+		case e := <-m.liveEvents:
+			m.onLiveEvent(e)
 		}
 	}
 }
@@ -5,20 +5,30 @@ import (
 	"sync"
 
 	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/foundation/history"
 	"github.com/sirupsen/logrus"
 )
 
 type Manager struct {
 	mu     sync.RWMutex
 	savers map[config.ID]Monitor
+	skips  map[config.ID]bool
+
+	history *history.Book
+	events  *events.Bus
 
 	log *logrus.Logger
 	cfg *config.Config
 }
 
-func NewManager(log *logrus.Logger, cfg *config.Config) *Manager {
+func NewManager(log *logrus.Logger, cfg *config.Config, book *history.Book, bus *events.Bus) *Manager {
 	return &Manager{
 		savers: make(map[config.ID]Monitor),
+		skips:  make(map[config.ID]bool),
+
+		history: book,
+		events:  bus,
 
 		log: log,
 		cfg: cfg,
@@ -41,9 +51,23 @@ func (m *Manager) addMonitor(bout config.Bout) error {
 	if _, ok := m.savers[bout.GetID()]; ok {
 		return errors.New("exist")
 	}
-	monitor := NewMonitor(m.log, bout, m.cfg)
-	m.savers[bout.GetID()] = monitor
-	return monitor.Start()
+	mon := NewMonitor(m.log, bout, m.cfg, m.history, m.events)
+	if m.skips[bout.GetID()] {
+		delete(m.skips, bout.GetID())
+		// taking the room as live already, the monitor waits for it to go
+		// offline and live again before recording.
+		mon.(*monitor).roomOn = true
+	}
+	m.savers[bout.GetID()] = mon
+	return mon.Start()
+}
+
+// SkipSession makes the next monitor of the show skip the live session in
+// progress.
+func (m *Manager) SkipSession(id config.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.skips[id] = true
 }
 
 func (m *Manager) removeMonitor(bout config.Bout) error {
@@ -58,3 +82,11 @@ func (m *Manager) removeMonitor(bout config.Bout) error {
 	delete(m.savers, bout.GetID())
 	return nil
 }
+
+// Has reports whether the show is being monitored.
+func (m *Manager) Has(id config.ID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.savers[id]
+	return ok
+}
@@ -0,0 +1,18 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/go-olive/olive/foundation/schedule"
+)
+
+// polling reports whether the show is snapped at t, a nil schedule meaning
+// always.
+func polling(sched *schedule.Schedule, t time.Time) bool {
+	return sched == nil || sched.Outside != schedule.OutsideStop || sched.Active(t)
+}
+
+// recording reports whether a show going live at t is recorded.
+func recording(sched *schedule.Schedule, t time.Time) bool {
+	return sched == nil || !sched.StopOnClose || sched.Active(t)
+}
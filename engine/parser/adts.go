@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// adtsWriter extracts the AAC frames of flv audio tags into an ADTS stream,
+// which plays as a .aac file.
+type adtsWriter struct {
+	w io.Writer
+
+	// from the AudioSpecificConfig of the sequence header.
+	configured bool
+	profile    byte
+	freqIndex  byte
+	channels   byte
+}
+
+func (a *adtsWriter) WriteTag(typ byte, timestamp uint32, data []byte) error {
+	if typ != tagAudio || len(data) < 2 {
+		return nil
+	}
+	if codec := data[0] >> 4; codec != 10 {
+		return fmt.Errorf("%w: audio codec[%d], only aac is", ErrUnsupported, codec)
+	}
+
+	frame := data[2:]
+	if data[1] == 0 {
+		if len(frame) < 2 {
+			return errors.New("invalid aac sequence header")
+		}
+		objectType := frame[0] >> 3
+		a.freqIndex = (frame[0]&0x07)<<1 | frame[1]>>7
+		a.channels = (frame[1] >> 3) & 0x0f
+		if a.freqIndex > 12 {
+			return fmt.Errorf("%w: aac with an explicit sampling frequency", ErrUnsupported)
+		}
+		// ADTS only signals the first four object types, the others decode
+		// as LC.
+		a.profile = 1
+		if objectType >= 1 && objectType <= 4 {
+			a.profile = objectType - 1
+		}
+		a.configured = true
+		return nil
+	}
+	if !a.configured {
+		return nil
+	}
+
+	n := len(frame) + 7
+	header := []byte{
+		0xff, 0xf1,
+		a.profile<<6 | a.freqIndex<<2 | a.channels>>2,
+		(a.channels&0x03)<<6 | byte(n>>11),
+		byte(n >> 3),
+		byte(n&0x07)<<5 | 0x1f,
+		0xfc,
+	}
+	if _, err := a.w.Write(header); err != nil {
+		return err
+	}
+	_, err := a.w.Write(frame)
+	return err
+}
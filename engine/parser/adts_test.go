@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestADTSHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []byte
+		size   int
+		header []byte
+	}{
+		// AAC LC, 44.1 kHz, stereo.
+		{"lc", []byte{0x12, 0x10}, 100, []byte{0xff, 0xf1, 0x50, 0x80, 0x0d, 0x7f, 0xfc}},
+		// frame lengths past 8 bits spread over three bytes.
+		{"long frame", []byte{0x12, 0x10}, 2000, []byte{0xff, 0xf1, 0x50, 0x80, 0xfa, 0xff, 0xfc}},
+		// AAC LC, 48 kHz, mono.
+		{"mono", []byte{0x11, 0x88}, 100, []byte{0xff, 0xf1, 0x4c, 0x40, 0x0d, 0x7f, 0xfc}},
+		// HE-AAC is not signaled by ADTS, it decodes as LC.
+		{"he-aac", []byte{0x2b, 0x10}, 100, []byte{0xff, 0xf1, 0x58, 0x80, 0x0d, 0x7f, 0xfc}},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		a := &adtsWriter{w: buf}
+		frame := bytes.Repeat([]byte{0xaa}, tt.size)
+
+		// frames ahead of the sequence header are dropped.
+		if err := a.WriteTag(tagAudio, 0, append([]byte{0xaf, 0x01}, frame...)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := a.WriteTag(tagAudio, 0, append([]byte{0xaf, 0x00}, tt.config...)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := a.WriteTag(tagAudio, 0, append([]byte{0xaf, 0x01}, frame...)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		out := buf.Bytes()
+		if len(out) != 7+tt.size {
+			t.Fatalf("%s: got %d bytes, want %d", tt.name, len(out), 7+tt.size)
+		}
+		if !bytes.Equal(out[:7], tt.header) {
+			t.Errorf("%s: got header % x, want % x", tt.name, out[:7], tt.header)
+		}
+		if !bytes.Equal(out[7:], frame) {
+			t.Errorf("%s: frame is not copied as is", tt.name)
+		}
+	}
+}
+
+func TestADTSUnsupported(t *testing.T) {
+	a := &adtsWriter{w: new(bytes.Buffer)}
+	// mp3.
+	if err := a.WriteTag(tagAudio, 0, []byte{0x2f, 0x00}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("mp3: got %v, want ErrUnsupported", err)
+	}
+	// explicit sampling frequency, index 15.
+	if err := a.WriteTag(tagAudio, 0, []byte{0xaf, 0x00, 0x17, 0x80}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("explicit frequency: got %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+// externalStopTimeout is how long an external parser has to exit once asked
+// to stop, before it is killed.
+const externalStopTimeout = 10 * time.Second
+
+// External parsers are executables declared in the config and speaking JSON
+// lines over stdio, one message per line, each carrying its "type".
+//
+// olive writes to stdin:
+//
+//	{"type":"start","stream_url":"...","out":"...","headers":{"User-Agent":"...","Referer":"..."}}
+//	{"type":"stop"}
+//
+// The parser writes to stdout:
+//
+//	{"type":"progress","bytes_read":1024,"bytes_written":1024,"bitrate":8192,"stream_time_ms":1000}
+//	{"type":"out","out":"..."}       the output moved, e.g. to another extension
+//	{"type":"log","message":"..."}
+//	{"type":"error","message":"..."} the reason of a coming non-zero exit
+//
+// The recording ends when the parser exits, a non-zero status being a
+// failure. Upon stop, the parser has 10 seconds to exit before it is killed.
+// Whatever it writes to stderr is logged.
+type externalMessage struct {
+	Type string `json:"type"`
+
+	// start
+	StreamURL string            `json:"stream_url,omitempty"`
+	Out       string            `json:"out,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+
+	// progress
+	BytesRead    int64   `json:"bytes_read,omitempty"`
+	BytesWritten int64   `json:"bytes_written,omitempty"`
+	Bitrate      float64 `json:"bitrate,omitempty"`
+	StreamTimeMS int64   `json:"stream_time_ms,omitempty"`
+
+	// log, error
+	Message string `json:"message,omitempty"`
+}
+
+type external struct {
+	meter
+	logged
+	name string
+	cfg  config.ExternalParser
+	opts Options
+	out  string
+	// stopTimeout is how long the parser has to exit once asked to stop.
+	stopTimeout time.Duration
+
+	stdinMu sync.Mutex
+	stdin   io.WriteCloser
+
+	closeOnce sync.Once
+	stop      chan struct{}
+}
+
+// NewExternal returns a parser driving the executable of cfg.
+func NewExternal(name string, cfg config.ExternalParser) Parser {
+	return &external{
+		name:        name,
+		cfg:         cfg,
+		stopTimeout: externalStopTimeout,
+		stop:        make(chan struct{}),
+	}
+}
+
+func (p *external) New() Parser {
+	return NewExternal(p.name, p.cfg)
+}
+
+func (p *external) Stop() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+func (p *external) Type() string {
+	return p.name
+}
+
+func (p *external) Configure(opts Options) error {
+	p.opts = opts
+	return nil
+}
+
+// Out returns the output filepath last reported by the parser.
+func (p *external) Out() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.out
+}
+
+func (p *external) Parse(streamURL string, out string) error {
+	log := p.logger().WithFields(logrus.Fields{
+		"parser": p.name,
+		"out":    out,
+	})
+	log.Debug("external parser working")
+
+	p.mu.Lock()
+	p.out = out
+	p.mu.Unlock()
+
+	cmd := exec.Command(p.cfg.Path, p.cfg.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.stdin = stdin
+
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		s := bufio.NewScanner(stderr)
+		for s.Scan() {
+			log.Debug(s.Text())
+		}
+		io.Copy(io.Discard, stderr)
+	}()
+
+	headers := map[string]string{"User-Agent": userAgent}
+	if p.opts.Referer != "" {
+		headers["Referer"] = p.opts.Referer
+	}
+	if err := p.send(externalMessage{Type: "start", StreamURL: streamURL, Out: out, Headers: headers}); err != nil {
+		cmd.Process.Kill()
+		<-stderrDone
+		cmd.Wait()
+		return fmt.Errorf("start external parser: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-p.stop:
+		case <-done:
+			return
+		}
+		p.send(externalMessage{Type: "stop"})
+		select {
+		case <-done:
+		case <-time.After(p.stopTimeout):
+			log.Warn("external parser did not stop in time, killing it")
+			cmd.Process.Kill()
+		}
+	}()
+
+	reported := p.readMessages(stdout, log)
+	<-stderrDone
+	err = cmd.Wait()
+
+	select {
+	case <-p.stop:
+		return nil
+	default:
+	}
+	if err != nil && reported != "" {
+		return errors.New(reported)
+	}
+	return err
+}
+
+func (p *external) send(m externalMessage) error {
+	data, err := jsoniter.Marshal(m)
+	if err != nil {
+		return err
+	}
+	p.stdinMu.Lock()
+	defer p.stdinMu.Unlock()
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// readMessages handles the messages of the parser until it closes stdout,
+// and returns the last error it reported.
+func (p *external) readMessages(r io.Reader, log *logrus.Entry) (reported string) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		var m externalMessage
+		if err := jsoniter.Unmarshal(s.Bytes(), &m); err != nil {
+			log.Debugf("external parser wrote an invalid message: %s", s.Text())
+			continue
+		}
+		switch m.Type {
+		case "progress":
+			prev := p.Progress()
+			cur := Progress{
+				BytesRead:    m.BytesRead,
+				BytesWritten: m.BytesWritten,
+				LastDataTime: prev.LastDataTime,
+				Bitrate:      m.Bitrate,
+				StreamTime:   time.Duration(m.StreamTimeMS) * time.Millisecond,
+				Reconnects:   prev.Reconnects,
+			}
+			if cur.BytesRead > prev.BytesRead || cur.BytesWritten > prev.BytesWritten || cur.StreamTime > prev.StreamTime {
+				cur.LastDataTime = time.Now()
+			}
+			p.set(cur)
+		case "out":
+			if m.Out != "" {
+				p.mu.Lock()
+				p.out = m.Out
+				p.mu.Unlock()
+			}
+		case "log":
+			log.Info(m.Message)
+		case "error":
+			log.Error(m.Message)
+			reported = m.Message
+		}
+	}
+	// drain whatever is left so that the parser never blocks on a full pipe.
+	io.Copy(io.Discard, r)
+	return reported
+}
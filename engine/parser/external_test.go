@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+// TestExternalHelper is the external parser run by the tests, re-executing
+// the test binary in the mode given by OLIVE_EXTERNAL_MODE.
+func TestExternalHelper(t *testing.T) {
+	mode := os.Getenv("OLIVE_EXTERNAL_MODE")
+	if mode == "" {
+		return
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	var start externalMessage
+	if !in.Scan() || jsoniter.Unmarshal(in.Bytes(), &start) != nil || start.Type != "start" || start.StreamURL == "" {
+		fmt.Println(`{"type":"error","message":"no start message"}`)
+		os.Exit(2)
+	}
+	fmt.Println(`{"type":"progress","bytes_read":2048,"bytes_written":1024,"bitrate":8192,"stream_time_ms":1000}`)
+
+	switch mode {
+	case "normal":
+		fmt.Printf(`{"type":"out","out":%q}`+"\n", start.Out+".mkv")
+		fmt.Println(`{"type":"log","message":"done"}`)
+		os.Exit(0)
+	case "malformed":
+		fmt.Println(`not a message`)
+		fmt.Println(`{"type":"progress","bytes_read":4096,"bytes_written":2048}`)
+		fmt.Println(`{"type":"error","message":"stream gone"}`)
+		os.Exit(1)
+	case "stop":
+		for in.Scan() {
+			var m externalMessage
+			if jsoniter.Unmarshal(in.Bytes(), &m) == nil && m.Type == "stop" {
+				os.Exit(0)
+			}
+		}
+		os.Exit(1)
+	case "hang":
+		// ignores the stop message, waiting to be killed.
+		io.Copy(io.Discard, os.Stdin)
+		select {}
+	}
+}
+
+func newTestExternal(t *testing.T, mode string) *external {
+	t.Setenv("OLIVE_EXTERNAL_MODE", mode)
+	p := NewExternal("helper", config.ExternalParser{
+		Path: os.Args[0],
+		Args: []string{"-test.run=^TestExternalHelper$"},
+	}).(*external)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	p.SetLogger(log)
+	return p
+}
+
+// parse runs the parser in the background, returning the channel of its
+// result.
+func parse(p *external) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- p.Parse("https://example.com/live.flv", "out.flv")
+	}()
+	return result
+}
+
+func wait(t *testing.T, result <-chan error, within time.Duration) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(within):
+		t.Fatal("external parser did not exit in time")
+		return nil
+	}
+}
+
+// waitProgress waits for the parser to report its first progress.
+func waitProgress(t *testing.T, p *external) {
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Progress().BytesRead == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("external parser reported no progress")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	p := newTestExternal(t, "normal")
+	if err := wait(t, parse(p), 5*time.Second); err != nil {
+		t.Fatalf("got %v, want a clean exit", err)
+	}
+	if got := p.Out(); got != "out.flv.mkv" {
+		t.Errorf("got out %q, want the one reported", got)
+	}
+	progress := p.Progress()
+	if progress.BytesRead != 2048 || progress.BytesWritten != 1024 || progress.Bitrate != 8192 || progress.StreamTime != time.Second {
+		t.Errorf("got progress %+v", progress)
+	}
+	if progress.LastDataTime.IsZero() {
+		t.Error("data time was not updated")
+	}
+}
+
+func TestExternalMalformed(t *testing.T) {
+	p := newTestExternal(t, "malformed")
+	err := wait(t, parse(p), 5*time.Second)
+	if err == nil || err.Error() != "stream gone" {
+		t.Fatalf("got %v, want the error reported", err)
+	}
+	// the messages past the malformed line are still read.
+	if got := p.Progress().BytesRead; got != 4096 {
+		t.Errorf("got %d bytes read, want the last progress", got)
+	}
+}
+
+func TestExternalStop(t *testing.T) {
+	p := newTestExternal(t, "stop")
+	result := parse(p)
+	waitProgress(t, p)
+	p.Stop()
+	if err := wait(t, result, 5*time.Second); err != nil {
+		t.Errorf("got %v, want no error on stop", err)
+	}
+}
+
+func TestExternalStopKill(t *testing.T) {
+	p := newTestExternal(t, "hang")
+	p.stopTimeout = 100 * time.Millisecond
+	result := parse(p)
+	waitProgress(t, p)
+	p.Stop()
+	if err := wait(t, result, 5*time.Second); err != nil {
+		t.Errorf("got %v, want no error once killed on stop", err)
+	}
+}
@@ -1,11 +1,22 @@
 package parser
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"io"
+	"net/http"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 
-	l "github.com/go-olive/olive/engine/log"
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,7 +30,31 @@ func init() {
 	)
 }
 
+// defaultFfmpegProfile is used by the shows selecting no profile.
+var defaultFfmpegProfile = config.FfmpegProfile{
+	InputOptions:  []string{"-re"},
+	OutputOptions: []string{"-c", "copy", "-bsf:a", "aac_adtstoasc"},
+	Container:     "mp4",
+}
+
+// ffmpegFormats maps the containers to the ffmpeg muxers.
+var ffmpegFormats = map[string]string{
+	"mp4": "mp4",
+	"mkv": "matroska",
+	"ts":  "mpegts",
+	"flv": "flv",
+	// audio only containers.
+	"m4a":  "ipod",
+	"aac":  "adts",
+	"opus": "opus",
+}
+
 type ffmpeg struct {
+	meter
+	logged
+	opts Options
+	out  string
+
 	cmd      *exec.Cmd
 	cmdStdIn io.WriteCloser
 
@@ -43,37 +78,198 @@ func (p *ffmpeg) Type() string {
 	return "ffmpeg"
 }
 
+func (p *ffmpeg) Configure(opts Options) error {
+	if opts.FfmpegProfile != nil {
+		if c := opts.FfmpegProfile.Container; c != "" {
+			if _, ok := ffmpegFormats[c]; !ok {
+				return fmt.Errorf("ffmpeg container[%s] is not supported", c)
+			}
+		}
+	}
+	p.opts = opts
+	return nil
+}
+
+// Out returns the output filepath, whose extension follows the container.
+func (p *ffmpeg) Out() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.out
+}
+
 func (p *ffmpeg) Parse(streamURL string, out string) (err error) {
-	l.Logger.WithFields(logrus.Fields{
+	profile := defaultFfmpegProfile
+	if p.opts.FfmpegProfile != nil {
+		profile = *p.opts.FfmpegProfile
+	}
+	if profile.Container == "" {
+		profile.Container = defaultFfmpegProfile.Container
+	}
+	if p.opts.AudioOnly && profile.Container == "mp4" {
+		profile.Container = "m4a"
+	}
+	out = strings.TrimSuffix(out, filepath.Ext(out)) + "." + profile.Container
+
+	p.mu.Lock()
+	p.out = out
+	p.mu.Unlock()
+
+	p.logger().WithFields(logrus.Fields{
 		// "streamURL": streamURL,
 		"out": out,
 	}).Debug("ffmpeg working")
 
-	p.cmd = exec.Command(
-		"ffmpeg",
-		"-nostats",
-		"-progress", "-",
-		"-y", "-re",
-		"-user_agent", userAgent,
-		// "-referer", live.GetRawUrl(),
-		// "-timeout", "60000000",
-		"-i", streamURL,
-		"-c", "copy",
-		"-bsf:a", "aac_adtstoasc",
-		"-f", "mp4",
-		out,
-	)
+	args, err := p.args(profile, streamURL, out)
+	if err != nil {
+		return err
+	}
+	p.cmd = exec.Command("ffmpeg", args...)
 	// p.cmd.Stderr = os.Stderr
 	if p.cmdStdIn, err = p.cmd.StdinPipe(); err != nil {
 		return err
 	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
 	if err = p.cmd.Start(); err != nil {
-		p.cmd.Process.Kill()
 		return err
 	}
 	go func() {
 		<-p.stop
 		p.cmdStdIn.Write([]byte("q"))
 	}()
+	p.readProgress(stdout)
 	return p.cmd.Wait()
 }
+
+// args renders the profile into the ffmpeg arguments.
+func (p *ffmpeg) args(profile config.FfmpegProfile, streamURL, out string) ([]string, error) {
+	data := struct {
+		StreamURL string
+		Out       string
+		Referer   string
+	}{
+		StreamURL: streamURL,
+		Out:       out,
+		Referer:   p.opts.Referer,
+	}
+	render := func(s string) (string, error) {
+		tmpl, err := template.New("ffmpeg").Funcs(util.NameFuncMap).Parse(s)
+		if err != nil {
+			return "", err
+		}
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	renderAll := func(list []string) ([]string, error) {
+		res := make([]string, len(list))
+		for i, s := range list {
+			var err error
+			if res[i], err = render(s); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+
+	args := []string{"-nostats", "-progress", "-", "-y"}
+
+	input, err := renderAll(profile.InputOptions)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg input options: %w", err)
+	}
+	args = append(args, input...)
+
+	headers := map[string]string{"User-Agent": userAgent}
+	for k, v := range profile.Headers {
+		if headers[http.CanonicalHeaderKey(k)], err = render(v); err != nil {
+			return nil, fmt.Errorf("ffmpeg header[%s]: %w", k, err)
+		}
+	}
+	if ua := headers["User-Agent"]; ua != "" {
+		args = append(args, "-user_agent", ua)
+	}
+	delete(headers, "User-Agent")
+	if len(headers) > 0 {
+		keys := make([]string, 0, len(headers))
+		for k := range headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		for _, k := range keys {
+			sb.WriteString(k + ": " + headers[k] + "\r\n")
+		}
+		args = append(args, "-headers", sb.String())
+	}
+
+	args = append(args, "-i", streamURL)
+
+	output, err := renderAll(profile.OutputOptions)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg output options: %w", err)
+	}
+	args = append(args, output...)
+	if p.opts.AudioOnly {
+		args = append(args, "-vn")
+	}
+
+	if len(p.opts.Relays) == 0 {
+		return append(args, "-f", ffmpegFormats[profile.Container], out), nil
+	}
+
+	// the tee muxer writes the file and pushes the relays, a failing relay
+	// leaving the recording alone.
+	slaves := []string{fmt.Sprintf("[f=%s]%s", ffmpegFormats[profile.Container], teeEscape(out))}
+	for _, relay := range p.opts.Relays {
+		format := "flv"
+		if strings.HasPrefix(relay, "srt://") {
+			format = "mpegts"
+		}
+		slaves = append(slaves, fmt.Sprintf("[f=%s:onfail=ignore]%s", format, teeEscape(relay)))
+	}
+	return append(args, "-map", "0", "-f", "tee", strings.Join(slaves, "|")), nil
+}
+
+// teeEscape escapes the characters the tee muxer treats as special.
+func teeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// readProgress consumes the key=value blocks written by `-progress`, each
+// one ending with a "progress" key.
+func (p *ffmpeg) readProgress(r io.Reader) {
+	var cur Progress
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(s.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "total_size":
+			cur.BytesWritten, _ = strconv.ParseInt(value, 10, 64)
+		case "out_time_us":
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil {
+				cur.StreamTime = time.Duration(us) * time.Microsecond
+			}
+		case "bitrate":
+			if kbps, err := strconv.ParseFloat(strings.TrimSuffix(value, "kbits/s"), 64); err == nil {
+				cur.Bitrate = kbps * 1000
+			}
+		case "progress":
+			prev := p.Progress()
+			cur.LastDataTime = prev.LastDataTime
+			if cur.BytesWritten > prev.BytesWritten || cur.StreamTime > prev.StreamTime {
+				cur.LastDataTime = time.Now()
+			}
+			p.set(cur)
+		}
+	}
+	// drain whatever is left so that ffmpeg never blocks on a full pipe.
+	io.Copy(io.Discard, r)
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-olive/olive/engine/config"
+)
+
+func TestFfmpegArgs(t *testing.T) {
+	const (
+		stream = "https://example.com/live.flv"
+		out    = "/data/a.mp4"
+	)
+	common := []string{"-nostats", "-progress", "-", "-y"}
+	join := func(lists ...[]string) []string {
+		var args []string
+		for _, list := range lists {
+			args = append(args, list...)
+		}
+		return args
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		profile config.FfmpegProfile
+		want    []string
+	}{
+		{
+			name:    "default",
+			profile: defaultFfmpegProfile,
+			want: join(common, []string{"-re", "-user_agent", userAgent, "-i", stream,
+				"-c", "copy", "-bsf:a", "aac_adtstoasc", "-f", "mp4", out}),
+		},
+		{
+			name: "profile",
+			opts: Options{Referer: "https://example.com/room"},
+			profile: config.FfmpegProfile{
+				InputOptions:  []string{"-rw_timeout", "10000000"},
+				OutputOptions: []string{"-c:v", "libx264", "-metadata", "comment={{ .StreamURL }}"},
+				Container:     "mkv",
+				Headers: map[string]string{
+					"user-agent": "olive",
+					"referer":    "{{ .Referer }}",
+					"Origin":     "https://example.com",
+				},
+			},
+			want: join(common, []string{"-rw_timeout", "10000000", "-user_agent", "olive",
+				"-headers", "Origin: https://example.com\r\nReferer: https://example.com/room\r\n",
+				"-i", stream, "-c:v", "libx264", "-metadata", "comment=" + stream, "-f", "matroska", out}),
+		},
+		{
+			name:    "audio only",
+			opts:    Options{AudioOnly: true},
+			profile: config.FfmpegProfile{Container: "aac"},
+			want:    join(common, []string{"-user_agent", userAgent, "-i", stream, "-vn", "-f", "adts", out}),
+		},
+		{
+			name:    "relays",
+			opts:    Options{Relays: []string{"rtmp://example.com/live/a", "srt://example.com:9000"}},
+			profile: config.FfmpegProfile{Container: "ts"},
+			want: join(common, []string{"-user_agent", userAgent, "-i", stream, "-map", "0", "-f", "tee",
+				"[f=mpegts]/data/a.mp4|[f=flv:onfail=ignore]rtmp://example.com/live/a|[f=mpegts:onfail=ignore]srt://example.com:9000"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ffmpeg{opts: tt.opts}
+			got, err := p.args(tt.profile, stream, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	p := &ffmpeg{}
+	if _, err := p.args(config.FfmpegProfile{OutputOptions: []string{"{{ .Unknown }}"}}, stream, out); err == nil {
+		t.Error("got no error, want the unknown field rejected")
+	}
+}
+
+func TestFfmpegConfigure(t *testing.T) {
+	p := new(ffmpeg).New().(*ffmpeg)
+	if err := p.Configure(Options{FfmpegProfile: &config.FfmpegProfile{Container: "avi"}}); err == nil {
+		t.Error("got no error, want the unknown container rejected")
+	}
+	if err := p.Configure(Options{FfmpegProfile: &config.FfmpegProfile{Container: "mkv"}}); err != nil {
+		t.Errorf("got %v, want the container taken", err)
+	}
+}
@@ -1,8 +1,14 @@
 package parser
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
 	"github.com/go-olive/flv"
-	l "github.com/go-olive/olive/engine/log"
 	"github.com/sirupsen/logrus"
 )
 
@@ -14,19 +20,192 @@ func init() {
 
 type customFlv struct {
 	*flv.Parser
+	meter
+	logged
+	opts Options
+	out  string
+
+	closeOnce sync.Once
+	stop      chan struct{}
 }
 
 func (this *customFlv) New() Parser {
 	return &customFlv{
 		Parser: flv.NewParser(),
+		stop:   make(chan struct{}),
 	}
 }
 
+func (this *customFlv) Stop() {
+	this.Parser.Stop()
+	this.closeOnce.Do(func() {
+		close(this.stop)
+	})
+}
+
+func (this *customFlv) Type() string {
+	return "flv"
+}
+
+func (this *customFlv) Configure(opts Options) error {
+	this.opts = opts
+	return nil
+}
+
 func (this *customFlv) Parse(streamURL string, out string) (err error) {
-	l.Logger.WithFields(logrus.Fields{
+	this.logger().WithFields(logrus.Fields{
 		// "streamURL": streamURL,
 		"out": out,
 	}).Debug("flv working")
 
-	return this.Parser.Parse(streamURL, out)
+	// the tags are only looked into when something else than the file wants them.
+	if !this.opts.AudioOnly && len(this.opts.Relays) == 0 && this.opts.Preview == nil {
+		this.mu.Lock()
+		this.out = out
+		this.mu.Unlock()
+		return this.Parser.Parse(streamURL, out)
+	}
+	return this.parseTags(streamURL, out)
+}
+
+// Progress is measured from the size of the output file when the flv parser
+// writes it on its own.
+func (this *customFlv) Progress() Progress {
+	this.mu.Lock()
+	out := this.out
+	this.mu.Unlock()
+
+	if out != "" {
+		this.sized(out)
+	}
+	return this.meter.Progress()
+}
+
+// parseTags records the stream tag by tag, handing them over to the relays,
+// the preview or the audio only writer.
+func (this *customFlv) parseTags(streamURL string, out string) (err error) {
+	req, err := http.NewRequest(http.MethodGet, streamURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("User-Agent", userAgent)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	body := &meterReader{ReadCloser: resp.Body, m: &this.meter}
+
+	// closing the body unblocks a pending read of a stalled stream.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-this.stop:
+			resp.Body.Close()
+		case <-done:
+		}
+	}()
+
+	d, err := flv.NewDemuxer(body)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	fw := &meterWriter{WriteCloser: f, m: &this.meter}
+	var (
+		m     flv.Muxer
+		audio *adtsWriter
+	)
+	if this.opts.AudioOnly {
+		audio = &adtsWriter{w: fw}
+		defer fw.Close()
+	} else {
+		if m, err = flv.NewMuxer(fw); err != nil {
+			f.Close()
+			return err
+		}
+		defer m.Close()
+	}
+
+	// the header is checked by hand, the demuxer decoding more than it reads.
+	header := flv.GetHeaderCompo()
+	defer header.Put()
+	header.Raw = [13]byte{}
+	d.ReadHeader(header)
+	if string(header.Raw[:3]) != "FLV" {
+		return this.stopped(fmt.Errorf("%w: invalid flv header", ErrUnsupported))
+	}
+	if m != nil {
+		if err := m.WriteHeader(header); err != nil {
+			return err
+		}
+	}
+
+	var rs *relays
+	if len(this.opts.Relays) > 0 {
+		rs = newRelays(this.opts.Relays, this.logger())
+		defer rs.close()
+	}
+
+	var firstTS int64 = -1
+	for {
+		select {
+		case <-this.stop:
+			return nil
+		default:
+		}
+
+		tag := new(flv.TagCompo)
+		if err := d.ReadTag(tag); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return this.stopped(err)
+		}
+		var err error
+		if audio == nil {
+			err = m.WriteTag(tag)
+		}
+		// the muxer rewrites the timestamp in place, relative to the first tag.
+		raw := tag.TagHeaderRaw
+		ts := uint32(raw[7])<<24 | uint32(raw[4])<<16 | uint32(raw[5])<<8 | uint32(raw[6])
+		if audio != nil {
+			if firstTS < 0 {
+				firstTS = int64(ts)
+			}
+			ts = uint32(int64(ts) - firstTS)
+		}
+		this.streamTime(time.Duration(ts) * time.Millisecond)
+		if err == nil && len(tag.TagBodyRaw) >= 4 {
+			body := tag.TagBodyRaw[:len(tag.TagBodyRaw)-4]
+			if audio != nil {
+				err = audio.WriteTag(tag.TagType, ts, body)
+			}
+			if rs != nil {
+				rs.write(tag.TagType, ts, body)
+			}
+			if this.opts.Preview != nil {
+				this.opts.Preview.WriteTag(tag.TagType, ts, body)
+			}
+		}
+		tag.Free()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// stopped swallows the errors caused by Stop.
+func (this *customFlv) stopped(err error) error {
+	select {
+	case <-this.stop:
+		return nil
+	default:
+		return err
+	}
 }
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlvProgress(t *testing.T) {
+	stream := []byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0}
+	// an audio tag of 2 bytes followed by its previous tag size.
+	stream = append(stream, 8, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0xaf, 0x01, 0, 0, 0, 13)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(stream)
+	}))
+	defer ts.Close()
+
+	p := new(customFlv).New()
+	out := filepath.Join(t.TempDir(), "out.flv")
+	if err := p.Parse(ts.URL, out); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := p.(Progresser).Progress().BytesWritten
+	if got == 0 || got != fi.Size() {
+		t.Errorf("got %d bytes written, want the %d bytes of the file", got, fi.Size())
+	}
+}
@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"github.com/go-olive/olive/foundation/hls"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	SharedManager.Register(
+		new(customHLS),
+	)
+}
+
+type customHLS struct {
+	*hls.Parser
+	logged
+}
+
+func (this *customHLS) New() Parser {
+	return &customHLS{
+		Parser: hls.NewParser(),
+	}
+}
+
+// Configure sends the Referer along with the requests of the stream, which
+// some platforms check.
+func (this *customHLS) Configure(opts Options) error {
+	if opts.Referer != "" {
+		this.Parser.SetHeader("Referer", opts.Referer)
+	}
+	return nil
+}
+
+func (this *customHLS) Parse(streamURL string, out string) (err error) {
+	this.logger().WithFields(logrus.Fields{
+		// "streamURL": streamURL,
+		"out": out,
+	}).Debug("hls working")
+
+	return this.Parser.Parse(streamURL, out)
+}
+
+func (this *customHLS) Progress() Progress {
+	s := this.Parser.Stats()
+	p := Progress{
+		BytesRead:    s.BytesRead,
+		BytesWritten: s.BytesWritten,
+		LastDataTime: s.LastDataTime,
+		StreamTime:   s.StreamTime,
+		Reconnects:   s.PlaylistFailures,
+	}
+	if secs := s.StreamTime.Seconds(); secs > 0 {
+		p.Bitrate = float64(s.BytesWritten) * 8 / secs
+	}
+	return p
+}
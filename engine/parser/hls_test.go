@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestHLSReferer(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		referers []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		referers = append(referers, r.Header.Get("Referer"))
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/index.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1.0,\n/seg0.ts\n#EXT-X-ENDLIST\n")
+		case "/seg0.ts":
+			w.Write([]byte("segment"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := new(customHLS).New()
+	c, ok := p.(Configurable)
+	if !ok {
+		t.Fatal("the hls parser does not take options")
+	}
+	const referer = "https://live.example.com/1"
+	if err := c.Configure(Options{Referer: referer}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(ts.URL+"/index.m3u8", filepath.Join(t.TempDir(), "out.ts")); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(referers) != 2 {
+		t.Fatalf("got %d requests, want the playlist and the segment", len(referers))
+	}
+	for _, got := range referers {
+		if got != referer {
+			t.Errorf("got referer %q, want %q", got, referer)
+		}
+	}
+}
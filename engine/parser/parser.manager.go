@@ -1,9 +1,22 @@
 package parser
 
-import l "github.com/go-olive/olive/engine/log"
+import (
+	"errors"
 
+	"github.com/go-olive/olive/engine/config"
+	"github.com/sirupsen/logrus"
+)
+
+// SharedManager holds the built-in parsers, registered on init. It is shared
+// by the kernels of a process as it only holds prototypes: every recording
+// gets its own parser from New, and the parsers configured per kernel, like
+// the external ones, are looked up in the config of the show instead.
 var SharedManager = &Manager{}
 
+// ErrUnsupported is returned by parsers which can not record the stream at
+// all, the recorder then moves on to the next parser of the show at once.
+var ErrUnsupported = errors.New("stream not supported by the parser")
+
 type Manager struct {
 	savers map[string]Parser
 }
@@ -15,7 +28,7 @@ func (p *Manager) Register(parsers ...Parser) {
 	for _, parser := range parsers {
 		_, ok := p.savers[parser.Type()]
 		if ok {
-			l.Logger.Errorf("[%T]Type(%s)已注册\n", p, parser.Type())
+			logrus.Errorf("[%T]Type(%s)已注册\n", p, parser.Type())
 		}
 		p.savers[parser.Type()] = parser
 	}
@@ -32,3 +45,58 @@ type Parser interface {
 	Parse(streamURL string, out string) error
 	Stop()
 }
+
+// Outputter is implemented by parsers which settle the output filepath on
+// their own, e.g. picking the extension matching the stream container.
+type Outputter interface {
+	Out() string
+}
+
+// Logger is implemented by parsers which log, the recorder hands them the
+// logger of its kernel before Parse.
+type Logger interface {
+	SetLogger(*logrus.Logger)
+}
+
+// logged implements Logger for the parsers embedding it.
+type logged struct {
+	log *logrus.Logger
+}
+
+func (lg *logged) SetLogger(log *logrus.Logger) {
+	lg.log = log
+}
+
+// logger returns the logger handed over, the standard one if none was.
+func (lg *logged) logger() *logrus.Logger {
+	if lg.log == nil {
+		return logrus.StandardLogger()
+	}
+	return lg.log
+}
+
+// Options are the settings of a single recording.
+type Options struct {
+	Referer       string
+	FfmpegProfile *config.FfmpegProfile
+	// Relays are the rtmp, rtmps or srt urls the stream is pushed to while
+	// being recorded.
+	Relays []string
+	// AudioOnly drops the video, keeping the audio track only.
+	AudioOnly bool
+	// Preview receives the tags of flv streams, for the viewers of the
+	// recording in progress.
+	Preview TagWriter
+}
+
+// TagWriter receives the body of flv tags, without their trailing previous
+// tag size.
+type TagWriter interface {
+	WriteTag(typ byte, timestamp uint32, data []byte) error
+}
+
+// Configurable is implemented by parsers which take Options, it is called
+// before Parse.
+type Configurable interface {
+	Configure(Options) error
+}
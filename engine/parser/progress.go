@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// bitrateWindow is the period the bitrate is averaged over.
+const bitrateWindow = 5 * time.Second
+
+// Progress is a snapshot of a running parser. Zero values mean the parser
+// has no way to tell.
+type Progress struct {
+	BytesRead    int64         `json:"bytes_read"`
+	BytesWritten int64         `json:"bytes_written"`
+	LastDataTime time.Time     `json:"last_data_time"`
+	Bitrate      float64       `json:"bitrate"`
+	StreamTime   time.Duration `json:"stream_time"`
+	Reconnects   int           `json:"reconnects"`
+}
+
+// Progresser is implemented by parsers which report their progress.
+type Progresser interface {
+	Progress() Progress
+}
+
+// meter keeps track of the progress of a parser.
+type meter struct {
+	mu sync.Mutex
+	p  Progress
+
+	sampleTime  time.Time
+	sampleBytes int64
+}
+
+func (m *meter) Progress() Progress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.p
+}
+
+func (m *meter) read(n int) {
+	if n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.p.BytesRead += int64(n)
+	m.touch()
+}
+
+func (m *meter) written(n int) {
+	if n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.p.BytesWritten += int64(n)
+	m.touch()
+}
+
+// touch refreshes the last data time and the bitrate, m.mu must be held.
+func (m *meter) touch() {
+	now := time.Now()
+	m.p.LastDataTime = now
+
+	total := m.p.BytesRead
+	if total == 0 {
+		total = m.p.BytesWritten
+	}
+	if m.sampleTime.IsZero() {
+		m.sampleTime, m.sampleBytes = now, total
+		return
+	}
+	if elapsed := now.Sub(m.sampleTime); elapsed >= bitrateWindow {
+		m.p.Bitrate = float64(total-m.sampleBytes) * 8 / elapsed.Seconds()
+		m.sampleTime, m.sampleBytes = now, total
+	}
+}
+
+func (m *meter) streamTime(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.p.StreamTime = d
+}
+
+func (m *meter) reconnect() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.p.Reconnects++
+}
+
+// sized catches up with the size of the first of the files which exists, for
+// parsers writing the output on their own.
+func (m *meter) sized(names ...string) {
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if cur := m.Progress(); fi.Size() > cur.BytesWritten {
+			m.written(int(fi.Size() - cur.BytesWritten))
+		}
+		return
+	}
+}
+
+// set replaces the whole snapshot, for parsers reporting their own totals.
+func (m *meter) set(p Progress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.p = p
+}
+
+type meterReader struct {
+	io.ReadCloser
+	m *meter
+}
+
+func (r *meterReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.m.read(n)
+	return n, err
+}
+
+type meterWriter struct {
+	io.WriteCloser
+	m *meter
+}
+
+func (w *meterWriter) Write(b []byte) (int, error) {
+	n, err := w.WriteCloser.Write(b)
+	w.m.written(n)
+	return n, err
+}
@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/foundation/rtmp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	relayDialTimeout = 10 * time.Second
+	relayQueueSize   = 1024
+	relayMinBackoff  = 2 * time.Second
+	relayMaxBackoff  = time.Minute
+
+	tagAudio  = 8
+	tagVideo  = 9
+	tagScript = 18
+)
+
+// relaySink is a relay target receiving flv tags.
+type relaySink interface {
+	TagWriter
+	Close() error
+}
+
+func dialRelay(target string) (relaySink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "rtmp", "rtmps":
+		return rtmp.Dial(target, relayDialTimeout)
+	case "srt":
+		return newSRTSink(target)
+	default:
+		return nil, fmt.Errorf("relay scheme[%s] is not supported", u.Scheme)
+	}
+}
+
+type relayTag struct {
+	typ  byte
+	ts   uint32
+	data []byte
+}
+
+func (t relayTag) isKeyframe() bool {
+	return t.typ == tagVideo && len(t.data) > 0 && t.data[0]>>4 == 1
+}
+
+// relays fans the flv tags out to the relay targets. Every target has its
+// own queue and reconnects, a failing one holds back neither the others nor
+// the recording.
+type relays struct {
+	mu      sync.Mutex
+	headers map[byte]relayTag
+
+	targets   []*relay
+	closeOnce sync.Once
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newRelays(targets []string, log *logrus.Logger) *relays {
+	rs := &relays{
+		headers: make(map[byte]relayTag),
+		stop:    make(chan struct{}),
+	}
+	for _, target := range targets {
+		r := &relay{
+			target: target,
+			tags:   make(chan relayTag, relayQueueSize),
+			rs:     rs,
+			log:    log,
+		}
+		rs.targets = append(rs.targets, r)
+		rs.wg.Add(1)
+		go func() {
+			defer rs.wg.Done()
+			r.run()
+		}()
+	}
+	return rs
+}
+
+// write queues a tag body, without its trailing previous tag size.
+func (rs *relays) write(typ byte, ts uint32, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	t := relayTag{typ: typ, ts: ts, data: append([]byte(nil), body...)}
+
+	// the metadata and the sequence headers are sent again on reconnects.
+	var header bool
+	switch typ {
+	case tagScript:
+		header = true
+	case tagVideo:
+		codec := t.data[0] & 0x0f
+		header = (codec == 7 || codec == 12) && len(t.data) > 1 && t.data[1] == 0
+	case tagAudio:
+		header = t.data[0]>>4 == 10 && len(t.data) > 1 && t.data[1] == 0
+	}
+	if header {
+		rs.mu.Lock()
+		rs.headers[typ] = t
+		rs.mu.Unlock()
+	}
+
+	for _, r := range rs.targets {
+		r.push(t)
+	}
+}
+
+func (rs *relays) headerTags() []relayTag {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var list []relayTag
+	for _, typ := range []byte{tagScript, tagVideo, tagAudio} {
+		if t, ok := rs.headers[typ]; ok {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+func (rs *relays) close() {
+	rs.closeOnce.Do(func() {
+		close(rs.stop)
+	})
+	rs.wg.Wait()
+}
+
+type relay struct {
+	target string
+	tags   chan relayTag
+	rs     *relays
+	log    *logrus.Logger
+
+	// dropping is set once the queue overflowed, video is then skipped up to
+	// the next keyframe.
+	dropping bool
+}
+
+func (r *relay) push(t relayTag) {
+	if r.dropping && t.typ == tagVideo {
+		if !t.isKeyframe() {
+			return
+		}
+		r.dropping = false
+	}
+	select {
+	case r.tags <- t:
+	default:
+		r.dropping = true
+	}
+}
+
+func (r *relay) run() {
+	log := r.log.WithFields(logrus.Fields{
+		"relay": redact(r.target),
+	})
+
+	backoff := relayMinBackoff
+	for {
+		sink, err := dialRelay(r.target)
+		if err == nil {
+			log.Info("relay start")
+			backoff = relayMinBackoff
+			err = r.pump(sink)
+			sink.Close()
+			if err == nil {
+				log.Info("relay stop")
+				return
+			}
+		}
+		log.Errorf("relay failed, retry in %s: %s", backoff, err)
+
+		select {
+		case <-r.rs.stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > relayMaxBackoff {
+			backoff = relayMaxBackoff
+		}
+	}
+}
+
+// pump writes the queued tags to sink until the relays stop or sink fails.
+func (r *relay) pump(sink relaySink) error {
+	// whatever queued up while disconnected is stale.
+	for drained := false; !drained; {
+		select {
+		case <-r.tags:
+		default:
+			drained = true
+		}
+	}
+
+	var ts uint32
+	for _, t := range r.rs.headerTags() {
+		if err := sink.WriteTag(t.typ, t.ts, t.data); err != nil {
+			return err
+		}
+		ts = t.ts
+	}
+
+	started := false
+	for {
+		select {
+		case <-r.rs.stop:
+			return nil
+		case t := <-r.tags:
+			if !started && t.typ == tagVideo {
+				if !t.isKeyframe() {
+					continue
+				}
+				started = true
+			}
+			if t.ts < ts {
+				t.ts = ts
+			}
+			if err := sink.WriteTag(t.typ, t.ts, t.data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// redact keeps the stream key out of the logs.
+func redact(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "invalid url"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// srtSink pushes to a SRT target through ffmpeg, remuxing the flv tags into
+// mpegts.
+type srtSink struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	done  chan struct{}
+}
+
+func newSRTSink(target string) (*srtSink, error) {
+	cmd := exec.Command(
+		"ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-f", "flv",
+		"-i", "pipe:0",
+		"-c", "copy",
+		"-f", "mpegts",
+		target,
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &srtSink{
+		cmd:   cmd,
+		stdin: stdin,
+		done:  make(chan struct{}),
+	}
+	go func() {
+		cmd.Wait()
+		close(s.done)
+	}()
+
+	if _, err := stdin.Write([]byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0}); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *srtSink) WriteTag(typ byte, timestamp uint32, data []byte) error {
+	select {
+	case <-s.done:
+		return errors.New("ffmpeg exited")
+	default:
+	}
+
+	n := len(data)
+	header := []byte{
+		typ, byte(n >> 16), byte(n >> 8), byte(n),
+		byte(timestamp >> 16), byte(timestamp >> 8), byte(timestamp), byte(timestamp >> 24),
+		0, 0, 0,
+	}
+	prev := uint32(n + len(header))
+	tag := append(append(header, data...), byte(prev>>24), byte(prev>>16), byte(prev>>8), byte(prev))
+	_, err := s.stdin.Write(tag)
+	return err
+}
+
+func (s *srtSink) Close() error {
+	s.stdin.Close()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		s.cmd.Process.Kill()
+		<-s.done
+	}
+	return nil
+}
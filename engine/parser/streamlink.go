@@ -2,10 +2,10 @@ package parser
 
 import (
 	"io"
+	"os"
 	"os/exec"
 	"sync"
 
-	l "github.com/go-olive/olive/engine/log"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +16,9 @@ func init() {
 }
 
 type streamlink struct {
+	meter
+	logged
+
 	cmd      *exec.Cmd
 	cmdStdIn io.WriteCloser
 
@@ -39,26 +42,36 @@ func (s *streamlink) Type() string {
 	return "streamlink"
 }
 
-// streamlink -o "a.mp4"  https://www.twitch.tv/nnabi best -f
+// streamlink -O https://www.twitch.tv/nnabi best
 func (s *streamlink) Parse(streamURL string, out string) (err error) {
-	l.Logger.WithFields(logrus.Fields{
+	s.logger().WithFields(logrus.Fields{
 		// "streamURL": streamURL,
 		"out": out,
 	}).Debug("streamlink working")
 
 	s.cmd = exec.Command(
 		"streamlink",
-		"-o", out,
+		"-O",
 		streamURL,
 		"best",
-		"-f",
 	)
 	// s.cmd.Stderr = os.Stderr
 	if s.cmdStdIn, err = s.cmd.StdinPipe(); err != nil {
 		return err
 	}
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
 	if err = s.cmd.Start(); err != nil {
-		s.cmd.Process.Kill()
+		f.Close()
+		os.Remove(out)
 		return err
 	}
 	go func() {
@@ -66,5 +79,7 @@ func (s *streamlink) Parse(streamURL string, out string) (err error) {
 		// s.cmdStdIn.Write([]byte("\x03"))
 		s.cmd.Process.Kill()
 	}()
+	// the stream goes through stdout so that its progress can be measured.
+	io.Copy(&meterWriter{WriteCloser: f, m: &s.meter}, &meterReader{ReadCloser: stdout, m: &s.meter})
 	return s.cmd.Wait()
 }
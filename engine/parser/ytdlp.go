@@ -5,7 +5,6 @@ import (
 	"os/exec"
 	"sync"
 
-	l "github.com/go-olive/olive/engine/log"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +15,10 @@ func init() {
 }
 
 type ytdlp struct {
+	meter
+	logged
+	out string
+
 	cmd      *exec.Cmd
 	cmdStdIn io.WriteCloser
 
@@ -41,11 +44,15 @@ func (p *ytdlp) Type() string {
 
 // yt-dlp -f "bv[height=1080]+ba/b" https://www.youtube.com/watch?v=f6PdkucL1hk
 func (p *ytdlp) Parse(streamURL string, out string) (err error) {
-	l.Logger.WithFields(logrus.Fields{
+	p.logger().WithFields(logrus.Fields{
 		// "streamURL": streamURL,
 		"out": out,
 	}).Debug("yt-dlp working")
 
+	p.mu.Lock()
+	p.out = out
+	p.mu.Unlock()
+
 	p.cmd = exec.Command(
 		"yt-dlp",
 		"-o", out,
@@ -67,3 +74,16 @@ func (p *ytdlp) Parse(streamURL string, out string) (err error) {
 	}()
 	return p.cmd.Wait()
 }
+
+// Progress is measured from the size of the output file, yt-dlp writing to a
+// ".part" file until the download completes.
+func (p *ytdlp) Progress() Progress {
+	p.mu.Lock()
+	out := p.out
+	p.mu.Unlock()
+
+	if out != "" {
+		p.sized(out+".part", out)
+	}
+	return p.meter.Progress()
+}
@@ -0,0 +1,249 @@
+// Package preview re-serves the recordings in progress as HTTP-FLV, teeing
+// the tags out of the running parsers. Only the flv parser feeds the
+// preview, the other ones writing the stream out without parsing it.
+package preview
+
+import (
+	"io"
+	"sync"
+
+	"github.com/go-olive/olive/engine/config"
+)
+
+const (
+	tagAudio  = 8
+	tagVideo  = 9
+	tagScript = 18
+
+	// queueSize is the number of tags a viewer may lag behind before video is
+	// skipped up to the next keyframe.
+	queueSize = 512
+)
+
+// Hub holds the preview streams of the shows being recorded.
+type Hub struct {
+	mu      sync.RWMutex
+	streams map[config.ID]*Stream
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		streams: make(map[config.ID]*Stream),
+	}
+}
+
+// Open returns a preview stream for a show. It is served once the first tag
+// is written, so that parsers feeding no tags leave no stream to watch.
+func (h *Hub) Open(id config.ID) *Stream {
+	return &Stream{
+		hub:     h,
+		id:      id,
+		headers: make(map[byte]tag),
+		viewers: make(map[*viewer]struct{}),
+	}
+}
+
+// publish serves the stream of a show, closing the previous one.
+func (h *Hub) publish(s *Stream) {
+	h.mu.Lock()
+	old := h.streams[s.id]
+	h.streams[s.id] = s
+	h.mu.Unlock()
+
+	if old != nil && old != s {
+		old.close()
+	}
+}
+
+// Close ends the preview stream of a show, disconnecting its viewers.
+func (h *Hub) Close(s *Stream) {
+	h.mu.Lock()
+	if h.streams[s.id] == s {
+		delete(h.streams, s.id)
+	}
+	h.mu.Unlock()
+
+	s.close()
+}
+
+// Stream returns the preview stream of a show.
+func (h *Hub) Stream(id config.ID) (*Stream, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	s, ok := h.streams[id]
+	return s, ok
+}
+
+type tag struct {
+	typ  byte
+	ts   uint32
+	data []byte
+}
+
+func (t tag) isKeyframe() bool {
+	return t.typ == tagVideo && len(t.data) > 0 && t.data[0]>>4 == 1
+}
+
+// isHeader reports whether the tag is the metadata or a sequence header,
+// which every viewer needs before the first frame.
+func (t tag) isHeader() bool {
+	if len(t.data) == 0 {
+		return false
+	}
+	switch t.typ {
+	case tagScript:
+		return true
+	case tagVideo:
+		codec := t.data[0] & 0x0f
+		return (codec == 7 || codec == 12) && len(t.data) > 1 && t.data[1] == 0
+	case tagAudio:
+		return t.data[0]>>4 == 10 && len(t.data) > 1 && t.data[1] == 0
+	}
+	return false
+}
+
+// Stream fans the tags of a recording out to its viewers.
+type Stream struct {
+	hub         *Hub
+	id          config.ID
+	publishOnce sync.Once
+
+	mu      sync.Mutex
+	headers map[byte]tag
+	viewers map[*viewer]struct{}
+	closed  bool
+}
+
+type viewer struct {
+	tags chan tag
+	// started is set once the viewer got the headers and a keyframe.
+	started bool
+	// dropping is set once the queue overflowed.
+	dropping bool
+}
+
+// WriteTag receives the body of a flv tag, without its trailing previous tag
+// size.
+func (s *Stream) WriteTag(typ byte, timestamp uint32, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	t := tag{typ: typ, ts: timestamp, data: append([]byte(nil), data...)}
+	s.publishOnce.Do(func() {
+		s.hub.publish(s)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if t.isHeader() {
+		s.headers[typ] = t
+		return nil
+	}
+	_, hasVideo := s.headers[tagVideo]
+	for v := range s.viewers {
+		if !v.started {
+			// viewers join on a keyframe, or on any audio frame of audio only
+			// streams.
+			if !t.isKeyframe() && (hasVideo || t.typ != tagAudio) {
+				continue
+			}
+			v.started = true
+			for _, typ := range []byte{tagScript, tagVideo, tagAudio} {
+				if h, ok := s.headers[typ]; ok {
+					h.ts = t.ts
+					s.push(v, h)
+				}
+			}
+		}
+		s.push(v, t)
+	}
+	return nil
+}
+
+func (s *Stream) push(v *viewer, t tag) {
+	if v.dropping && t.typ == tagVideo {
+		if !t.isKeyframe() {
+			return
+		}
+		v.dropping = false
+	}
+	select {
+	case v.tags <- t:
+	default:
+		v.dropping = true
+	}
+}
+
+func (s *Stream) subscribe() (*viewer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, false
+	}
+	v := &viewer{tags: make(chan tag, queueSize)}
+	s.viewers[v] = struct{}{}
+	return v, true
+}
+
+func (s *Stream) unsubscribe(v *viewer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.viewers[v]; ok {
+		delete(s.viewers, v)
+		close(v.tags)
+	}
+}
+
+func (s *Stream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for v := range s.viewers {
+		delete(s.viewers, v)
+		close(v.tags)
+	}
+}
+
+// ServeFLV writes the stream to w as a flv file until the recording ends, w
+// fails or done is closed.
+func (s *Stream) ServeFLV(w io.Writer, done <-chan struct{}) error {
+	v, ok := s.subscribe()
+	if !ok {
+		return io.EOF
+	}
+	defer s.unsubscribe(v)
+
+	if _, err := w.Write([]byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	buf := make([]byte, 0, 64*1024)
+	for {
+		select {
+		case <-done:
+			return nil
+		case t, ok := <-v.tags:
+			if !ok {
+				return nil
+			}
+			buf = appendTag(buf[:0], t)
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func appendTag(buf []byte, t tag) []byte {
+	n := len(t.data)
+	buf = append(buf,
+		t.typ, byte(n>>16), byte(n>>8), byte(n),
+		byte(t.ts>>16), byte(t.ts>>8), byte(t.ts), byte(t.ts>>24),
+		0, 0, 0,
+	)
+	buf = append(buf, t.data...)
+	prev := uint32(11 + n)
+	return append(buf, byte(prev>>24), byte(prev>>16), byte(prev>>8), byte(prev))
+}
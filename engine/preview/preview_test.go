@@ -0,0 +1,73 @@
+package preview_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/go-olive/olive/engine/preview"
+)
+
+func TestStream(t *testing.T) {
+	hub := preview.NewHub()
+	s := hub.Open("a")
+	if _, ok := hub.Stream("a"); ok {
+		t.Fatal("Should not serve the stream before the first tag")
+	}
+
+	s.WriteTag(18, 0, []byte{0x02})
+	s.WriteTag(9, 0, []byte{0x17, 0x00, 0x01})
+	if _, ok := hub.Stream("a"); !ok {
+		t.Fatal("Should serve the stream once tags are written")
+	}
+
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServeFLV(w, nil)
+		w.Close()
+	}()
+
+	// the viewer is subscribed once the flv header is written.
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		s.WriteTag(9, 40, []byte{0x27, 0x01, 0xaa})
+		s.WriteTag(9, 80, []byte{0x17, 0x01, 0xbb})
+		hub.Close(s)
+	}()
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := append(header, body...)
+	if err := <-done; err != nil {
+		t.Fatalf("Should stop once the stream is closed: %s", err)
+	}
+	if _, ok := hub.Stream("a"); ok {
+		t.Error("Should not serve the closed stream")
+	}
+
+	want := []byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0}
+	for _, tag := range []struct {
+		typ  byte
+		ts   byte
+		data []byte
+	}{
+		// the viewer joins on the keyframe, the headers ahead of it.
+		{18, 80, []byte{0x02}},
+		{9, 80, []byte{0x17, 0x00, 0x01}},
+		{9, 80, []byte{0x17, 0x01, 0xbb}},
+	} {
+		n := byte(len(tag.data))
+		want = append(want, tag.typ, 0, 0, n, 0, 0, tag.ts, 0, 0, 0, 0)
+		want = append(want, tag.data...)
+		want = append(want, 0, 0, 0, 11+n)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Should serve\n%v\ngot\n%v", want, got)
+	}
+}
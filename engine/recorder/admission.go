@@ -0,0 +1,278 @@
+package recorder
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/sirupsen/logrus"
+)
+
+// Decision tells why a live show is waiting instead of being recorded.
+type Decision struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// queued is a live show waiting for a recorder, because MaxRecorders or
+// MaxRecordKbps was reached, or because another show of its streamer is
+// being recorded.
+type queued struct {
+	bout config.Bout
+	Decision
+}
+
+// schedule decides whether the show starts recording now, pre-empting the
+// recorders making room for it, or waits in the queue. m.mu must be held.
+func (m *Manager) schedule(bout config.Bout) bool {
+	reason, losers := m.yield(bout)
+	if reason != "" {
+		m.enqueue(bout, reason)
+		return false
+	}
+	victim, reason, ok := m.admit(bout)
+	if len(losers) > 0 {
+		// the shows giving way make room for it.
+		victim, ok = nil, true
+	}
+	if !ok {
+		m.enqueue(bout, reason)
+		return false
+	}
+	delete(m.queue, bout.GetID())
+	if victim != nil {
+		m.preempt(victim, fmt.Sprintf("pre-empted by %s (priority %d)", name(bout), bout.GetPriority()))
+	}
+	for _, r := range losers {
+		m.preempt(r, fmt.Sprintf("giving way to %s on %s", name(bout), bout.GetPlatform()))
+	}
+	return true
+}
+
+// name returns what the show is called in the reasons, its streamer or its
+// room. Unlike GetStreamerName, it does not snap the show, m.mu being held.
+func name(bout config.Bout) string {
+	if s := bout.GetStreamer(); s != nil && s.Name != "" {
+		return s.Name
+	}
+	return bout.GetPlatform() + "/" + bout.GetRoomID()
+}
+
+// admit decides whether the show may start recording, m.mu must be held.
+// When the limits are reached, the lowest priority recorder below the one of
+// the show is returned to make room for it, or ok is false and the reason
+// tells why the show has to wait.
+func (m *Manager) admit(bout config.Bout) (victim Recorder, reason string, ok bool) {
+	reason = m.full()
+	if reason == "" {
+		return nil, "", true
+	}
+
+	for _, r := range m.savers {
+		if r.Bout().GetPriority() >= bout.GetPriority() {
+			continue
+		}
+		// among equal priorities, the latest started loses the least.
+		if victim == nil ||
+			r.Bout().GetPriority() < victim.Bout().GetPriority() ||
+			r.Bout().GetPriority() == victim.Bout().GetPriority() && r.StartTime().After(victim.StartTime()) {
+			victim = r
+		}
+	}
+	if victim == nil {
+		return nil, reason, false
+	}
+	return victim, reason, true
+}
+
+// yield returns why the show gives way to a recorder of another show of its
+// streamer, empty if it does not, along with the recorders giving way to it
+// under the preferred policy. m.mu must be held.
+func (m *Manager) yield(bout config.Bout) (reason string, losers []Recorder) {
+	s := bout.GetStreamer()
+	if s == nil || s.Policy == "" || s.Policy == config.StreamerPolicyAll {
+		return "", nil
+	}
+
+	for id, r := range m.savers {
+		if id == bout.GetID() {
+			continue
+		}
+		peer := r.Bout().GetStreamer()
+		if peer == nil || peer.ID != s.ID {
+			continue
+		}
+		if s.Policy == config.StreamerPolicyPreferred && bout.GetPriority() > r.Bout().GetPriority() {
+			losers = append(losers, r)
+			continue
+		}
+		return fmt.Sprintf("streamer %s is recorded on %s (%s policy)", s.Name, r.Bout().GetPlatform(), s.Policy), nil
+	}
+	return "", losers
+}
+
+// full returns why no recorder can be added, empty if one can, m.mu must be
+// held.
+func (m *Manager) full() string {
+	if max := m.cfg.MaxRecorders; max > 0 && uint(len(m.savers)) >= max {
+		return fmt.Sprintf("max recorders reached (%d)", max)
+	}
+	if max := m.cfg.MaxRecordKbps; max > 0 {
+		if kbps := m.bitrate() / 1000; kbps >= float64(max) {
+			return fmt.Sprintf("max bandwidth reached (%.0f/%d kbps)", kbps, max)
+		}
+	}
+	return ""
+}
+
+// bitrate returns the aggregate bitrate of the recorders in bit/s, m.mu must
+// be held.
+func (m *Manager) bitrate() float64 {
+	var sum float64
+	for _, r := range m.savers {
+		if p, ok := r.Progress(); ok {
+			sum += p.Bitrate
+		}
+	}
+	return sum
+}
+
+// enqueue puts the show in the queue, m.mu must be held.
+func (m *Manager) enqueue(bout config.Bout, reason string) {
+	q, ok := m.queue[bout.GetID()]
+	if !ok {
+		q = queued{bout: bout, Decision: Decision{Since: time.Now()}}
+	}
+	q.Reason = reason
+	m.queue[bout.GetID()] = q
+
+	m.log.WithFields(logrus.Fields{
+		"pf": bout.GetPlatform(),
+		"id": bout.GetRoomID(),
+	}).Infof("recorder queued, %s", reason)
+
+	e := events.New(events.Queued, bout)
+	e.Message = reason
+	m.events.Publish(e)
+}
+
+// dequeue takes the show out of the queue, once its room went offline.
+func (m *Manager) dequeue(bout config.Bout) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.queue[bout.GetID()]; !ok {
+		return nil
+	}
+	delete(m.queue, bout.GetID())
+	m.log.WithFields(logrus.Fields{
+		"pf": bout.GetPlatform(),
+		"id": bout.GetRoomID(),
+	}).Info("recorder dequeued, the room is offline")
+	return nil
+}
+
+// preempt stops the recorder to make room for another show, queueing it
+// back for the reason given, m.mu must be held. The show is monitored again
+// for it to leave the queue once offline.
+func (m *Manager) preempt(victim Recorder, reason string) {
+	victim.Stop()
+	delete(m.savers, victim.Bout().GetID())
+	go m.detach(victim.Bout(), victim)
+	go func() {
+		<-victim.Done()
+		if err := victim.Bout().AddMonitor(); err != nil {
+			m.log.Error(err)
+		}
+	}()
+	m.enqueue(victim.Bout(), reason)
+}
+
+// Queued returns why the show is waiting for a recorder, if it is.
+func (m *Manager) Queued(id config.ID) (Decision, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	q, ok := m.queue[id]
+	return q.Decision, ok
+}
+
+// next returns the queued show to start next, the highest priority one
+// first, then the one queued the longest. Shows giving way to another show
+// of their streamer are passed over. m.mu must be held.
+func (m *Manager) next() (config.Bout, bool) {
+	if len(m.queue) == 0 || m.full() != "" {
+		return nil, false
+	}
+	list := make([]queued, 0, len(m.queue))
+	for _, q := range m.queue {
+		if reason, _ := m.yield(q.bout); reason == "" {
+			list = append(list, q)
+		}
+	}
+	if len(list) == 0 {
+		return nil, false
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if pi, pj := list[i].bout.GetPriority(), list[j].bout.GetPriority(); pi != pj {
+			return pi > pj
+		}
+		return list[i].Since.Before(list[j].Since)
+	})
+	return list[0].bout, true
+}
+
+// WatchQueue starts the queued shows as recorders free up, highest priority
+// first, one at a time to let the bitrate of the last one show.
+func (m *Manager) WatchQueue() {
+	m.log.Info("queue program starts...")
+
+	t := time.NewTicker(5 * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-t.C:
+			m.startNext()
+		}
+	}
+}
+
+// startNext starts the next queued show, if any. Shows giving way to another
+// show of their streamer stay queued, the ones gone offline are dropped and
+// left to their monitor. The show stays queued while it is snapped, the next
+// try coming when the snap fails.
+func (m *Manager) startNext() {
+	m.mu.Lock()
+	next, ok := m.next()
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	log := m.log.WithFields(logrus.Fields{
+		"pf": next.GetPlatform(),
+		"id": next.GetRoomID(),
+	})
+	if err := next.Snap(); err != nil {
+		log.Tracef("snap failed, %s", err.Error())
+		return
+	}
+	_, live := next.StreamURL()
+
+	m.mu.Lock()
+	_, queued := m.queue[next.GetID()]
+	delete(m.queue, next.GetID())
+	m.mu.Unlock()
+	switch {
+	case !queued:
+		// dequeued in the meantime.
+	case !live:
+		log.Info("dequeued by queue program, the room is offline")
+	default:
+		log.Info("dequeued by queue program")
+		next.AddRecorder()
+	}
+}
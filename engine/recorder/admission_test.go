@@ -0,0 +1,306 @@
+package recorder
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/parser"
+	"github.com/go-olive/olive/foundation/history"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeBout is a show with a priority, and a streamer if any.
+type fakeBout struct {
+	config.Bout
+	id       string
+	priority int
+	streamer *config.Streamer
+	offline  bool
+	snapErr  error
+	// started counts the recorders added, monitored is signaled when the
+	// monitor is added.
+	started   int
+	monitored chan struct{}
+	external  map[string]config.ExternalParser
+}
+
+func (b *fakeBout) GetID() config.ID              { return config.ID(b.id) }
+func (b *fakeBout) GetPriority() int              { return b.priority }
+func (b *fakeBout) GetStreamer() *config.Streamer { return b.streamer }
+func (b *fakeBout) GetPlatform() string           { return "bilibili" }
+func (b *fakeBout) GetRoomID() string             { return b.id }
+func (b *fakeBout) Snap() error                   { return b.snapErr }
+func (b *fakeBout) StreamURL() (string, bool)     { return "", !b.offline }
+func (b *fakeBout) AddRecorder() error            { b.started++; return nil }
+func (b *fakeBout) AddMonitor() error {
+	if b.monitored != nil {
+		b.monitored <- struct{}{}
+	}
+	return nil
+}
+
+// fakeRecorder is a running recorder of a show.
+type fakeRecorder struct {
+	Recorder
+	bout    *fakeBout
+	start   time.Time
+	kbps    float64
+	stopped bool
+	// done is closed once the recorder ends, nil for a recorder already
+	// ended.
+	done chan struct{}
+}
+
+func (r *fakeRecorder) Stop() { r.stopped = true }
+func (r *fakeRecorder) Done() <-chan struct{} {
+	if r.done != nil {
+		return r.done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+func (r *fakeRecorder) Bout() config.Bout    { return r.bout }
+func (r *fakeRecorder) StartTime() time.Time { return r.start }
+func (r *fakeRecorder) Progress() (parser.Progress, bool) {
+	return parser.Progress{Bitrate: r.kbps * 1000}, r.kbps > 0
+}
+
+func newTestManager(cfg config.Config, recorders ...*fakeRecorder) *Manager {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	book, _ := history.Open("")
+	m := NewManager(log, &cfg, book, nil, nil, nil)
+	for _, r := range recorders {
+		m.savers[r.bout.GetID()] = r
+	}
+	return m
+}
+
+func TestAdmit(t *testing.T) {
+	now := time.Now()
+	low := &fakeRecorder{bout: &fakeBout{id: "low", priority: 1}, start: now.Add(-time.Hour)}
+	lowLate := &fakeRecorder{bout: &fakeBout{id: "low-late", priority: 1}, start: now}
+	high := &fakeRecorder{bout: &fakeBout{id: "high", priority: 5}, start: now}
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		priority int
+		victim   Recorder
+		ok       bool
+	}{
+		{"no limit", config.Config{}, 0, nil, true},
+		{"room left", config.Config{MaxRecorders: 4}, 0, nil, true},
+		{"full, lowest", config.Config{MaxRecorders: 3}, 0, nil, false},
+		{"full, equal to the lowest", config.Config{MaxRecorders: 3}, 1, nil, false},
+		{"full, pre-empts the latest lowest", config.Config{MaxRecorders: 3}, 3, lowLate, true},
+		{"full, highest", config.Config{MaxRecorders: 3}, 9, lowLate, true},
+	}
+	for _, tt := range tests {
+		m := newTestManager(tt.cfg, low, lowLate, high)
+		victim, reason, ok := m.admit(&fakeBout{id: "new", priority: tt.priority})
+		if ok != tt.ok || victim != tt.victim {
+			t.Errorf("%s: got victim %v ok %v, want %v %v", tt.name, victim, ok, tt.victim, tt.ok)
+		}
+		if tt.cfg.MaxRecorders == 3 && reason == "" {
+			t.Errorf("%s: no reason given", tt.name)
+		}
+	}
+
+	m := newTestManager(config.Config{MaxRecordKbps: 5000}, &fakeRecorder{bout: &fakeBout{id: "a"}, kbps: 6000})
+	if _, reason, ok := m.admit(&fakeBout{id: "new"}); ok || reason == "" {
+		t.Errorf("bandwidth: got ok %v reason %q, want a reason to wait", ok, reason)
+	}
+}
+
+func TestYield(t *testing.T) {
+	streamer := func(policy string) *config.Streamer {
+		return &config.Streamer{ID: "s", Name: "s", Policy: policy}
+	}
+	tests := []struct {
+		name     string
+		policy   string
+		running  int
+		priority int
+		wait     bool
+		losers   int
+	}{
+		{"all", config.StreamerPolicyAll, 1, 5, false, 0},
+		{"fallback, lower", config.StreamerPolicyFallback, 5, 1, true, 0},
+		{"fallback, higher keeps the running one", config.StreamerPolicyFallback, 1, 5, true, 0},
+		{"preferred, lower", config.StreamerPolicyPreferred, 5, 1, true, 0},
+		{"preferred, equal", config.StreamerPolicyPreferred, 5, 5, true, 0},
+		{"preferred, higher takes over", config.StreamerPolicyPreferred, 1, 5, false, 1},
+	}
+	for _, tt := range tests {
+		running := &fakeRecorder{bout: &fakeBout{id: "running", priority: tt.running, streamer: streamer(tt.policy)}}
+		other := &fakeRecorder{bout: &fakeBout{id: "other", priority: 0}}
+		m := newTestManager(config.Config{}, running, other)
+
+		reason, losers := m.yield(&fakeBout{id: "new", priority: tt.priority, streamer: streamer(tt.policy)})
+		if (reason != "") != tt.wait || len(losers) != tt.losers {
+			t.Errorf("%s: got reason %q losers %d, want wait %v losers %d", tt.name, reason, len(losers), tt.wait, tt.losers)
+		}
+		if len(losers) > 0 && losers[0] != running {
+			t.Errorf("%s: got loser %v, want the running show", tt.name, losers[0])
+		}
+	}
+
+	m := newTestManager(config.Config{})
+	if reason, losers := m.yield(&fakeBout{id: "new"}); reason != "" || losers != nil {
+		t.Errorf("no streamer: got reason %q losers %d", reason, len(losers))
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	now := time.Now()
+	fallback := &config.Streamer{ID: "s", Name: "s", Policy: config.StreamerPolicyFallback}
+	running := &fakeRecorder{bout: &fakeBout{id: "running", streamer: fallback}}
+	m := newTestManager(config.Config{MaxRecorders: 2}, running)
+
+	enqueue := func(b *fakeBout, since time.Duration) {
+		m.queue[b.GetID()] = queued{bout: b, Decision: Decision{Since: now.Add(-since)}}
+	}
+	enqueue(&fakeBout{id: "old", priority: 1}, time.Hour)
+	enqueue(&fakeBout{id: "new", priority: 1}, time.Minute)
+	enqueue(&fakeBout{id: "high", priority: 5}, time.Second)
+	// gives way to the running show of its streamer, however high.
+	enqueue(&fakeBout{id: "yielding", priority: 9, streamer: fallback}, 2*time.Hour)
+
+	var order []string
+	for {
+		next, ok := m.next()
+		if !ok {
+			break
+		}
+		order = append(order, string(next.GetID()))
+		delete(m.queue, next.GetID())
+	}
+	want := []string{"high", "old", "new"}
+	if len(order) != len(want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got %v, want %v", order, want)
+		}
+	}
+	if _, ok := m.queue["yielding"]; !ok {
+		t.Error("the yielding show left the queue")
+	}
+
+	m.savers["other"] = &fakeRecorder{bout: &fakeBout{id: "other"}}
+	enqueue(&fakeBout{id: "late"}, 0)
+	if next, ok := m.next(); ok {
+		t.Errorf("full: got %s, want none", next.GetID())
+	}
+
+	if err := m.dequeue(&fakeBout{id: "late"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.queue["late"]; ok {
+		t.Error("dequeue left the show queued")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	streamer := func(policy string) *config.Streamer {
+		return &config.Streamer{ID: "s", Name: "streamer", Policy: policy}
+	}
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		policy   string
+		priority int
+		start    bool
+		// reason the new show waits for, or the running one is queued for.
+		reason string
+	}{
+		{"room left", config.Config{}, "", 5, true, ""},
+		{"fallback", config.Config{}, config.StreamerPolicyFallback, 5, false, "streamer streamer is recorded on bilibili (fallback policy)"},
+		{"preferred, lower", config.Config{}, config.StreamerPolicyPreferred, 0, false, "streamer streamer is recorded on bilibili (preferred policy)"},
+		{"preferred, higher", config.Config{}, config.StreamerPolicyPreferred, 5, true, "giving way to streamer on bilibili"},
+		{"full, lower", config.Config{MaxRecorders: 1}, "", 0, false, "max recorders reached (1)"},
+		{"full, higher", config.Config{MaxRecorders: 1}, "", 5, true, "pre-empted by bilibili/new (priority 5)"},
+	}
+	for _, tt := range tests {
+		var s *config.Streamer
+		if tt.policy != "" {
+			s = streamer(tt.policy)
+		}
+		running := &fakeRecorder{bout: &fakeBout{id: "running", priority: 1, streamer: s}}
+		m := newTestManager(tt.cfg, running)
+		bout := &fakeBout{id: "new", priority: tt.priority, streamer: s}
+
+		m.mu.Lock()
+		start := m.schedule(bout)
+		m.mu.Unlock()
+		if start != tt.start {
+			t.Errorf("%s: got start %v, want %v", tt.name, start, tt.start)
+			continue
+		}
+
+		// the show waiting is the new one, or the running one giving way.
+		waiting, stopped := bout.GetID(), false
+		if start {
+			waiting, stopped = running.bout.GetID(), tt.reason != ""
+		}
+		if running.stopped != stopped {
+			t.Errorf("%s: got running stopped %v, want %v", tt.name, running.stopped, stopped)
+		}
+		d, queued := m.Queued(waiting)
+		if queued != (tt.reason != "") || d.Reason != tt.reason {
+			t.Errorf("%s: got %s queued %v for %q, want %q", tt.name, waiting, queued, d.Reason, tt.reason)
+		}
+	}
+}
+
+func TestStartNext(t *testing.T) {
+	tests := []struct {
+		name    string
+		bout    *fakeBout
+		started bool
+		queued  bool
+	}{
+		{"live", &fakeBout{id: "a"}, true, false},
+		{"offline", &fakeBout{id: "a", offline: true}, false, false},
+		{"snap failed", &fakeBout{id: "a", snapErr: errors.New("timeout")}, false, true},
+	}
+	for _, tt := range tests {
+		m := newTestManager(config.Config{})
+		m.queue[tt.bout.GetID()] = queued{bout: tt.bout}
+
+		m.startNext()
+		if started := tt.bout.started > 0; started != tt.started {
+			t.Errorf("%s: got started %v, want %v", tt.name, started, tt.started)
+		}
+		if _, queued := m.Queued(tt.bout.GetID()); queued != tt.queued {
+			t.Errorf("%s: got queued %v, want %v", tt.name, queued, tt.queued)
+		}
+	}
+}
+
+func TestPreemptMonitors(t *testing.T) {
+	victim := &fakeRecorder{bout: &fakeBout{id: "victim", monitored: make(chan struct{}, 1)}}
+	m := newTestManager(config.Config{}, victim)
+
+	m.mu.Lock()
+	m.preempt(victim, "pre-empted")
+	m.mu.Unlock()
+	if !victim.stopped {
+		t.Error("the victim was not stopped")
+	}
+	if _, ok := m.Queued(victim.bout.GetID()); !ok {
+		t.Error("the victim was not queued")
+	}
+	select {
+	case <-victim.bout.monitored:
+	case <-time.After(time.Second):
+		t.Error("the victim is no longer monitored, it would stay queued once offline")
+	}
+}
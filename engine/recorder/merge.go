@@ -0,0 +1,84 @@
+package recorder
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-olive/olive/foundation/flvfix"
+)
+
+// mergeSegments losslessly concatenates the segments into a single file
+// named after the first one, which it replaces unless the parts are kept.
+// FLV is merged in Go, anything else through the concat demuxer of ffmpeg.
+func mergeSegments(parts []string, keepParts bool) (string, error) {
+	first := parts[0]
+	ext := filepath.Ext(first)
+	out := strings.TrimSuffix(first, ext) + "[merged]" + ext
+
+	allFLV := true
+	for _, part := range parts {
+		if !flvfix.IsFLV(part) {
+			allFLV = false
+			break
+		}
+	}
+
+	var err error
+	if allFLV {
+		err = flvfix.Merge(parts, out)
+	} else {
+		err = concat(parts, out)
+	}
+	if err != nil {
+		os.Remove(out)
+		return "", err
+	}
+
+	if keepParts {
+		return out, nil
+	}
+	for _, part := range parts {
+		os.Remove(part)
+	}
+	if err := os.Rename(out, first); err != nil {
+		return out, nil
+	}
+	return first, nil
+}
+
+// concat merges the parts with the concat demuxer of ffmpeg, which rebases
+// the timestamps of every part on the end of the previous one.
+func concat(parts []string, out string) error {
+	list := out + ".txt"
+	var sb strings.Builder
+	for _, part := range parts {
+		abs, err := filepath.Abs(part)
+		if err != nil {
+			return err
+		}
+		sb.WriteString("file '" + strings.ReplaceAll(abs, "'", `'\''`) + "'\n")
+	}
+	if err := os.WriteFile(list, []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+	defer os.Remove(list)
+
+	cmd := exec.Command(
+		"ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", list,
+		"-map", "0",
+		"-c", "copy",
+		"-y", out,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg concat: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
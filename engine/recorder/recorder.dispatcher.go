@@ -20,6 +20,8 @@ func (m *Manager) Dispatch(event *dispatcher.Event) error {
 		return m.addRecorder(bout)
 	case enum.EventType.RemoveRecorder:
 		return m.removeRecorder(bout)
+	case enum.EventType.DequeueRecorder:
+		return m.dequeue(bout)
 	}
 	return nil
 }
@@ -32,5 +34,6 @@ func (m *Manager) DispatchTypes() []enum.EventTypeID {
 	return []enum.EventTypeID{
 		enum.EventType.AddRecorder,
 		enum.EventType.RemoveRecorder,
+		enum.EventType.DequeueRecorder,
 	}
 }
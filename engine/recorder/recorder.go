@@ -5,15 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-olive/olive/engine/config"
 	"github.com/go-olive/olive/engine/enum"
+	"github.com/go-olive/olive/engine/events"
 	"github.com/go-olive/olive/engine/parser"
-	"github.com/go-olive/olive/engine/uploader"
+	"github.com/go-olive/olive/engine/preview"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,6 +24,13 @@ type Recorder interface {
 	Done() <-chan struct{}
 	Out() string
 	Bout() config.Bout
+	// Progress reports the progress of the running parser, if it supports it.
+	Progress() (parser.Progress, bool)
+	// Parser returns the parser in use, which may be a fallback.
+	Parser() string
+	// Session returns the live session and the segment being recorded, once
+	// the recording started.
+	Session() (config.Session, bool)
 }
 
 type recorder struct {
@@ -35,9 +42,25 @@ type recorder struct {
 	done      chan struct{}
 	out       string
 	log       *logrus.Logger
+	cfg       *config.Config
+	session   *session
+	hub       *preview.Hub
+
+	// fallback state, the index of the parser in use within the parsers of
+	// the show and its consecutive failures.
+	parserIndex int
+	failures    uint
+	fellBack    bool
+	parserName  atomic.Value
+
+	segment atomic.Value
 }
 
-func NewRecorder(log *logrus.Logger, bout config.Bout) (Recorder, error) {
+func NewRecorder(log *logrus.Logger, cfg *config.Config, bout config.Bout) (Recorder, error) {
+	return newRecorder(log, cfg, bout, newSession(cfg, bout, nil, nil), nil), nil
+}
+
+func newRecorder(log *logrus.Logger, cfg *config.Config, bout config.Bout, s *session, hub *preview.Hub) *recorder {
 	return &recorder{
 		status:    enum.Status.Starting,
 		bout:      bout,
@@ -45,7 +68,10 @@ func NewRecorder(log *logrus.Logger, bout config.Bout) (Recorder, error) {
 		startTime: time.Now(),
 		done:      make(chan struct{}),
 		log:       log,
-	}, nil
+		cfg:       cfg,
+		session:   s,
+		hub:       hub,
+	}
 }
 
 func (r *recorder) Start() error {
@@ -78,9 +104,31 @@ func (r *recorder) StartTime() time.Time {
 }
 
 func (r *recorder) Out() string {
+	if o, ok := r.parser.(parser.Outputter); ok && o.Out() != "" {
+		return o.Out()
+	}
 	return r.out
 }
 
+func (r *recorder) Progress() (parser.Progress, bool) {
+	p, ok := r.parser.(parser.Progresser)
+	if !ok {
+		return parser.Progress{}, false
+	}
+	return p.Progress(), true
+}
+
+func (r *recorder) Parser() string {
+	name, _ := r.parserName.Load().(string)
+	return name
+}
+
+// Session returns the live session and the segment being recorded.
+func (r *recorder) Session() (config.Session, bool) {
+	s, ok := r.segment.Load().(config.Session)
+	return s, ok
+}
+
 func (r *recorder) Bout() config.Bout {
 	return r.bout
 }
@@ -91,13 +139,28 @@ func (r *recorder) record() error {
 		return nil
 	}
 
-	newParser, exist := parser.SharedManager.Parser(r.bout.GetParser())
-	if !exist {
-		return fmt.Errorf("parser[%s] does not exist", r.bout.GetParser())
+	parsers := r.bout.GetParsers()
+	if len(parsers) == 0 {
+		return errors.New("no parser")
+	}
+	if r.parserIndex >= len(parsers) {
+		r.parserIndex = 0
+	}
+	name, newParser, found := r.pick(parsers)
+	if !found {
+		return fmt.Errorf("none of the parsers %v exists", parsers)
 	}
 	r.parser = newParser.New()
+	if lg, ok := r.parser.(parser.Logger); ok {
+		lg.SetLogger(r.log)
+	}
+	r.parserName.Store(name)
 
-	var out string
+	var (
+		out     string
+		current config.Session
+		start   time.Time
+	)
 	defer func() {
 		fi, err := os.Stat(out)
 		if err != nil {
@@ -115,7 +178,7 @@ func (r *recorder) record() error {
 			return
 		}
 
-		r.SubmitUploadTask(out, r.bout.GetPostCmds())
+		r.session.add(out, current.SegmentIndex, time.Since(start))
 	}()
 
 	const retry = 3
@@ -143,7 +206,11 @@ func (r *recorder) record() error {
 	}
 
 	roomName, _ := r.bout.RoomName()
-	out = r.bout.GetOutFilename()
+	current = r.session.next()
+	current.Parser = name
+	start = time.Now()
+	r.segment.Store(current)
+	out = r.bout.GetOutFilename(current)
 
 	r.log.WithFields(logrus.Fields{
 		"pf": r.bout.GetPlatform(),
@@ -151,7 +218,7 @@ func (r *recorder) record() error {
 		"rn": roomName,
 	}).Info("record start")
 
-	saveDir := r.bout.GetSaveDir()
+	saveDir := r.bout.GetSaveDir(current)
 	if err := os.MkdirAll(saveDir, os.ModePerm); err != nil {
 		r.log.WithFields(logrus.Fields{
 			"pf": r.bout.GetPlatform(),
@@ -167,27 +234,148 @@ func (r *recorder) record() error {
 	case "yt-dlp":
 		ext := filepath.Ext(out)
 		out = out[0:len(out)-len(ext)] + ".mp4"
+	case "hls":
+		ext := filepath.Ext(out)
+		out = out[0:len(out)-len(ext)] + ".ts"
 	default:
 		ext := filepath.Ext(out)
 		out = out[0:len(out)-len(ext)] + ".mp4"
 	}
 
+	if r.bout.GetAudioOnly() {
+		switch r.parser.Type() {
+		case "flv":
+			ext := filepath.Ext(out)
+			out = out[0:len(out)-len(ext)] + ".aac"
+		case "ffmpeg":
+			// the extension follows the container.
+		default:
+			r.log.WithFields(logrus.Fields{
+				"pf": r.bout.GetPlatform(),
+				"id": r.bout.GetRoomID(),
+			}).Warnf("parser[%s] does not record audio only, the video is kept", r.parser.Type())
+		}
+	}
+
+	if c, ok := r.parser.(parser.Configurable); ok {
+		opts := parser.Options{
+			Referer:       r.bout.GetReferer(),
+			FfmpegProfile: r.bout.GetFfmpegProfile(),
+			Relays:        r.bout.GetRelays(),
+			AudioOnly:     r.bout.GetAudioOnly(),
+		}
+		if r.hub != nil {
+			stream := r.hub.Open(r.bout.GetID())
+			defer r.hub.Close(stream)
+			opts.Preview = stream
+		}
+		if err := c.Configure(opts); err != nil {
+			r.log.WithFields(logrus.Fields{
+				"pf": r.bout.GetPlatform(),
+				"id": r.bout.GetRoomID(),
+			}).Errorf("configure parser failed: %s", err.Error())
+			return err
+		}
+	} else if len(r.bout.GetRelays()) > 0 {
+		r.log.WithFields(logrus.Fields{
+			"pf": r.bout.GetPlatform(),
+			"id": r.bout.GetRoomID(),
+		}).Warnf("parser[%s] does not relay, relays are ignored", r.parser.Type())
+	}
+
 	r.startTime = time.Now()
 	r.out = out
 
+	r.session.publish(events.RecordStart, out, "")
 	err := r.parser.Parse(streamURL, out)
+	out = r.Out()
+	var msg string
+	if err != nil {
+		msg = err.Error()
+	}
+	r.session.publish(events.RecordStop, out, msg)
 
 	r.log.WithFields(logrus.Fields{
 		"pf": r.bout.GetPlatform(),
 		"id": r.bout.GetRoomID(),
 	}).Infof("record stop: %+v", err)
 
+	r.fallback(parsers, err, out, time.Since(start))
 	return nil
 }
 
+// pick returns the parser in use, moving on to the next parser of the show
+// past the names which are neither a parser nor an external parser. ok is
+// false when none of them is.
+func (r *recorder) pick(parsers []string) (name string, p parser.Parser, ok bool) {
+	for range parsers {
+		name = parsers[r.parserIndex]
+		if p, ok = parser.SharedManager.Parser(name); ok {
+			return name, p, true
+		}
+		if ext := r.bout.GetExternalParser(name); ext != nil {
+			return name, parser.NewExternal(name, *ext), true
+		}
+		r.parserIndex = (r.parserIndex + 1) % len(parsers)
+		r.log.WithFields(logrus.Fields{
+			"pf": r.bout.GetPlatform(),
+			"id": r.bout.GetRoomID(),
+		}).Errorf("parser[%s] does not exist, moving on to parser[%s]", name, parsers[r.parserIndex])
+	}
+	return "", nil, false
+}
+
+// A parser succeeds when it records at least fallbackMinSize bytes or for
+// fallbackMinDuration, whatever it returns.
+const (
+	fallbackMinSize     = 1e6
+	fallbackMinDuration = time.Minute
+)
+
+// fallback moves on to the next parser of the show after too many
+// consecutive failures, or at once when the parser does not support the
+// stream. A parser fails when it records too little, even without an error,
+// as the flv parser returns nil on the immediate EOF of a dead stream.
+func (r *recorder) fallback(parsers []string, err error, out string, elapsed time.Duration) {
+	select {
+	case <-r.stop:
+		return
+	default:
+	}
+
+	log := r.log.WithFields(logrus.Fields{
+		"pf":     r.bout.GetPlatform(),
+		"id":     r.bout.GetRoomID(),
+		"parser": parsers[r.parserIndex],
+	})
+
+	fi, statErr := os.Stat(out)
+	if elapsed >= fallbackMinDuration || (statErr == nil && fi.Size() >= fallbackMinSize) {
+		if r.fellBack {
+			log.Info("fallback parser succeeded")
+			r.fellBack = false
+		}
+		r.failures = 0
+		return
+	}
+
+	r.failures++
+	if !errors.Is(err, parser.ErrUnsupported) && r.failures < r.cfg.ParserFallbackFailures {
+		return
+	}
+	if len(parsers) == 1 {
+		return
+	}
+	r.failures = 0
+	r.fellBack = true
+	r.parserIndex = (r.parserIndex + 1) % len(parsers)
+	log.Warnf("parser failed, falling back to parser[%s]", parsers[r.parserIndex])
+}
+
 func (r *recorder) run() {
 	r.bout.RemoveMonitor()
 
+	defer close(r.done)
 	defer func() {
 		select {
 		case <-r.stop:
@@ -199,7 +387,6 @@ func (r *recorder) run() {
 	for {
 		select {
 		case <-r.stop:
-			close(r.done)
 			r.log.WithFields(logrus.Fields{
 				"pf": r.bout.GetPlatform(),
 				"id": r.bout.GetRoomID(),
@@ -216,14 +403,3 @@ func (r *recorder) run() {
 func (r *recorder) Done() <-chan struct{} {
 	return r.done
 }
-
-func (r *recorder) SubmitUploadTask(filepath string, cmds []*exec.Cmd) {
-	if len(cmds) > 0 && filepath != "" {
-		if uploader.UploaderWorkerPool != nil {
-			uploader.UploaderWorkerPool.AddTask(&uploader.TaskGroup{
-				Filepath: filepath,
-				PostCmds: cmds,
-			})
-		}
-	}
-}
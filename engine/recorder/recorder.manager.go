@@ -2,31 +2,44 @@ package recorder
 
 import (
 	"errors"
-	"os"
-	"strconv"
 	"sync"
 	"time"
 
-	"github.com/coocood/freecache"
 	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/engine/preview"
+	"github.com/go-olive/olive/engine/uploader"
+	"github.com/go-olive/olive/foundation/history"
 	"github.com/sirupsen/logrus"
 )
 
 type Manager struct {
-	mu     sync.RWMutex
-	savers map[config.ID]Recorder
-	stop   chan struct{}
+	mu       sync.RWMutex
+	savers   map[config.ID]Recorder
+	sessions map[config.ID]*session
+	queue    map[config.ID]queued
+	stop     chan struct{}
+	history  *history.Book
+	pool     *uploader.WorkerPool
+	hub      *preview.Hub
+	events   *events.Bus
 
 	log *logrus.Logger
 	cfg *config.Config
 }
 
-func NewManager(log *logrus.Logger, cfg *config.Config) *Manager {
+func NewManager(log *logrus.Logger, cfg *config.Config, book *history.Book, pool *uploader.WorkerPool, hub *preview.Hub, bus *events.Bus) *Manager {
 	return &Manager{
-		savers: make(map[config.ID]Recorder),
-		stop:   make(chan struct{}),
-		log:    log,
-		cfg:    cfg,
+		savers:   make(map[config.ID]Recorder),
+		sessions: make(map[config.ID]*session),
+		queue:    make(map[config.ID]queued),
+		stop:     make(chan struct{}),
+		history:  book,
+		pool:     pool,
+		hub:      hub,
+		events:   bus,
+		log:      log,
+		cfg:      cfg,
 	}
 }
 
@@ -36,6 +49,18 @@ func (m *Manager) Stop() {
 		recorder.Stop()
 		<-recorder.Done()
 	}
+	// there is no time left to merge, the segments are handed over as they
+	// are.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.sessions {
+		if s.merge {
+			s.submitParts(false)
+		} else {
+			s.flush(false)
+		}
+		delete(m.sessions, id)
+	}
 }
 
 func (m *Manager) addRecorder(bout config.Bout) error {
@@ -45,10 +70,12 @@ func (m *Manager) addRecorder(bout config.Bout) error {
 	if _, ok := m.savers[bout.GetID()]; ok {
 		return errors.New("exist")
 	}
-	recorder, err := NewRecorder(m.log, bout)
-	if err != nil {
-		return err
+	if !m.schedule(bout) {
+		return nil
 	}
+	s := m.attach(bout)
+	recorder := newRecorder(m.log, m.cfg, bout, s, m.hub)
+	s.latest = recorder
 	m.savers[bout.GetID()] = recorder
 	return recorder.Start()
 }
@@ -56,15 +83,27 @@ func (m *Manager) addRecorder(bout config.Bout) error {
 func (m *Manager) removeRecorder(bout config.Bout) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	// the show no longer waits for a recorder either.
+	delete(m.queue, bout.GetID())
 	recorder, ok := m.savers[bout.GetID()]
 	if !ok {
 		return errors.New("recorder not exist")
 	}
 	recorder.Stop()
 	delete(m.savers, bout.GetID())
+	go m.detach(bout, recorder)
 	return nil
 }
 
+// upload hands a file over to the post commands of its show.
+func (m *Manager) upload(task *uploader.TaskGroup) {
+	if !task.Pipeline.Empty() && task.Filepath != "" {
+		if m.pool != nil {
+			m.pool.AddTask(task)
+		}
+	}
+}
+
 type Splitter interface {
 	Split()
 }
@@ -86,6 +125,7 @@ func (m *Manager) Split() {
 						"pf": r.Bout().GetPlatform(),
 						"id": r.Bout().GetRoomID(),
 					}).Info("restart by split program")
+					m.events.Publish(events.New(events.Split, r.Bout()))
 					r.Bout().RestartRecorder()
 				}
 			}
@@ -93,13 +133,17 @@ func (m *Manager) Split() {
 	}
 }
 
+// MonitorParserStatus restarts the recorders whose parser has not received
+// any data for ParserMonitorRestSeconds.
 func (m *Manager) MonitorParserStatus() {
 	m.log.Info("parser-monitor program starts...")
 
-	const cacheSize = 1024
-	cache := freecache.NewCache(cacheSize)
-
-	t := time.NewTicker(time.Second * time.Duration(m.cfg.ParserMonitorRestSeconds))
+	stall := time.Second * time.Duration(m.cfg.ParserMonitorRestSeconds)
+	interval := 5 * time.Second
+	if stall < interval {
+		interval = stall
+	}
+	t := time.NewTicker(interval)
 	defer t.Stop()
 
 	for {
@@ -107,49 +151,107 @@ func (m *Manager) MonitorParserStatus() {
 		case <-m.stop:
 			return
 		case <-t.C:
+			m.mu.RLock()
+			for _, r := range m.savers {
+				p, ok := r.Progress()
+				if !ok {
+					continue
+				}
+				last := r.StartTime()
+				if p.LastDataTime.After(last) {
+					last = p.LastDataTime
+				}
+				if time.Since(last) < stall {
+					continue
+				}
+				m.log.WithFields(logrus.Fields{
+					"pf": r.Bout().GetPlatform(),
+					"id": r.Bout().GetRoomID(),
+				}).Info("restart by parser-monitor program")
+				m.events.Publish(events.New(events.ParserRestart, r.Bout()))
+				go r.Bout().RestartRecorder()
+			}
+			m.mu.RUnlock()
+		}
+	}
+}
+
+// WatchSchedule stops the recorders whose recording window closed, handing
+// their show back to the monitor, for shows with StopOnClose set.
+func (m *Manager) WatchSchedule() {
+	m.log.Info("schedule program starts...")
 
-			// m.log.Debug("---------------------")
-			// m.log.Debugf("EntryCount = %d", cache.EntryCount())
-			// iter := cache.NewIterator()
-			// for entry := iter.Next(); entry != nil; entry = iter.Next() {
-			// 	m.log.Debugf("key = %s, val = %s", entry.Key, entry.Value)
-			// }
-			// m.log.Debug("---------------------")
+	t := time.NewTicker(30 * time.Second)
+	defer t.Stop()
 
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-t.C:
+			m.mu.RLock()
 			for _, r := range m.savers {
-				fi, err := os.Stat(r.Out())
-				if err != nil {
+				sched := r.Bout().GetSchedule()
+				if sched == nil || !sched.StopOnClose || sched.Active(time.Now()) {
 					continue
 				}
-				curSize := fi.Size()
-
-				func() {
-					defer func() {
-						cache.Set([]byte(r.Out()), []byte(strconv.FormatInt(curSize, 10)), int(m.cfg.ParserMonitorRestSeconds+1))
-					}()
-
-					preSizeBytes, err := cache.Peek([]byte(r.Out()))
-					if err != nil {
-						// m.log.Error(err)
-						return
-					}
-
-					preSize, err := strconv.ParseInt(string(preSizeBytes), 10, 64)
-					if err != nil {
-						m.log.Error(err)
-						return
-					}
-
-					if curSize <= preSize {
-						m.log.WithFields(logrus.Fields{
-							"pf": r.Bout().GetPlatform(),
-							"id": r.Bout().GetRoomID(),
-						}).Info("restart by parser-monitor program")
-						r.Bout().RestartRecorder()
-					}
-				}()
+				m.log.WithFields(logrus.Fields{
+					"pf": r.Bout().GetPlatform(),
+					"id": r.Bout().GetRoomID(),
+				}).Info("stop by schedule program")
+				go func(bout config.Bout) {
+					bout.RemoveRecorder()
+					bout.AddMonitor()
+				}(r.Bout())
+			}
+			m.mu.RUnlock()
+		}
+	}
+}
+
+// WatchFilter snaps the shows being recorded whose filter rule is rechecked,
+// stopping the recordings which no longer match and handing their show back
+// to the monitor, which starts recording again once they match.
+func (m *Manager) WatchFilter() {
+	m.log.Info("filter program starts...")
+
+	t := time.NewTicker(time.Minute)
+	defer t.Stop()
 
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-t.C:
+			// snap outside the lock, it takes a round trip per show.
+			var bouts []config.Bout
+			m.mu.RLock()
+			for _, r := range m.savers {
+				if r.Bout().RecheckFilterRule() {
+					bouts = append(bouts, r.Bout())
+				}
+			}
+			m.mu.RUnlock()
+
+			for _, bout := range bouts {
+				if err := bout.Snap(); err != nil || bout.SatisfyFilterRule() {
+					continue
+				}
+				m.log.WithFields(logrus.Fields{
+					"pf": bout.GetPlatform(),
+					"id": bout.GetRoomID(),
+				}).Info("stop by filter program")
+				bout.RemoveRecorder()
+				bout.AddMonitor()
 			}
 		}
 	}
 }
+
+// Recorder returns the recorder of the show, if any.
+func (m *Manager) Recorder(id config.ID) (Recorder, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.savers[id]
+	return r, ok
+}
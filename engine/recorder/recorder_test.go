@@ -0,0 +1,105 @@
+package recorder
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/parser"
+	"github.com/sirupsen/logrus"
+)
+
+func (b *fakeBout) GetExternalParser(name string) *config.ExternalParser {
+	if ext, ok := b.external[name]; ok {
+		return &ext
+	}
+	return nil
+}
+
+func newTestRecorder(cfg config.Config, bout *fakeBout) *recorder {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return newRecorder(log, &cfg, bout, nil, nil)
+}
+
+// file returns the path of a file of size bytes.
+func file(t *testing.T, size int) string {
+	path := filepath.Join(t.TempDir(), "out.flv")
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFallback(t *testing.T) {
+	parsers := []string{"flv", "ffmpeg", "streamlink"}
+	empty, big := file(t, 0), file(t, fallbackMinSize)
+	failed := errors.New("failed")
+
+	type run struct {
+		err     error
+		out     string
+		elapsed time.Duration
+	}
+	tests := []struct {
+		name string
+		runs []run
+		// index of the parser in use after the runs.
+		want int
+	}{
+		{"fails once", []run{{failed, empty, 0}}, 0},
+		{"fails twice", []run{{failed, empty, 0}, {failed, empty, 0}}, 1},
+		{"dead stream without error", []run{{nil, empty, 0}, {nil, "missing.flv", 0}}, 1},
+		{"unsupported", []run{{parser.ErrUnsupported, empty, 0}}, 1},
+		{"recorded enough bytes", []run{{failed, empty, 0}, {failed, big, 0}, {failed, empty, 0}}, 0},
+		{"recorded long enough", []run{{failed, empty, 0}, {failed, empty, time.Hour}, {failed, empty, 0}}, 0},
+		{"wraps around", []run{{parser.ErrUnsupported, empty, 0}, {parser.ErrUnsupported, empty, 0}, {parser.ErrUnsupported, empty, 0}}, 0},
+	}
+	for _, tt := range tests {
+		r := newTestRecorder(config.Config{ParserFallbackFailures: 2}, &fakeBout{id: "a"})
+		for _, run := range tt.runs {
+			r.fallback(parsers, run.err, run.out, run.elapsed)
+		}
+		if r.parserIndex != tt.want {
+			t.Errorf("%s: got parser %d, want %d", tt.name, r.parserIndex, tt.want)
+		}
+	}
+
+	r := newTestRecorder(config.Config{ParserFallbackFailures: 1}, &fakeBout{id: "a"})
+	r.fallback([]string{"flv"}, parser.ErrUnsupported, empty, 0)
+	if r.parserIndex != 0 {
+		t.Errorf("single parser: got parser %d", r.parserIndex)
+	}
+
+	r = newTestRecorder(config.Config{ParserFallbackFailures: 1}, &fakeBout{id: "a"})
+	close(r.stop)
+	r.fallback(parsers, parser.ErrUnsupported, empty, 0)
+	if r.parserIndex != 0 {
+		t.Errorf("stopped: got parser %d, want no fallback", r.parserIndex)
+	}
+}
+
+func TestPick(t *testing.T) {
+	bout := &fakeBout{id: "a", external: map[string]config.ExternalParser{"cdn": {Path: "/bin/cdnfetch"}}}
+	r := newTestRecorder(config.Config{}, bout)
+
+	parsers := []string{"unknown", "cdn", "flv"}
+	name, p, ok := r.pick(parsers)
+	if !ok || name != "cdn" || p == nil || r.parserIndex != 1 {
+		t.Fatalf("got %s %v at %d, want the external parser past the unknown one", name, ok, r.parserIndex)
+	}
+
+	r.parserIndex = 2
+	if name, _, ok := r.pick(parsers); !ok || name != "flv" {
+		t.Errorf("got %s %v, want flv", name, ok)
+	}
+
+	r.parserIndex = 0
+	if _, _, ok := r.pick([]string{"unknown", "missing"}); ok {
+		t.Error("got a parser out of unknown ones")
+	}
+}
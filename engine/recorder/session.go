@@ -0,0 +1,237 @@
+package recorder
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/engine/uploader"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// session is a live broadcast of a show, recorded as one or more segments
+// across parser exits, recorder restarts and the reconnects happening within
+// SessionGraceSeconds.
+type session struct {
+	id        string
+	startTime time.Time
+	bout      config.Bout
+	events    *events.Bus
+	// upload hands a file over to the post commands.
+	upload func(*uploader.TaskGroup)
+
+	mu        sync.Mutex
+	lastIndex int
+	segments  []segment
+	// pending is the segment last recorded when the segments are not merged,
+	// handed over once the next one starts or the session ends, as it may be
+	// the last of the session.
+	pending *segment
+
+	// latest is the recorder attached last, Manager.mu must be held.
+	latest Recorder
+
+	// merge is whether the segments are merged once the session ends, in
+	// which case their post commands wait for the merged file.
+	merge     bool
+	keepParts bool
+}
+
+// segment is a file recorded within a session.
+type segment struct {
+	path     string
+	index    int
+	duration time.Duration
+}
+
+func newSession(cfg *config.Config, bout config.Bout, upload func(*uploader.TaskGroup), bus *events.Bus) *session {
+	return &session{
+		id:        uuid.NewString(),
+		startTime: time.Now(),
+		bout:      bout,
+		events:    bus,
+		upload:    upload,
+		merge:     cfg.MergeEnable,
+		keepParts: cfg.MergeKeepParts,
+	}
+}
+
+// next starts a new segment, whose index counts from 1, handing the pending
+// one over to the post commands since the session goes on.
+func (s *session) next() config.Session {
+	s.flush(false)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastIndex++
+	return config.Session{
+		ID:           s.id,
+		StartTime:    s.startTime,
+		SegmentIndex: s.lastIndex,
+	}
+}
+
+// add records a segment of the session, which is pending unless the
+// segments are merged.
+func (s *session) add(path string, index int, duration time.Duration) {
+	seg := segment{path: path, index: index, duration: duration}
+	s.mu.Lock()
+	s.segments = append(s.segments, seg)
+	prev := s.pending
+	if !s.merge {
+		s.pending = &seg
+	}
+	s.mu.Unlock()
+
+	if prev != nil {
+		s.submit(*prev, false)
+	}
+}
+
+// flush hands the pending segment over to the post commands, if any. end is
+// whether the session ended.
+func (s *session) flush(end bool) {
+	s.mu.Lock()
+	seg := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if seg != nil {
+		s.submit(*seg, end)
+	}
+}
+
+func (s *session) parts() []segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]segment(nil), s.segments...)
+}
+
+// submit runs the post commands on a segment of the session, or on the
+// merged file when it counts several, which get the session id in their
+// environment. end is whether the session ended.
+func (s *session) submit(seg segment, end bool) {
+	env := []string{"SESSION_ID=" + s.id}
+	if seg.index > 0 {
+		env = append(env, "SEGMENT_INDEX="+strconv.Itoa(seg.index))
+	} else {
+		env = append(env, "SEGMENT_COUNT="+strconv.Itoa(len(s.parts())))
+	}
+	s.upload(&uploader.TaskGroup{
+		Bout:       s.bout,
+		SessionID:  s.id,
+		Filepath:   seg.path,
+		Pipeline:   s.bout.GetPipeline(),
+		Env:        env,
+		Duration:   seg.duration,
+		SessionEnd: end,
+	})
+}
+
+// publish publishes an event of the session.
+func (s *session) publish(typ events.Type, out, msg string) {
+	e := events.New(typ, s.bout)
+	e.SessionID = s.id
+	e.Out = out
+	e.Message = msg
+	s.events.Publish(e)
+}
+
+// attach returns the session of the show, starting one if none is running,
+// m.mu must be held.
+func (m *Manager) attach(bout config.Bout) *session {
+	if s, ok := m.sessions[bout.GetID()]; ok {
+		return s
+	}
+	s := newSession(m.cfg, bout, m.upload, m.events)
+	m.sessions[bout.GetID()] = s
+	m.history.Live(config.HistoryKey(bout), s.startTime, true)
+	return s
+}
+
+// detach ends the session of the show once the recorder is done and the
+// grace period passed, unless another recorder picked it up in the meantime.
+// The grace period counts from the end of the latest recorder, whose detach
+// is the one ending the session.
+func (m *Manager) detach(bout config.Bout, r Recorder) {
+	<-r.Done()
+	select {
+	case <-m.stop:
+		// Stop hands the segments over itself.
+		return
+	case <-time.After(time.Second * time.Duration(m.cfg.SessionGraceSeconds)):
+	}
+
+	m.mu.Lock()
+	s, ok := m.sessions[bout.GetID()]
+	if _, recording := m.savers[bout.GetID()]; !ok || recording || s.latest != r {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.sessions, bout.GetID())
+	m.mu.Unlock()
+
+	m.history.Live(config.HistoryKey(bout), time.Now(), false)
+	m.endSession(s)
+}
+
+func (m *Manager) endSession(s *session) {
+	parts := s.parts()
+
+	log := m.log.WithFields(logrus.Fields{
+		"pf":      s.bout.GetPlatform(),
+		"id":      s.bout.GetRoomID(),
+		"session": s.id,
+	})
+	log.Infof("session end with %d segments", len(parts))
+
+	if !s.merge {
+		// the last segment is the one still pending.
+		var out string
+		if len(parts) > 0 {
+			out = parts[len(parts)-1].path
+		}
+		s.publish(events.SessionEnd, out, "")
+		s.flush(true)
+		return
+	}
+	if len(parts) == 0 {
+		s.publish(events.SessionEnd, "", "")
+		return
+	}
+	if len(parts) == 1 {
+		s.publish(events.SessionEnd, parts[0].path, "")
+		s.submit(parts[0], true)
+		return
+	}
+
+	paths := make([]string, len(parts))
+	var duration time.Duration
+	for i, part := range parts {
+		paths[i] = part.path
+		duration += part.duration
+	}
+	merged, err := mergeSegments(paths, s.keepParts)
+	if err != nil {
+		log.Errorf("merge %d segments failed: %s", len(parts), err)
+		s.publish(events.SessionEnd, "", err.Error())
+		s.submitParts(true)
+		return
+	}
+	log.Infof("merged %d segments into %s", len(parts), merged)
+	s.publish(events.SessionEnd, merged, "")
+	s.submit(segment{path: merged, duration: duration}, true)
+}
+
+// submitParts hands the segments over to the post commands one by one, when
+// they could not be merged. end is whether the session ended, the last
+// segment being the one handed over as its end.
+func (s *session) submitParts(end bool) {
+	parts := s.parts()
+	for i, part := range parts {
+		s.submit(part, end && i == len(parts)-1)
+	}
+}
@@ -0,0 +1,194 @@
+package recorder
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/engine/uploader"
+)
+
+func (b *fakeBout) GetPipeline() *config.Pipeline { return nil }
+
+// uploads records the files handed over to the post commands.
+type uploads struct {
+	mu    sync.Mutex
+	tasks []*uploader.TaskGroup
+}
+
+func (u *uploads) add(t *uploader.TaskGroup) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.tasks = append(u.tasks, t)
+}
+
+// ends returns the files handed over, with whether they ended the session.
+func (u *uploads) ends() map[string]bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	ends := make(map[string]bool, len(u.tasks))
+	for _, t := range u.tasks {
+		ends[t.Filepath] = t.SessionEnd
+	}
+	return ends
+}
+
+func newTestSession(merge bool) (*session, *uploads) {
+	u := new(uploads)
+	cfg := &config.Config{MergeEnable: merge}
+	return newSession(cfg, &fakeBout{id: "a"}, u.add, events.NewBus()), u
+}
+
+func TestSessionEnd(t *testing.T) {
+	m := newTestManager(config.Config{})
+	s, u := newTestSession(false)
+
+	s.next()
+	s.add("1.flv", 1, 0)
+	if got := len(u.ends()); got != 0 {
+		t.Fatalf("got %d uploads, want the segment pending", got)
+	}
+	s.next()
+	s.add("2.flv", 2, 0)
+	if got := u.ends(); len(got) != 1 || got["1.flv"] {
+		t.Fatalf("got %v, want the first segment handed over as the session goes on", got)
+	}
+
+	m.endSession(s)
+	if got := u.ends(); len(got) != 2 || !got["2.flv"] {
+		t.Errorf("got %v, want the last segment handed over as the session end", got)
+	}
+}
+
+func TestSessionEndMergeFailed(t *testing.T) {
+	m := newTestManager(config.Config{})
+	s, u := newTestSession(true)
+
+	// the segments do not exist, failing the merge.
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "1.flv"), filepath.Join(dir, "2.flv"), filepath.Join(dir, "3.flv")}
+	for _, path := range paths {
+		current := s.next()
+		s.add(path, current.SegmentIndex, 0)
+	}
+	if got := len(u.ends()); got != 0 {
+		t.Fatalf("got %d uploads, want the segments waiting for the merge", got)
+	}
+
+	m.endSession(s)
+	want := map[string]bool{paths[0]: false, paths[1]: false, paths[2]: true}
+	got := u.ends()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for path, end := range want {
+		if got[path] != end {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// start attaches a recorder of the show to its session, as addRecorder does.
+func start(m *Manager, r *fakeRecorder) *session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.attach(r.bout)
+	s.latest = r
+	m.savers[r.bout.GetID()] = r
+	return s
+}
+
+// end ends the recorder, as removeRecorder does, and detaches it.
+func end(m *Manager, r *fakeRecorder) {
+	m.mu.Lock()
+	delete(m.savers, r.bout.GetID())
+	m.mu.Unlock()
+	if r.done != nil {
+		close(r.done)
+	}
+	go m.detach(r.bout, r)
+}
+
+// running returns the session of the show, if it did not end.
+func running(m *Manager, id config.ID) *session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions[id]
+}
+
+// waitEnd waits for the session of the show to end.
+func waitEnd(t *testing.T, m *Manager, id config.ID) {
+	deadline := time.Now().Add(3 * time.Second)
+	for running(m, id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the session did not end")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionGrace(t *testing.T) {
+	t.Parallel()
+	m := newTestManager(config.Config{SessionGraceSeconds: 1})
+	bout := &fakeBout{id: "a"}
+
+	first := &fakeRecorder{bout: bout, done: make(chan struct{})}
+	s := start(m, first)
+	end(m, first)
+
+	// reconnecting within the grace period goes on with the session.
+	time.Sleep(200 * time.Millisecond)
+	r := &fakeRecorder{bout: bout, done: make(chan struct{})}
+	if got := start(m, r); got != s {
+		t.Fatal("the reconnect started a new session")
+	}
+	time.Sleep(1200 * time.Millisecond)
+	if running(m, bout.GetID()) != s {
+		t.Fatal("the session ended while recording")
+	}
+
+	end(m, r)
+	waitEnd(t, m, bout.GetID())
+}
+
+func TestSessionGraceExpired(t *testing.T) {
+	t.Parallel()
+	m := newTestManager(config.Config{SessionGraceSeconds: 1})
+	bout := &fakeBout{id: "a"}
+
+	r := &fakeRecorder{bout: bout, done: make(chan struct{})}
+	s := start(m, r)
+	end(m, r)
+	waitEnd(t, m, bout.GetID())
+
+	// reconnecting after the grace period starts a new session.
+	if got := start(m, &fakeRecorder{bout: bout}); got == s || got.id == s.id {
+		t.Error("the reconnect went on with the ended session")
+	}
+}
+
+func TestSessionOverlap(t *testing.T) {
+	t.Parallel()
+	m := newTestManager(config.Config{SessionGraceSeconds: 1})
+	bout := &fakeBout{id: "a"}
+
+	first := &fakeRecorder{bout: bout, done: make(chan struct{})}
+	s := start(m, first)
+	end(m, first)
+
+	// the latest recorder ends within the grace period of the first one,
+	// its own grace period being the one ending the session.
+	time.Sleep(500 * time.Millisecond)
+	latest := &fakeRecorder{bout: bout, done: make(chan struct{})}
+	start(m, latest)
+	end(m, latest)
+
+	time.Sleep(750 * time.Millisecond)
+	if running(m, bout.GetID()) != s {
+		t.Fatal("the detach of the first recorder ended the session")
+	}
+	waitEnd(t, m, bout.GetID())
+}
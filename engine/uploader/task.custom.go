@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-olive/olive/engine/util"
 	"github.com/go-olive/olive/foundation/biliup"
+	"github.com/go-olive/olive/foundation/flvfix"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +17,7 @@ const (
 	olivearchive = "olivearchive"
 	olivebiliup  = "olivebiliup"
 	oliveshell   = "oliveshell"
+	olivefix     = "olivefix"
 )
 
 var DefaultHandlerFunc = TaskHandlerFunc(OliveDefault)
@@ -25,6 +27,7 @@ func init() {
 	DefaultTaskMux.RegisterHandler(olivearchive, TaskHandlerFunc(OliveArchive))
 	DefaultTaskMux.RegisterHandler(olivebiliup, TaskHandlerFunc(OliveBiliup))
 	DefaultTaskMux.RegisterHandler(oliveshell, DefaultHandlerFunc)
+	DefaultTaskMux.RegisterHandler(olivefix, TaskHandlerFunc(OliveFix))
 }
 
 func OliveTrash(t *Task) error {
@@ -73,6 +76,17 @@ func OliveBiliup(t *Task) error {
 	return err
 }
 
+// OliveFix repairs flv recordings, other files are left untouched.
+func OliveFix(t *Task) error {
+	if !flvfix.IsFLV(t.Filepath) {
+		t.log.WithFields(logrus.Fields{
+			"filepath": t.Filepath,
+		}).Info("fix skipped, not a flv file")
+		return nil
+	}
+	return flvfix.FixFile(t.Filepath)
+}
+
 func OliveDefault(t *Task) error {
 	doneChan := make(chan struct{})
 	defer close(doneChan)
@@ -84,7 +98,8 @@ func OliveDefault(t *Task) error {
 	cmd := exec.Command(t.Cmd.Args[0], t.Cmd.Args[1:]...)
 
 	envFilepath := "FILE_PATH=" + t.Filepath
-	cmd.Env = append([]string{envFilepath}, t.Cmd.Env...)
+	cmd.Env = append([]string{envFilepath}, t.Env...)
+	cmd.Env = append(cmd.Env, t.Cmd.Env...)
 	cmd.Dir = t.Cmd.Dir
 
 	go func() {
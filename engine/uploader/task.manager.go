@@ -51,6 +51,7 @@ type Task struct {
 	Filepath string
 	StopChan chan struct{}
 	Cmd      *exec.Cmd
+	Env      []string
 }
 
 type TaskHandler interface {
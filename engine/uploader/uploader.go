@@ -2,11 +2,13 @@
 package uploader
 
 import (
-	"os/exec"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,8 +19,19 @@ type Uploader interface {
 }
 
 type TaskGroup struct {
-	Filepath string
-	PostCmds []*exec.Cmd
+	// Bout is the show the file is recorded from, nil for the files left
+	// over from a previous run.
+	Bout      config.Bout
+	SessionID string
+	Filepath  string
+	Pipeline  *config.Pipeline
+	// Env is added to the environment of the post commands.
+	Env []string
+	// Duration is how long the recording lasts, SessionEnd whether it is
+	// handed over once the live session ended, both checked by the
+	// conditions of the pipeline.
+	Duration   time.Duration
+	SessionEnd bool
 
 	cfg *config.Config
 }
@@ -30,51 +43,140 @@ type uploader struct {
 	closeOnce sync.Once
 	stopChan  chan struct{}
 	doneChan  chan struct{}
+	events    *events.Bus
 }
 
-func NewUploader(log *logrus.Logger, cfg *config.Config, taskGroup *TaskGroup) Uploader {
+func NewUploader(log *logrus.Logger, cfg *config.Config, taskGroup *TaskGroup, bus *events.Bus) Uploader {
 	return &uploader{
 		log:       log,
 		cfg:       cfg,
 		taskGroup: taskGroup,
 		stopChan:  make(chan struct{}),
 		doneChan:  make(chan struct{}),
+		events:    bus,
 	}
 }
 
 func (u *uploader) proc() {
 	defer close(u.doneChan)
 
-	for _, postCmd := range u.taskGroup.PostCmds {
-		select {
-		case <-u.stopChan:
-			return
-		default:
-			u.log.WithFields(logrus.Fields{
-				"postCmdPath": postCmd.Path,
-				"postCmdArgs": strings.Join(postCmd.Args, " "),
-				"filepath":    u.taskGroup.Filepath,
-			}).Info("cmd start running")
-			handler := DefaultTaskMux.MustGetHandler(postCmd.Path)
-			err := handler.Process(
-				&Task{
-					log:      u.log,
-					cfg:      u.cfg,
-					Filepath: u.taskGroup.Filepath,
-					StopChan: u.stopChan,
-					Cmd:      postCmd,
-				},
-			)
-			if err != nil {
-				u.log.WithFields(logrus.Fields{
-					"postCmdPath": postCmd.Path,
-					"postCmdArgs": strings.Join(postCmd.Args, " "),
-					"filepath":    u.taskGroup.Filepath,
-				}).Error(err)
-				return
+	steps := u.taskGroup.Pipeline.Steps
+	results := make(map[string]*result, len(steps))
+	for _, step := range steps {
+		results[step.Name] = &result{done: make(chan struct{})}
+	}
+	var others []string
+	for _, step := range steps {
+		if !step.Final {
+			others = append(others, step.Name)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, step := range steps {
+		needs := step.Needs
+		if step.Final {
+			// final steps wait for every other step.
+			needs = append(append([]string(nil), needs...), others...)
+		}
+		wg.Add(1)
+		go func(step config.Step, needs []string) {
+			defer wg.Done()
+			r := results[step.Name]
+			defer close(r.done)
+
+			for _, need := range needs {
+				dep := results[need]
+				select {
+				case <-u.stopChan:
+					r.failed = true
+					return
+				case <-dep.done:
+				}
+				if dep.failed {
+					r.failed = true
+					return
+				}
 			}
+			r.failed = !u.run(step)
+		}(step, needs)
+	}
+	wg.Wait()
+}
+
+// result is the outcome of a step, set before done is closed. Skipped steps
+// do not fail, steps whose needs failed do.
+type result struct {
+	done   chan struct{}
+	failed bool
+}
+
+// run runs the step unless its condition is not satisfied, reporting
+// whether it did not fail.
+func (u *uploader) run(step config.Step) bool {
+	select {
+	case <-u.stopChan:
+		return false
+	default:
+	}
+
+	log := u.log.WithFields(logrus.Fields{
+		"step":        step.Name,
+		"postCmdPath": step.Path,
+		"postCmdArgs": strings.Join(step.Args, " "),
+		"filepath":    u.taskGroup.Filepath,
+	})
+	if step.If != nil {
+		var size int64
+		if fi, err := os.Stat(u.taskGroup.Filepath); err == nil {
+			size = fi.Size()
 		}
+		f := config.File{
+			Size:       size,
+			Duration:   u.taskGroup.Duration,
+			SessionEnd: u.taskGroup.SessionEnd,
+		}
+		if !step.If.Satisfy(f) {
+			log.Info("cmd skipped")
+			u.publish(events.StepSkip, step, nil)
+			return true
+		}
+	}
+
+	log.Info("cmd start running")
+	u.publish(events.StepStart, step, nil)
+	handler := DefaultTaskMux.MustGetHandler(step.Path)
+	err := handler.Process(
+		&Task{
+			log:      u.log,
+			cfg:      u.cfg,
+			Filepath: u.taskGroup.Filepath,
+			StopChan: u.stopChan,
+			Cmd:      step.Cmd(),
+			Env:      u.taskGroup.Env,
+		},
+	)
+	u.publish(events.StepEnd, step, err)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	return true
+}
+
+// publish publishes an event of the step.
+func (u *uploader) publish(typ events.Type, step config.Step, err error) {
+	e := events.Event{Type: typ, Time: time.Now()}
+	if b := u.taskGroup.Bout; b != nil {
+		e = events.New(typ, b)
+	}
+	e.SessionID = u.taskGroup.SessionID
+	e.Out = u.taskGroup.Filepath
+	e.Step = step.Name
+	if err != nil {
+		e.Message = err.Error()
 	}
+	u.events.Publish(e)
 }
 
 func (u *uploader) stop() {
@@ -2,6 +2,7 @@ package uploader
 
 import (
 	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
 	"github.com/sirupsen/logrus"
 )
 
@@ -12,19 +13,21 @@ type worker struct {
 	stopChan chan struct{}
 	doneChan chan struct{}
 	uploader Uploader
+	events   *events.Bus
 }
 
 func (w *worker) done() <-chan struct{} {
 	return w.doneChan
 }
 
-func newWorker(log *logrus.Logger, cfg *config.Config, id uint) *worker {
+func newWorker(log *logrus.Logger, cfg *config.Config, id uint, bus *events.Bus) *worker {
 	return &worker{
 		log:      log,
 		cfg:      cfg,
 		id:       id,
 		stopChan: make(chan struct{}),
 		doneChan: make(chan struct{}),
+		events:   bus,
 	}
 }
 
@@ -36,7 +39,7 @@ func (w *worker) start(tasks <-chan *TaskGroup) {
 		if !ok {
 			return
 		}
-		w.uploader = NewUploader(w.log, w.cfg, task)
+		w.uploader = NewUploader(w.log, w.cfg, task, w.events)
 
 		select {
 		case <-w.stopChan:
@@ -1,15 +1,14 @@
 package uploader
 
 import (
-	"os/exec"
 	"path/filepath"
+	"sync"
 
 	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
 	"github.com/sirupsen/logrus"
 )
 
-var UploaderWorkerPool *WorkerPool
-
 func (wp *WorkerPool) BiliupPrerun() {
 	files, err := filepath.Glob(filepath.Join(wp.cfg.SaveDir, "*.flv"))
 	if err != nil {
@@ -19,9 +18,11 @@ func (wp *WorkerPool) BiliupPrerun() {
 	for i, filepath := range files {
 		tasks[i] = &TaskGroup{
 			Filepath: filepath,
-			PostCmds: []*exec.Cmd{
-				{Path: olivebiliup},
-				{Path: olivetrash},
+			Pipeline: &config.Pipeline{
+				Steps: []config.Step{
+					{Name: olivebiliup, Path: olivebiliup},
+					{Name: olivetrash, Path: olivetrash, Needs: []string{olivebiliup}},
+				},
 			},
 			cfg: wp.cfg,
 		}
@@ -36,9 +37,13 @@ type WorkerPool struct {
 	workers     []*worker
 	uploadTasks chan *TaskGroup
 	stopChan    chan struct{}
+
+	// mu guards uploadTasks from being closed while tasks are added.
+	mu      sync.RWMutex
+	stopped bool
 }
 
-func NewWorkerPool(log *logrus.Logger, concurrency uint, cfg *config.Config) *WorkerPool {
+func NewWorkerPool(log *logrus.Logger, concurrency uint, cfg *config.Config, bus *events.Bus) *WorkerPool {
 	wp := &WorkerPool{
 		log:         log,
 		cfg:         cfg,
@@ -47,20 +52,21 @@ func NewWorkerPool(log *logrus.Logger, concurrency uint, cfg *config.Config) *Wo
 		stopChan:    make(chan struct{}),
 	}
 	for i := uint(0); i < wp.concurrency; i++ {
-		w := newWorker(log, cfg, i)
+		w := newWorker(log, cfg, i, bus)
 		wp.workers = append(wp.workers, w)
 	}
 	return wp
 }
 
+// AddTask queues the tasks, which are dropped once the pool is stopped.
 func (wp *WorkerPool) AddTask(tasks ...*TaskGroup) {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	if wp.stopped {
+		return
+	}
 	for _, t := range tasks {
-		select {
-		case <-wp.stopChan:
-			return
-		default:
-			wp.uploadTasks <- t
-		}
+		wp.uploadTasks <- t
 	}
 }
 
@@ -70,9 +76,14 @@ func (wp *WorkerPool) Run() {
 	}
 }
 
+// Stop stops the workers, the tasks in progress being stopped and the queued
+// ones dropped.
 func (wp *WorkerPool) Stop() {
 	close(wp.stopChan)
+	wp.mu.Lock()
+	wp.stopped = true
 	close(wp.uploadTasks)
+	wp.mu.Unlock()
 	for _, worker := range wp.workers {
 		worker.stop()
 		<-worker.done()
@@ -0,0 +1,122 @@
+package uploader_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/uploader"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPipeline(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	record := func(name string, err error) uploader.TaskHandlerFunc {
+		return func(*uploader.Task) error {
+			mu.Lock()
+			ran = append(ran, name)
+			mu.Unlock()
+			return err
+		}
+	}
+	// the branches wait for each other, finishing only when run at the same
+	// time.
+	var branches sync.WaitGroup
+	branches.Add(2)
+	branch := func(name string) uploader.TaskHandlerFunc {
+		return func(t *uploader.Task) error {
+			branches.Done()
+			branches.Wait()
+			return record(name, nil)(t)
+		}
+	}
+	synced := make(chan struct{})
+	uploader.DefaultTaskMux.RegisterHandler("test-fix", record("fix", nil))
+	uploader.DefaultTaskMux.RegisterHandler("test-up1", branch("up"))
+	uploader.DefaultTaskMux.RegisterHandler("test-up2", branch("up"))
+	uploader.DefaultTaskMux.RegisterHandler("test-fail", record("fail", errors.New("failed")))
+	uploader.DefaultTaskMux.RegisterHandler("test-big", record("big", nil))
+	uploader.DefaultTaskMux.RegisterHandler("test-clean", record("clean", nil))
+	uploader.DefaultTaskMux.RegisterHandler("test-sync", uploader.TaskHandlerFunc(func(*uploader.Task) error {
+		synced <- struct{}{}
+		return nil
+	}))
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	// a single worker runs the groups one after another.
+	pool := uploader.NewWorkerPool(log, 1, &config.Config{}, nil)
+	pool.Run()
+
+	run := func(postCmds string) []string {
+		pipeline, err := config.ParsePipeline(postCmds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		ran = nil
+		mu.Unlock()
+		pool.AddTask(
+			&uploader.TaskGroup{Filepath: "test", Pipeline: pipeline},
+			&uploader.TaskGroup{Filepath: "test", Pipeline: &config.Pipeline{
+				Steps: []config.Step{{Name: "sync", Path: "test-sync"}},
+			}},
+		)
+		select {
+		case <-synced:
+		case <-time.After(5 * time.Second):
+			t.Fatal("pipeline is stuck")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return ran
+	}
+
+	got := run(`{"Steps":[
+		{"Name":"clean","Path":"test-clean","Final":true},
+		{"Name":"up1","Path":"test-up1","Needs":["fix"]},
+		{"Name":"up2","Path":"test-up2","Needs":["fix"]},
+		{"Name":"fix","Path":"test-fix"}
+	]}`)
+	if want := []string{"fix", "up", "up", "clean"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = run(`{"Steps":[
+		{"Name":"fix","Path":"test-fix"},
+		{"Name":"fail","Path":"test-fail","Needs":["fix"]},
+		{"Name":"after","Path":"test-fix","Needs":["fail"]},
+		{"Name":"big","Path":"test-big","If":{"MinFileSize":1000000}},
+		{"Name":"clean","Path":"test-clean","Final":true}
+	]}`)
+	if want := []string{"fix", "fail"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = run(`[{"Path":"test-fix"},{"Path":"test-big"},{"Path":"test-clean"}]`)
+	if want := []string{"fix", "big", "clean"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestStoppedPool(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	pool := uploader.NewWorkerPool(log, 2, &config.Config{}, nil)
+	pool.Run()
+	pool.Stop()
+
+	// a task handed over after the pool stopped is dropped.
+	pipeline, err := config.ParsePipeline(`[{"Name":"fix","Path":"test-fix"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.AddTask(&uploader.TaskGroup{Filepath: "a.flv", Pipeline: pipeline})
+}
@@ -0,0 +1,238 @@
+// Package amf provides support for encoding and decoding AMF0 values, see
+// https://rtmp.veriskope.com/pdf/amf0-file-format-specification.pdf
+package amf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Set of AMF0 markers.
+const (
+	markerNumber      = 0x00
+	markerBoolean     = 0x01
+	markerString      = 0x02
+	markerObject      = 0x03
+	markerNull        = 0x05
+	markerUndefined   = 0x06
+	markerECMAArray   = 0x08
+	markerObjectEnd   = 0x09
+	markerStrictArray = 0x0a
+	markerDate        = 0x0b
+	markerLongString  = 0x0c
+)
+
+var ErrUnsupportedMarker = errors.New("unsupported amf0 marker")
+
+// Property is a key value pair of an Object, which keeps its order.
+type Property struct {
+	Key   string
+	Value any
+}
+
+// Object is an anonymous AMF0 object.
+type Object []Property
+
+// ECMAArray is an AMF0 associative array.
+type ECMAArray []Property
+
+// Encode encodes the values one after another. Supported values are nil,
+// bool, float64, int, uint32, string, Object, ECMAArray, []float64 and []any.
+func Encode(values ...any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, v := range values {
+		if err := encode(buf, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(w *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		w.WriteByte(markerNull)
+	case bool:
+		w.WriteByte(markerBoolean)
+		if v {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	case float64:
+		w.WriteByte(markerNumber)
+		binary.Write(w, binary.BigEndian, math.Float64bits(v))
+	case int:
+		return encode(w, float64(v))
+	case uint32:
+		return encode(w, float64(v))
+	case string:
+		if len(v) > math.MaxUint16 {
+			w.WriteByte(markerLongString)
+			binary.Write(w, binary.BigEndian, uint32(len(v)))
+			w.WriteString(v)
+			return nil
+		}
+		w.WriteByte(markerString)
+		writeKey(w, v)
+	case Object:
+		w.WriteByte(markerObject)
+		return encodeProperties(w, v)
+	case ECMAArray:
+		w.WriteByte(markerECMAArray)
+		binary.Write(w, binary.BigEndian, uint32(len(v)))
+		return encodeProperties(w, v)
+	case []float64:
+		w.WriteByte(markerStrictArray)
+		binary.Write(w, binary.BigEndian, uint32(len(v)))
+		for _, n := range v {
+			encode(w, n)
+		}
+	case []any:
+		w.WriteByte(markerStrictArray)
+		binary.Write(w, binary.BigEndian, uint32(len(v)))
+		for _, e := range v {
+			if err := encode(w, e); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("amf0: unsupported type %T", v)
+	}
+	return nil
+}
+
+func encodeProperties(w *bytes.Buffer, props []Property) error {
+	for _, p := range props {
+		writeKey(w, p.Key)
+		if err := encode(w, p.Value); err != nil {
+			return err
+		}
+	}
+	w.Write([]byte{0, 0, markerObjectEnd})
+	return nil
+}
+
+func writeKey(w *bytes.Buffer, s string) {
+	binary.Write(w, binary.BigEndian, uint16(len(s)))
+	w.WriteString(s)
+}
+
+// Decode decodes all the values of data. Objects and ECMA arrays are decoded
+// as map[string]any, strict arrays as []any.
+func Decode(data []byte) ([]any, error) {
+	r := bytes.NewReader(data)
+	var values []any
+	for r.Len() > 0 {
+		v, err := decode(r)
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func decode(r *bytes.Reader) (any, error) {
+	marker, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch marker {
+	case markerNumber:
+		var n uint64
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	case markerBoolean:
+		b, err := r.ReadByte()
+		return b != 0, err
+	case markerString:
+		return readKey(r)
+	case markerLongString:
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		return readString(r, int(n))
+	case markerObject:
+		return decodeProperties(r)
+	case markerECMAArray:
+		if _, err := r.Seek(4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		return decodeProperties(r)
+	case markerStrictArray:
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		list := make([]any, 0, n)
+		for i := uint32(0); i < n; i++ {
+			v, err := decode(r)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case markerDate:
+		var ms uint64
+		if err := binary.Read(r, binary.BigEndian, &ms); err != nil {
+			return nil, err
+		}
+		// skip the time zone.
+		_, err := r.Seek(2, io.SeekCurrent)
+		return math.Float64frombits(ms), err
+	case markerNull, markerUndefined:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: 0x%02x", ErrUnsupportedMarker, marker)
+	}
+}
+
+func decodeProperties(r *bytes.Reader) (map[string]any, error) {
+	m := make(map[string]any)
+	for {
+		key, err := readKey(r)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			marker, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if marker == markerObjectEnd {
+				return m, nil
+			}
+			r.UnreadByte()
+		}
+		v, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+}
+
+func readKey(r *bytes.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return "", err
+	}
+	return readString(r, int(n))
+}
+
+func readString(r *bytes.Reader, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
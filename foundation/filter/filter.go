@@ -0,0 +1,102 @@
+// Package filter tells whether a room is to be recorded, given regular
+// expressions on its name, category and tags.
+package filter
+
+import (
+	"regexp"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Rule gates recording on the room name, category and tags. A room is
+// recorded if every field with include patterns matches one of them, and no
+// field matches an exclude pattern.
+type Rule struct {
+	Include Fields
+	Exclude Fields
+	// Recheck keeps evaluating the rule while recording, stopping the
+	// recording when the room stops matching.
+	Recheck bool
+}
+
+// Fields are the patterns per field, a room with unknown category or no tags
+// never matching patterns on them.
+type Fields struct {
+	RoomName []string
+	Category []string
+	Tags     []string
+
+	roomName []*regexp.Regexp
+	category []*regexp.Regexp
+	tags     []*regexp.Regexp
+}
+
+// Parse parses a rule given as json.
+func Parse(str string) (*Rule, error) {
+	var r Rule
+	if err := jsoniter.UnmarshalFromString(str, &r); err != nil {
+		return nil, err
+	}
+	if err := r.Include.compile(); err != nil {
+		return nil, err
+	}
+	if err := r.Exclude.compile(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (f *Fields) compile() error {
+	for _, fld := range []struct {
+		patterns []string
+		res      *[]*regexp.Regexp
+	}{
+		{f.RoomName, &f.roomName},
+		{f.Category, &f.category},
+		{f.Tags, &f.tags},
+	} {
+		for _, pattern := range fld.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return err
+			}
+			*fld.res = append(*fld.res, re)
+		}
+	}
+	return nil
+}
+
+// Satisfy reports whether the room is to be recorded, a nil rule recording
+// every room.
+func (r *Rule) Satisfy(roomName, category string, tags []string) bool {
+	if r == nil {
+		return true
+	}
+	for _, f := range []struct {
+		include, exclude []*regexp.Regexp
+		values           []string
+	}{
+		{r.Include.roomName, r.Exclude.roomName, []string{roomName}},
+		{r.Include.category, r.Exclude.category, []string{category}},
+		{r.Include.tags, r.Exclude.tags, tags},
+	} {
+		if len(f.include) > 0 && !matchAny(f.include, f.values) {
+			return false
+		}
+		if matchAny(f.exclude, f.values) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(res []*regexp.Regexp, values []string) bool {
+	for _, re := range res {
+		for _, v := range values {
+			if v != "" && re.MatchString(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/go-olive/olive/foundation/filter"
+)
+
+func TestSatisfy(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		roomName string
+		category string
+		tags     []string
+		want     bool
+	}{
+		{"empty rule", `{}`, "anything", "", nil, true},
+		{"include room name", `{"Include":{"RoomName":["^ASMR"]}}`, "ASMR night", "", nil, true},
+		{"include room name, miss", `{"Include":{"RoomName":["^ASMR"]}}`, "gaming", "", nil, false},
+		{"exclude room name", `{"Exclude":{"RoomName":["rerun"]}}`, "rerun of yesterday", "", nil, false},
+		{"exclude room name, miss", `{"Exclude":{"RoomName":["rerun"]}}`, "live now", "", nil, true},
+		{"include category", `{"Include":{"Category":["Music"]}}`, "", "Music", nil, true},
+		{"include category, unknown", `{"Include":{"Category":["Music"]}}`, "", "", nil, false},
+		{"exclude category, unknown", `{"Exclude":{"Category":[".*"]}}`, "", "", nil, true},
+		{"include tags, one matches", `{"Include":{"Tags":["^singing$"]}}`, "", "", []string{"chat", "singing"}, true},
+		{"include tags, none", `{"Include":{"Tags":["^singing$"]}}`, "", "", nil, false},
+		{"exclude tags", `{"Exclude":{"Tags":["sponsored"]}}`, "", "", []string{"sponsored"}, false},
+		{"exclude tags, none", `{"Exclude":{"Tags":["sponsored"]}}`, "", "", nil, true},
+		{"exclude wins over include", `{"Include":{"Category":["Music"]},"Exclude":{"RoomName":["rerun"]}}`, "rerun", "Music", nil, false},
+		{"every include must match", `{"Include":{"Category":["Music"],"Tags":["singing"]}}`, "", "Music", []string{"chat"}, false},
+	}
+	for _, tt := range tests {
+		fr, err := filter.Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := fr.Satisfy(tt.roomName, tt.category, tt.tags); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var nilRule *filter.Rule
+	if !nilRule.Satisfy("", "", nil) {
+		t.Error("nil rule: should record every room")
+	}
+	if _, err := filter.Parse(`{"Include":{"RoomName":["("]}}`); err == nil {
+		t.Error("invalid pattern: should fail")
+	}
+}
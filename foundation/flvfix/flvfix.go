@@ -0,0 +1,325 @@
+// Package flvfix repairs flv recordings so that players can seek them: it
+// normalizes the timestamps, drops the broken tags and writes an onMetaData
+// tag carrying the duration, the filesize and a keyframe index.
+package flvfix
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/go-olive/flv"
+	"github.com/go-olive/olive/foundation/amf"
+)
+
+var ErrNotFLV = errors.New("not a flv file")
+
+const (
+	tagAudio  = 8
+	tagVideo  = 9
+	tagScript = 18
+
+	flvHeaderSize = 9 + 4
+	tagHeaderSize = 11
+
+	// maxGap is the largest timestamp step, in milliseconds, between two tags
+	// of the same kind. Larger or backward steps are jumps, e.g. the stream
+	// restarted after a reconnect.
+	maxGap = 1000
+	// maxBrokenTags is the number of consecutive unreadable tags after which
+	// the rest of the file is given up.
+	maxBrokenTags = 8
+)
+
+// frameSteps are the timestamp steps, in milliseconds, used to close a jump.
+var frameSteps = map[byte]int64{
+	tagAudio: 23,
+	tagVideo: 33,
+}
+
+// IsFLV reports whether the file starts with a flv header.
+func IsFLV(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	sig := make([]byte, 3)
+	if _, err := io.ReadFull(f, sig); err != nil {
+		return false
+	}
+	return string(sig) == "FLV"
+}
+
+// FixFile repairs the flv file in place.
+func FixFile(path string) error {
+	tmp := path + ".fix"
+	if err := Fix(path, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Fix repairs the flv file in and writes the result to out. The input is
+// read twice: once to build the metadata, then to copy the tags after it.
+func Fix(in, out string) error {
+	return Merge([]string{in}, out)
+}
+
+// Merge concatenates the flv files ins into out, repairing them on the way.
+// The timestamps run on from one file to the next.
+func Merge(ins []string, out string) error {
+	var idx index
+	if err := scanAll(ins, idx.add); err != nil {
+		return err
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	if _, err := w.Write(idx.header()); err != nil {
+		return err
+	}
+	if _, err := w.Write(idx.metadataTag()); err != nil {
+		return err
+	}
+	err = scanAll(ins, func(c *flv.TagCompo, ts uint32) error {
+		header := c.TagHeaderRaw
+		header[4], header[5], header[6], header[7] = byte(ts>>16), byte(ts>>8), byte(ts), byte(ts>>24)
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		_, err := w.Write(c.TagBodyRaw)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+type bufferedFile struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// scanAll scans the files one after the other, normalizing the timestamps
+// across them.
+func scanAll(paths []string, fn func(c *flv.TagCompo, ts uint32) error) error {
+	var n normalizer
+	for _, path := range paths {
+		if err := scan(path, &n, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan calls fn with every audio and video tag kept from the file, along with
+// its normalized timestamp.
+func scan(path string, n *normalizer, fn func(c *flv.TagCompo, ts uint32) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	d, err := flv.NewDemuxer(bufferedFile{Reader: bufio.NewReader(f), Closer: f})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	defer d.Close()
+
+	// the header is checked by hand, the demuxer decoding more than it reads.
+	header := flv.GetHeaderCompo()
+	defer header.Put()
+	header.Raw = [flvHeaderSize]byte{}
+	d.ReadHeader(header)
+	if raw := header.Raw; string(raw[:3]) != "FLV" || binary.BigEndian.Uint32(raw[5:9]) != 9 {
+		return ErrNotFLV
+	}
+
+	var broken int
+	for {
+		c := new(flv.TagCompo)
+		err := d.ReadTag(c)
+		switch {
+		case err == nil:
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			// a truncated last tag is dropped.
+			return nil
+		default:
+			free(c)
+			if broken++; broken > maxBrokenTags {
+				return nil
+			}
+			continue
+		}
+		broken = 0
+
+		// script tags are dropped along with the stale onMetaData.
+		if (c.TagType != tagAudio && c.TagType != tagVideo) || c.GetDataSize() == 0 {
+			free(c)
+			continue
+		}
+
+		err = fn(c, n.next(c.TagType, c.GetTimestamp()))
+		free(c)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func free(c *flv.TagCompo) {
+	if c.TagBodyRaw != nil {
+		c.Free()
+	}
+}
+
+// normalizer makes the timestamps start at zero and closes the jumps.
+type normalizer struct {
+	started bool
+	offset  int64
+	max     int64
+	last    map[byte]int64
+}
+
+func (n *normalizer) next(typ byte, raw uint32) uint32 {
+	if !n.started {
+		n.started = true
+		n.offset = -int64(raw)
+		n.last = make(map[byte]int64)
+	}
+
+	ts := int64(raw) + n.offset
+	if last, ok := n.last[typ]; ok && (ts < last || ts > last+maxGap) {
+		n.offset = n.max + frameSteps[typ] - int64(raw)
+		ts = n.max + frameSteps[typ]
+	}
+	if ts < 0 {
+		ts = 0
+	}
+
+	n.last[typ] = ts
+	if ts > n.max {
+		n.max = ts
+	}
+	return uint32(ts)
+}
+
+type keyframe struct {
+	ts  uint32
+	pos int64
+}
+
+// index gathers what the onMetaData tag needs to know about the tags.
+type index struct {
+	hasAudio   bool
+	hasVideo   bool
+	audioCodec byte
+	videoCodec byte
+	lastTS     uint32
+	size       int64
+	keyframes  []keyframe
+}
+
+func (idx *index) add(c *flv.TagCompo, ts uint32) error {
+	switch c.TagType {
+	case tagAudio:
+		idx.hasAudio = true
+		idx.audioCodec = c.TagBodyRaw[0] >> 4
+	case tagVideo:
+		idx.hasVideo = true
+		idx.videoCodec = c.TagBodyRaw[0] & 0x0f
+		if idx.isKeyframe(c) {
+			idx.keyframes = append(idx.keyframes, keyframe{ts: ts, pos: idx.size})
+		}
+	}
+	if ts > idx.lastTS {
+		idx.lastTS = ts
+	}
+	idx.size += tagHeaderSize + int64(len(c.TagBodyRaw))
+	return nil
+}
+
+func (idx *index) isKeyframe(c *flv.TagCompo) bool {
+	if c.TagBodyRaw[0]>>4 != 1 {
+		return false
+	}
+	// the sequence headers of AVC and HEVC are flagged as keyframes too.
+	codec := c.TagBodyRaw[0] & 0x0f
+	if (codec == 7 || codec == 12) && len(c.TagBodyRaw) > 1 && c.TagBodyRaw[1] == 0 {
+		return false
+	}
+	return true
+}
+
+func (idx *index) header() []byte {
+	var flags byte
+	if idx.hasAudio {
+		flags |= 0x04
+	}
+	if idx.hasVideo {
+		flags |= 0x01
+	}
+	return []byte{'F', 'L', 'V', 1, flags, 0, 0, 0, 9, 0, 0, 0, 0}
+}
+
+// metadataTag returns the onMetaData tag. AMF0 numbers having a fixed size,
+// the tag is built once to learn its size, then again with the positions.
+func (idx *index) metadataTag() []byte {
+	size := len(idx.metadataTagAt(0))
+	return idx.metadataTagAt(int64(flvHeaderSize + size))
+}
+
+func (idx *index) metadataTagAt(base int64) []byte {
+	times := make([]float64, len(idx.keyframes))
+	positions := make([]float64, len(idx.keyframes))
+	for i, k := range idx.keyframes {
+		times[i] = float64(k.ts) / 1000
+		positions[i] = float64(base + k.pos)
+	}
+
+	props := amf.ECMAArray{
+		{Key: "duration", Value: float64(idx.lastTS) / 1000},
+		{Key: "filesize", Value: float64(base + idx.size)},
+		{Key: "hasAudio", Value: idx.hasAudio},
+		{Key: "hasVideo", Value: idx.hasVideo},
+	}
+	if idx.hasAudio {
+		props = append(props, amf.Property{Key: "audiocodecid", Value: float64(idx.audioCodec)})
+	}
+	if idx.hasVideo {
+		props = append(props, amf.Property{Key: "videocodecid", Value: float64(idx.videoCodec)})
+	}
+	props = append(props,
+		amf.Property{Key: "lasttimestamp", Value: float64(idx.lastTS) / 1000},
+		amf.Property{Key: "canSeekToEnd", Value: true},
+		amf.Property{Key: "hasKeyframes", Value: len(idx.keyframes) > 0},
+		amf.Property{Key: "keyframes", Value: amf.Object{
+			{Key: "filepositions", Value: positions},
+			{Key: "times", Value: times},
+		}},
+	)
+
+	// every value is supported, Encode can not fail.
+	data, _ := amf.Encode("onMetaData", props)
+
+	n := len(data)
+	tag := make([]byte, 0, tagHeaderSize+n+4)
+	tag = append(tag, tagScript, byte(n>>16), byte(n>>8), byte(n), 0, 0, 0, 0, 0, 0, 0)
+	tag = append(tag, data...)
+	prev := uint32(tagHeaderSize + n)
+	return append(tag, byte(prev>>24), byte(prev>>16), byte(prev>>8), byte(prev))
+}
@@ -0,0 +1,165 @@
+package flvfix_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-olive/flv"
+	"github.com/go-olive/olive/foundation/amf"
+	"github.com/go-olive/olive/foundation/flvfix"
+)
+
+func TestFix(t *testing.T) {
+	var in bytes.Buffer
+	in.Write([]byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0})
+	writeTag(&in, 18, 0, []byte("stale metadata"), false)
+	writeTag(&in, 9, 5000, []byte{0x17, 0x00, 0, 0, 0}, false) // AVC sequence header
+	writeTag(&in, 9, 5000, []byte{0x17, 0x01, 1}, false)
+	writeTag(&in, 8, 5010, []byte{0xaf, 0x01, 2}, false)
+	writeTag(&in, 9, 5040, []byte{0x27, 0x01, 3}, false)
+	writeTag(&in, 8, 5030, []byte{0xaf, 0x01, 4}, true) // broken
+	// the stream restarted after a reconnect.
+	writeTag(&in, 9, 0, []byte{0x17, 0x01, 5}, false)
+	writeTag(&in, 8, 10, []byte{0xaf, 0x01, 6}, false)
+	writeTag(&in, 9, 40, []byte{0x27, 0x01, 7}, false)
+	in.Write([]byte{9, 0, 0, 3}) // truncated
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.flv")
+	if err := os.WriteFile(path, in.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !flvfix.IsFLV(path) {
+		t.Fatal("Should detect the flv header")
+	}
+	if err := flvfix.FixFile(path); err != nil {
+		t.Fatalf("Should be able to fix the file: %s", err)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, meta := readTags(t, path)
+
+	if len(tags) != 8 || tags[0].typ != 18 {
+		t.Fatalf("Should get onMetaData and 7 tags, got %+v", tags)
+	}
+	wantTS := []uint32{0, 0, 10, 40, 73, 83, 113}
+	wantPayload := []byte{0, 1, 2, 3, 5, 6, 7}
+	for i, tg := range tags[1:] {
+		if tg.ts != wantTS[i] || tg.payload != wantPayload[i] {
+			t.Errorf("Should normalize tag %d, got ts %d payload %d, want ts %d payload %d", i, tg.ts, tg.payload, wantTS[i], wantPayload[i])
+		}
+	}
+
+	values, err := amf.Decode(meta)
+	if err != nil || len(values) != 2 || values[0] != "onMetaData" {
+		t.Fatalf("Should decode onMetaData, got %v: %v", values, err)
+	}
+	props := values[1].(map[string]any)
+	if got := props["duration"]; got != 0.113 {
+		t.Errorf("Should get the duration, got %v", got)
+	}
+	if got := props["filesize"]; got != float64(len(out)) {
+		t.Errorf("Should get the filesize %d, got %v", len(out), got)
+	}
+	keyframes := props["keyframes"].(map[string]any)
+	positions := keyframes["filepositions"].([]any)
+	times := keyframes["times"].([]any)
+	if len(positions) != 2 || positions[0] != float64(tags[2].pos) || positions[1] != float64(tags[5].pos) {
+		t.Errorf("Should index the keyframes at %d and %d, got %v", tags[2].pos, tags[5].pos, positions)
+	}
+	if len(times) != 2 || times[0] != 0.0 || times[1] != 0.073 {
+		t.Errorf("Should index the keyframe times, got %v", times)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	var parts []string
+	for i, start := range []uint32{90000, 0} {
+		var in bytes.Buffer
+		in.Write([]byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0})
+		writeTag(&in, 9, start, []byte{0x17, 0x01, byte(2 * i)}, false)
+		writeTag(&in, 9, start+40, []byte{0x27, 0x01, byte(2*i + 1)}, false)
+		path := filepath.Join(dir, fmt.Sprintf("part%d.flv", i))
+		if err := os.WriteFile(path, in.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, path)
+	}
+
+	out := filepath.Join(dir, "merged.flv")
+	if err := flvfix.Merge(parts, out); err != nil {
+		t.Fatalf("Should be able to merge the files: %s", err)
+	}
+
+	tags, _ := readTags(t, out)
+	if len(tags) != 5 || tags[0].typ != 18 {
+		t.Fatalf("Should get onMetaData and 4 tags, got %+v", tags)
+	}
+	wantTS := []uint32{0, 40, 73, 113}
+	for i, tg := range tags[1:] {
+		if tg.ts != wantTS[i] || tg.payload != byte(i) {
+			t.Errorf("Should run the timestamps on, got tag %d at %d with payload %d, want %d", i, tg.ts, tg.payload, wantTS[i])
+		}
+	}
+}
+
+type tag struct {
+	typ     byte
+	ts      uint32
+	payload byte
+	pos     int
+}
+
+// readTags reads back the tags of a fixed file along with the onMetaData
+// body.
+func readTags(t *testing.T, path string) ([]tag, []byte) {
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		tags []tag
+		meta []byte
+	)
+	pos := 13
+	f, _ := os.Open(path)
+	d, _ := flv.NewDemuxer(f)
+	defer d.Close()
+	d.ReadHeader(flv.GetHeaderCompo())
+	for {
+		c := new(flv.TagCompo)
+		if err := d.ReadTag(c); err != nil {
+			break
+		}
+		tags = append(tags, tag{c.TagType, c.GetTimestamp(), c.TagBodyRaw[len(c.TagBodyRaw)-5], pos})
+		if c.TagType == 18 {
+			meta = append([]byte(nil), c.TagBodyRaw[:len(c.TagBodyRaw)-4]...)
+		}
+		pos += 11 + len(c.TagBodyRaw)
+	}
+	if pos != len(out) {
+		t.Fatalf("Should read the whole file, read %d of %d bytes", pos, len(out))
+	}
+	return tags, meta
+}
+
+func writeTag(b *bytes.Buffer, typ byte, ts uint32, data []byte, broken bool) {
+	// the last data byte is the payload marker the test checks.
+	n := len(data)
+	b.Write([]byte{typ, byte(n >> 16), byte(n >> 8), byte(n), byte(ts >> 16), byte(ts >> 8), byte(ts), byte(ts >> 24), 0, 0, 0})
+	b.Write(data)
+	prev := uint32(n + 11)
+	if broken {
+		prev++
+	}
+	binary.Write(b, binary.BigEndian, prev)
+}
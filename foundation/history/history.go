@@ -0,0 +1,208 @@
+// Package history keeps when shows went live, to learn when they are likely
+// to go live again and which of them have gone dormant.
+package history
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// maxStarts is the number of starts kept per show.
+const maxStarts = 60
+
+// Book is the history of a set of shows, saved to a file when it has a path.
+// The sightings, coming with every poll, are only saved on Flush, pauses are
+// saved at once.
+type Book struct {
+	mu    sync.Mutex
+	path  string
+	shows map[string]*Record
+	dirty bool
+}
+
+// Record is the history of a show.
+type Record struct {
+	// Since is when the show was first watched.
+	Since time.Time
+	// LastLive is the last time the show was seen live.
+	LastLive time.Time
+	// Starts are the times it went live, oldest first.
+	Starts []time.Time
+	// PausedUntil is when the show paused by hand resumes, zero if it is not
+	// paused.
+	PausedUntil time.Time
+}
+
+// Open loads the book from path, an empty path or a missing file giving an
+// empty book. An empty path keeps the book in memory only.
+func Open(path string) (*Book, error) {
+	b := &Book{
+		path:  path,
+		shows: make(map[string]*Record),
+	}
+	if path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return b, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := jsoniter.Unmarshal(data, &b.shows); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Watch starts the history of the show at t, if it has none.
+func (b *Book) Watch(id string, t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.shows[id]; ok {
+		return
+	}
+	b.shows[id] = &Record{Since: t}
+	b.dirty = true
+}
+
+// Live marks the show as seen live at t, and as started if start is set.
+func (b *Book) Live(id string, t time.Time, start bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := b.record(id, t)
+	r.LastLive = t
+	if start {
+		r.Starts = append(r.Starts, t)
+		if len(r.Starts) > maxStarts {
+			r.Starts = r.Starts[len(r.Starts)-maxStarts:]
+		}
+	}
+	b.dirty = true
+}
+
+// Likely reports whether the show went live around the time of day of t
+// often enough to expect it to again: at least twice, and for a quarter of
+// its starts.
+func (b *Book) Likely(id string, t time.Time, within time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.shows[id]
+	if !ok || len(r.Starts) < 2 {
+		return false
+	}
+
+	const day = 24 * time.Hour
+	now := sinceMidnight(t)
+	hits := 0
+	for _, start := range r.Starts {
+		d := sinceMidnight(start.In(t.Location())) - now
+		if d < 0 {
+			d = -d
+		}
+		if d > day/2 {
+			d = day - d
+		}
+		if d <= within {
+			hits++
+		}
+	}
+	return hits >= 2 && hits*4 >= len(r.Starts)
+}
+
+// Dormant reports whether the show has not been seen live for the given
+// duration at t, counting from when it was first watched.
+func (b *Book) Dormant(id string, t time.Time, after time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.shows[id]
+	if !ok {
+		return false
+	}
+	last := r.Since
+	if r.LastLive.After(last) {
+		last = r.LastLive
+	}
+	return t.Sub(last) > after
+}
+
+// Pause records the show as paused until t, a zero t resuming it.
+func (b *Book) Pause(id string, t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.shows[id]
+	if !ok && t.IsZero() {
+		return
+	}
+	if !ok {
+		r = b.record(id, time.Now())
+	}
+	r.PausedUntil = t
+	// saving is best effort here, the pause is saved again on the next Flush
+	// if it fails.
+	b.dirty = true
+	b.save()
+}
+
+// PausedUntil returns when the show resumes, if it is paused at t.
+func (b *Book) PausedUntil(id string, t time.Time) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.shows[id]
+	if !ok || !r.PausedUntil.After(t) {
+		return time.Time{}, false
+	}
+	return r.PausedUntil, true
+}
+
+func (b *Book) record(id string, t time.Time) *Record {
+	r, ok := b.shows[id]
+	if !ok {
+		r = &Record{Since: t}
+		b.shows[id] = r
+	}
+	return r
+}
+
+// Flush saves the changes made since the last save.
+func (b *Book) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.dirty {
+		return nil
+	}
+	return b.save()
+}
+
+// save writes the book to its file, b.mu must be held. The book stays dirty
+// when it fails, to be saved on the next Flush.
+func (b *Book) save() error {
+	if b.path == "" {
+		b.dirty = false
+		return nil
+	}
+	data, err := jsoniter.Marshal(b.shows)
+	if err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return err
+	}
+	b.dirty = false
+	return nil
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
@@ -0,0 +1,134 @@
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/foundation/history"
+)
+
+func TestLikely(t *testing.T) {
+	b, err := history.Open("")
+	if err != nil {
+		t.Fatalf("Should be able to open an in memory book: %s", err)
+	}
+
+	day := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	b.Watch("a", day)
+	for i, at := range []time.Duration{
+		20*time.Hour + 5*time.Minute,
+		20*time.Hour + 10*time.Minute,
+		19*time.Hour + 55*time.Minute,
+		9 * time.Hour,
+	} {
+		b.Live("a", day.AddDate(0, 0, i).Add(at), true)
+	}
+
+	tests := []struct {
+		at     time.Duration
+		likely bool
+	}{
+		{20 * time.Hour, true},
+		{19*time.Hour + 50*time.Minute, true},
+		{21 * time.Hour, false},
+		{9 * time.Hour, false}, // only once
+	}
+	for _, tt := range tests {
+		at := day.AddDate(0, 0, 10).Add(tt.at)
+		if got := b.Likely("a", at, 15*time.Minute); got != tt.likely {
+			t.Errorf("Should be likely %v at %s, got %v", tt.likely, tt.at, got)
+		}
+	}
+	if b.Likely("b", day, time.Hour) {
+		t.Error("Should not be likely for an unknown show")
+	}
+}
+
+func TestDormant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	b, err := history.Open(path)
+	if err != nil {
+		t.Fatalf("Should be able to open a missing book: %s", err)
+	}
+
+	since := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	b.Watch("a", since)
+	b.Watch("b", since)
+	b.Live("b", since.AddDate(0, 0, 20), false)
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Should be able to save the book: %s", err)
+	}
+
+	b, err = history.Open(path)
+	if err != nil {
+		t.Fatalf("Should be able to reopen the book: %s", err)
+	}
+	b.Live("b", since.AddDate(0, 0, 20), true)
+
+	at := since.AddDate(0, 0, 21)
+	if !b.Dormant("a", at, 14*24*time.Hour) {
+		t.Error("Should be dormant when never seen live")
+	}
+	if b.Dormant("b", at, 14*24*time.Hour) {
+		t.Error("Should not be dormant when seen live lately")
+	}
+}
+
+func TestPause(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	b, err := history.Open(path)
+	if err != nil {
+		t.Fatalf("Should be able to open a missing book: %s", err)
+	}
+
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour)
+	b.Pause("a", until)
+	b.Pause("b", until)
+	b.Pause("b", time.Time{})
+
+	b, err = history.Open(path)
+	if err != nil {
+		t.Fatalf("Should be able to reopen the book: %s", err)
+	}
+	if got, ok := b.PausedUntil("a", now); !ok || !got.Equal(until) {
+		t.Errorf("Should be paused until %s, got %s %v", until, got, ok)
+	}
+	if _, ok := b.PausedUntil("a", until); ok {
+		t.Error("Should not be paused once the pause is over")
+	}
+	if _, ok := b.PausedUntil("b", now); ok {
+		t.Error("Should not be paused once resumed")
+	}
+	if _, ok := b.PausedUntil("c", now); ok {
+		t.Error("Should not be paused for an unknown show")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	b, err := history.Open(path)
+	if err != nil {
+		t.Fatalf("Should be able to open a missing book: %s", err)
+	}
+
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	b.Watch("a", now)
+	b.Live("a", now, true)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Should not save the sightings before a flush, got %v", err)
+	}
+
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Should be able to save the book: %s", err)
+	}
+	b, err = history.Open(path)
+	if err != nil {
+		t.Fatalf("Should be able to reopen the book: %s", err)
+	}
+	if !b.Dormant("a", now.Add(3*time.Hour), 2*time.Hour) {
+		t.Error("Should keep the sightings once flushed")
+	}
+}
@@ -0,0 +1,390 @@
+// Package hls provides support for downloading HLS live streams.
+package hls
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/59.0.3071.115 Safari/537.36"
+
+	// retry is the number of attempts for a single segment or key.
+	retry = 3
+	// maxPlaylistFailures is the number of consecutive playlist failures
+	// tolerated before giving up on the stream.
+	maxPlaylistFailures = 5
+)
+
+var ErrUnsupportedKey = errors.New("unsupported key method")
+
+type Parser struct {
+	closeOnce sync.Once
+	stop      chan struct{}
+
+	mu    sync.RWMutex
+	out   string
+	stats Stats
+
+	client  *http.Client
+	header  http.Header
+	keys    map[string][]byte
+	lastSeq uint64
+	lastMap string
+	started bool
+}
+
+// Stats is a snapshot of the download.
+type Stats struct {
+	BytesRead    int64
+	BytesWritten int64
+	LastDataTime time.Time
+	// StreamTime is the total duration of the segments written.
+	StreamTime time.Duration
+	// PlaylistFailures is the number of playlist refreshes which failed.
+	PlaylistFailures int
+}
+
+func NewParser() *Parser {
+	return &Parser{
+		stop:   make(chan struct{}),
+		client: &http.Client{Timeout: 30 * time.Second},
+		header: http.Header{"User-Agent": []string{userAgent}},
+		keys:   make(map[string][]byte),
+	}
+}
+
+func (p *Parser) New() *Parser {
+	return NewParser()
+}
+
+func (p *Parser) Stop() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+func (p *Parser) Type() string {
+	return "hls"
+}
+
+// SetHeader sets a header sent along with every request.
+func (p *Parser) SetHeader(key, value string) {
+	p.header.Set(key, value)
+}
+
+// Out returns the output filepath, whose extension follows the container of
+// the stream: ".mp4" for fMP4 segments, the requested one otherwise.
+func (p *Parser) Out() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.out
+}
+
+// Stats returns a snapshot of the download.
+func (p *Parser) Stats() Stats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.stats
+}
+
+// Parse downloads the stream into out until the playlist ends, the stream
+// fails or the parser is stopped.
+func (p *Parser) Parse(streamURL string, out string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	mediaURL, pl, err := p.mediaPlaylist(ctx, streamURL)
+	if err != nil {
+		return err
+	}
+
+	if len(pl.Segments) > 0 && pl.Segments[0].Map != nil {
+		out = strings.TrimSuffix(out, filepath.Ext(out)) + ".mp4"
+	}
+	p.mu.Lock()
+	p.out = out
+	p.mu.Unlock()
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	failures := 0
+	for {
+		newSegments, err := p.writeSegments(ctx, f, pl)
+		if err != nil {
+			return p.stopped(err)
+		}
+		if pl.EndList {
+			return nil
+		}
+
+		// https://datatracker.ietf.org/doc/html/rfc8216#section-6.3.4
+		wait := time.Duration(pl.TargetDuration * float64(time.Second))
+		if newSegments == 0 {
+			wait /= 2
+		}
+		if wait <= 0 {
+			wait = time.Second
+		}
+		select {
+		case <-p.stop:
+			return nil
+		case <-time.After(wait):
+		}
+
+		next, err := p.playlist(ctx, mediaURL)
+		if err != nil {
+			p.mu.Lock()
+			p.stats.PlaylistFailures++
+			p.mu.Unlock()
+			if failures++; failures >= maxPlaylistFailures {
+				return p.stopped(err)
+			}
+			continue
+		}
+		failures = 0
+		pl = next
+	}
+}
+
+// stopped swallows the errors caused by Stop.
+func (p *Parser) stopped(err error) error {
+	select {
+	case <-p.stop:
+		return nil
+	default:
+		return err
+	}
+}
+
+// mediaPlaylist resolves a master playlist to its highest bandwidth variant.
+func (p *Parser) mediaPlaylist(ctx context.Context, streamURL string) (string, *Playlist, error) {
+	pl, err := p.playlist(ctx, streamURL)
+	if err != nil {
+		return "", nil, err
+	}
+	if !pl.IsMaster() {
+		return streamURL, pl, nil
+	}
+
+	best := pl.Variants[0]
+	for _, v := range pl.Variants[1:] {
+		if v.Bandwidth > best.Bandwidth {
+			best = v
+		}
+	}
+	pl, err = p.playlist(ctx, best.URI)
+	if err != nil {
+		return "", nil, err
+	}
+	if pl.IsMaster() {
+		return "", nil, fmt.Errorf("%w: nested master playlist", ErrInvalidPlaylist)
+	}
+	return best.URI, pl, nil
+}
+
+func (p *Parser) playlist(ctx context.Context, playlistURL string) (*Playlist, error) {
+	base, err := url.Parse(playlistURL)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.get(ctx, playlistURL, "")
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlaylist(bytes.NewReader(body), base)
+}
+
+// writeSegments writes the segments not written yet, deduplicated by media
+// sequence, and returns how many were new.
+func (p *Parser) writeSegments(ctx context.Context, w io.Writer, pl *Playlist) (int, error) {
+	if n := len(pl.Segments); p.started && n > 0 && pl.Segments[n-1].Sequence+1 < p.lastSeq {
+		// the media sequence went backwards, the stream has been restarted.
+		p.started = false
+	}
+
+	var n int
+	for _, seg := range pl.Segments {
+		if p.started && seg.Sequence < p.lastSeq {
+			continue
+		}
+
+		if seg.Discontinuity {
+			// let the decoder reset on the init segment following a discontinuity.
+			p.lastMap = ""
+		}
+		if seg.Map != nil && seg.Map.URI+seg.Map.ByteRange != p.lastMap {
+			init, err := p.get(ctx, seg.Map.URI, seg.Map.ByteRange)
+			if err != nil {
+				return n, err
+			}
+			if _, err := w.Write(init); err != nil {
+				return n, err
+			}
+			p.written(len(init), 0)
+			p.lastMap = seg.Map.URI + seg.Map.ByteRange
+		}
+
+		data, err := p.segment(ctx, seg)
+		p.started = true
+		p.lastSeq = seg.Sequence + 1
+		if err != nil {
+			if ctx.Err() != nil {
+				return n, err
+			}
+			// a lost segment is not worth giving up the whole stream.
+			continue
+		}
+		if _, err := w.Write(data); err != nil {
+			return n, err
+		}
+		p.written(len(data), seg.Duration)
+		n++
+	}
+	return n, nil
+}
+
+func (p *Parser) segment(ctx context.Context, seg Segment) ([]byte, error) {
+	data, err := p.get(ctx, seg.URI, seg.ByteRange)
+	if err != nil {
+		return nil, err
+	}
+	if seg.Key == nil {
+		return data, nil
+	}
+	return p.decrypt(ctx, seg, data)
+}
+
+func (p *Parser) decrypt(ctx context.Context, seg Segment, data []byte) ([]byte, error) {
+	if seg.Key.Method != "AES-128" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, seg.Key.Method)
+	}
+
+	key, ok := p.keys[seg.Key.URI]
+	if !ok {
+		var err error
+		if key, err = p.get(ctx, seg.Key.URI, ""); err != nil {
+			return nil, err
+		}
+		p.keys[seg.Key.URI] = key
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if seg.Key.IV != "" {
+		raw := strings.TrimPrefix(strings.TrimPrefix(seg.Key.IV, "0x"), "0X")
+		b, err := hex.DecodeString(raw)
+		if err != nil || len(b) != aes.BlockSize {
+			return nil, fmt.Errorf("invalid iv %q", seg.Key.IV)
+		}
+		iv = b
+	} else {
+		binary.BigEndian.PutUint64(iv[8:], seg.Sequence)
+	}
+
+	return Decrypt(key, iv, data)
+}
+
+// Decrypt decrypts an AES-128 encrypted segment and strips its PKCS#7 padding.
+func Decrypt(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("segment is not a multiple of the block size")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return nil, errors.New("invalid padding")
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// get fetches url with retries, honouring an optional "length@offset" byte range.
+func (p *Parser) get(ctx context.Context, url, byteRange string) (body []byte, err error) {
+	for i := 0; i < retry; i++ {
+		if body, err = p.fetch(ctx, url, byteRange); err == nil || ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second * time.Duration(i+1)):
+		}
+	}
+	return
+}
+
+func (p *Parser) fetch(ctx context.Context, url, byteRange string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = p.header.Clone()
+	var length, offset int64
+	if byteRange != "" {
+		if _, err := fmt.Sscanf(byteRange, "%d@%d", &length, &offset); err == nil && length > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("get %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+
+	p.mu.Lock()
+	p.stats.BytesRead += int64(len(body))
+	p.mu.Unlock()
+
+	if err == nil && length > 0 && resp.StatusCode != http.StatusPartialContent {
+		// the server ignored the range, sending the whole resource.
+		if offset+length > int64(len(body)) {
+			return nil, fmt.Errorf("get %s: byte range %s beyond the %d bytes sent", url, byteRange, len(body))
+		}
+		body = body[offset : offset+length]
+	}
+	return body, err
+}
+
+func (p *Parser) written(n int, duration float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.BytesWritten += int64(n)
+	p.stats.StreamTime += time.Duration(duration * float64(time.Second))
+	p.stats.LastDataTime = time.Now()
+}
@@ -0,0 +1,234 @@
+package hls_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/foundation/hls"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	const media = `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-MEDIA-SEQUENCE:7
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x000102030405060708090a0b0c0d0e0f
+#EXTINF:2.000,
+seg7.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:1.500,
+seg8.m4s
+`
+	base, _ := url.Parse("https://example.com/live/index.m3u8")
+	pl, err := hls.ParsePlaylist(strings.NewReader(media), base)
+	if err != nil {
+		t.Fatalf("Should be able to parse the media playlist: %s", err)
+	}
+	if pl.IsMaster() || pl.TargetDuration != 2 || len(pl.Segments) != 2 {
+		t.Fatalf("Should get 2 segments, got %+v", pl)
+	}
+
+	seg := pl.Segments[1]
+	if seg.Sequence != 8 || !seg.Discontinuity || seg.Duration != 1.5 {
+		t.Errorf("Should get the second segment right, got %+v", seg)
+	}
+	if seg.URI != "https://example.com/live/seg8.m4s" {
+		t.Errorf("Should resolve the segment uri, got %s", seg.URI)
+	}
+	if seg.Map == nil || seg.Map.URI != "https://example.com/live/init.mp4" {
+		t.Errorf("Should carry the init segment, got %+v", seg.Map)
+	}
+	if seg.Key == nil || seg.Key.Method != "AES-128" || seg.Key.IV != "0x000102030405060708090a0b0c0d0e0f" {
+		t.Errorf("Should carry the key, got %+v", seg.Key)
+	}
+
+	const master = `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2"
+high/index.m3u8
+`
+	pl, err = hls.ParsePlaylist(strings.NewReader(master), base)
+	if err != nil {
+		t.Fatalf("Should be able to parse the master playlist: %s", err)
+	}
+	if !pl.IsMaster() || len(pl.Variants) != 2 || pl.Variants[1].Bandwidth != 2800000 {
+		t.Fatalf("Should get 2 variants, got %+v", pl.Variants)
+	}
+	if pl.Variants[1].URI != "https://example.com/live/high/index.m3u8" {
+		t.Errorf("Should resolve the variant uri, got %s", pl.Variants[1].URI)
+	}
+}
+
+func TestParser_Parse(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	// the playlist slides one segment forward on every request, the last
+	// window ends the stream.
+	windows := [][]int{{0, 1}, {1, 2}, {1, 2}, {2, 3}}
+	var mu sync.Mutex
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/index.m3u8":
+			mu.Lock()
+			win := windows[requests]
+			if requests < len(windows)-1 {
+				requests++
+			}
+			mu.Unlock()
+
+			fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:0\n#EXT-X-MEDIA-SEQUENCE:%d\n", win[0])
+			fmt.Fprint(w, "#EXT-X-KEY:METHOD=AES-128,URI=\"/key\"\n")
+			for _, seq := range win {
+				fmt.Fprintf(w, "#EXTINF:1.0,\n/seg%d.ts\n", seq)
+			}
+			if win[1] == 3 {
+				fmt.Fprint(w, "#EXT-X-ENDLIST\n")
+			}
+		case r.URL.Path == "/key":
+			w.Write(key)
+		case strings.HasPrefix(r.URL.Path, "/seg"):
+			var seq uint64
+			fmt.Sscanf(r.URL.Path, "/seg%d.ts", &seq)
+			w.Write(encrypt(t, key, seq, []byte(fmt.Sprintf("segment-%d;", seq))))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	out := filepath.Join(t.TempDir(), "out.ts")
+	p := hls.NewParser()
+	if err := p.Parse(ts.URL+"/index.m3u8", out); err != nil {
+		t.Fatalf("Should be able to parse the stream: %s", err)
+	}
+
+	got, err := os.ReadFile(p.Out())
+	if err != nil {
+		t.Fatalf("Should be able to read the output: %s", err)
+	}
+	const want = "segment-0;segment-1;segment-2;segment-3;"
+	if string(got) != want {
+		t.Errorf("Should write every segment once.\nGot: %s\nExp: %s", got, want)
+	}
+	if s := p.Stats(); s.BytesWritten != int64(len(want)) || s.StreamTime != 4*time.Second {
+		t.Errorf("Should count the segments written, got %+v", s)
+	}
+}
+
+func encrypt(t *testing.T, key []byte, seq uint64, plain []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	iv := make([]byte, aes.BlockSize)
+	iv[15] = byte(seq)
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, plain)
+	return data
+}
+
+func TestParsePlaylistByteRange(t *testing.T) {
+	const media = `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-BYTERANGE:100@0
+#EXTINF:2.000,
+main.ts
+#EXT-X-BYTERANGE:200
+#EXTINF:2.000,
+main.ts
+#EXTINF:2.000,
+other.ts
+#EXT-X-BYTERANGE:50@1000
+#EXTINF:2.000,
+main.ts
+#EXT-X-BYTERANGE:10
+#EXTINF:2.000,
+main.ts
+`
+	pl, err := hls.ParsePlaylist(strings.NewReader(media), nil)
+	if err != nil {
+		t.Fatalf("Should be able to parse the playlist: %s", err)
+	}
+	want := []string{"100@0", "200@100", "", "50@1000", "10@1050"}
+	if len(pl.Segments) != len(want) {
+		t.Fatalf("Should get %d segments, got %+v", len(want), pl.Segments)
+	}
+	for i, seg := range pl.Segments {
+		if seg.ByteRange != want[i] {
+			t.Errorf("Should get the byte range %q of segment %d, got %q", want[i], i, seg.ByteRange)
+		}
+	}
+
+	for _, invalid := range []string{
+		"#EXTM3U\n#EXT-X-BYTERANGE:abc\n#EXTINF:2,\nmain.ts\n",
+		"#EXTM3U\n#EXT-X-BYTERANGE:100\n#EXTINF:2,\nmain.ts\n",
+		"#EXTM3U\n#EXT-X-BYTERANGE:100@0\n#EXTINF:2,\na.ts\n#EXT-X-BYTERANGE:100\n#EXTINF:2,\nb.ts\n",
+	} {
+		if _, err := hls.ParsePlaylist(strings.NewReader(invalid), nil); err == nil {
+			t.Errorf("Should not be able to parse %q", invalid)
+		}
+	}
+}
+
+func TestParser_ParseByteRange(t *testing.T) {
+	const data = "segment-0;segment-1;segment-2;"
+	var mu sync.Mutex
+	var referer string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.m3u8":
+			mu.Lock()
+			referer = r.Header.Get("Referer")
+			mu.Unlock()
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n")
+			fmt.Fprint(w, "#EXT-X-BYTERANGE:10@0\n#EXTINF:1.0,\n/main.ts\n")
+			fmt.Fprint(w, "#EXT-X-BYTERANGE:10\n#EXTINF:1.0,\n/main.ts\n")
+			fmt.Fprint(w, "#EXT-X-BYTERANGE:10@20\n#EXTINF:1.0,\n/whole.ts\n")
+			fmt.Fprint(w, "#EXT-X-ENDLIST\n")
+		case "/main.ts":
+			http.ServeContent(w, r, "main.ts", time.Time{}, strings.NewReader(data))
+		case "/whole.ts":
+			// a server ignoring the range sends the whole resource.
+			w.Write([]byte(data))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	out := filepath.Join(t.TempDir(), "out.ts")
+	p := hls.NewParser()
+	p.SetHeader("Referer", "https://example.com/")
+	if err := p.Parse(ts.URL+"/index.m3u8", out); err != nil {
+		t.Fatalf("Should be able to parse the stream: %s", err)
+	}
+
+	got, err := os.ReadFile(p.Out())
+	if err != nil {
+		t.Fatalf("Should be able to read the output: %s", err)
+	}
+	if string(got) != data {
+		t.Errorf("Should write the sub-ranges of the segments.\nGot: %s\nExp: %s", got, data)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if referer != "https://example.com/" {
+		t.Errorf("Should send the headers set, got referer %q", referer)
+	}
+}
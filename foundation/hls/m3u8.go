@@ -0,0 +1,234 @@
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPlaylist = errors.New("invalid playlist")
+
+// Playlist is either a master playlist, listing Variants, or a media
+// playlist, listing Segments.
+type Playlist struct {
+	Variants []Variant
+
+	TargetDuration float64
+	MediaSequence  uint64
+	Segments       []Segment
+	EndList        bool
+}
+
+// IsMaster reports whether the playlist lists variant streams.
+func (p *Playlist) IsMaster() bool {
+	return len(p.Variants) > 0
+}
+
+type Variant struct {
+	URI        string
+	Bandwidth  int64
+	Resolution string
+}
+
+type Segment struct {
+	URI      string
+	Sequence uint64
+	Duration float64
+	// ByteRange is the "length@offset" sub-range of the resource holding
+	// the segment, empty for the whole resource.
+	ByteRange     string
+	Discontinuity bool
+	Key           *Key
+	Map           *Map
+}
+
+// Key is the EXT-X-KEY applying to a segment.
+type Key struct {
+	Method string
+	URI    string
+	IV     string
+}
+
+// Map is the EXT-X-MAP applying to a segment, i.e. the fMP4 init segment.
+type Map struct {
+	URI       string
+	ByteRange string
+}
+
+// ParsePlaylist parses a playlist, resolving every uri against base.
+func ParsePlaylist(r io.Reader, base *url.URL) (*Playlist, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	if !s.Scan() || strings.TrimSpace(strings.TrimPrefix(s.Text(), "\ufeff")) != "#EXTM3U" {
+		return nil, ErrInvalidPlaylist
+	}
+
+	var (
+		p             = new(Playlist)
+		key           *Key
+		initMap       *Map
+		duration      float64
+		discontinuity bool
+		variant       *Variant
+		seq           uint64
+		// rangeLen is the length of the EXT-X-BYTERANGE of the next segment,
+		// rangeOff its offset, -1 when it follows the previous sub-range.
+		rangeLen, rangeOff int64
+		// rangeURI and rangeEnd are the resource and end of the previous
+		// sub-range.
+		rangeURI string
+		rangeEnd int64
+	)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "#") {
+			uri, err := resolve(base, line)
+			if err != nil {
+				return nil, err
+			}
+			if variant != nil {
+				variant.URI = uri
+				p.Variants = append(p.Variants, *variant)
+				variant = nil
+				continue
+			}
+			var byteRange string
+			if rangeLen > 0 {
+				if rangeOff < 0 {
+					if uri != rangeURI {
+						return nil, fmt.Errorf("%w: byte range of %s without an offset", ErrInvalidPlaylist, uri)
+					}
+					rangeOff = rangeEnd
+				}
+				byteRange = fmt.Sprintf("%d@%d", rangeLen, rangeOff)
+				rangeURI, rangeEnd = uri, rangeOff+rangeLen
+			}
+			p.Segments = append(p.Segments, Segment{
+				URI:           uri,
+				Sequence:      p.MediaSequence + seq,
+				Duration:      duration,
+				ByteRange:     byteRange,
+				Discontinuity: discontinuity,
+				Key:           key,
+				Map:           initMap,
+			})
+			seq++
+			duration = 0
+			discontinuity = false
+			rangeLen = 0
+			continue
+		}
+
+		tag, value, _ := strings.Cut(line, ":")
+		switch tag {
+		case "#EXT-X-STREAM-INF":
+			attrs := parseAttributes(value)
+			bandwidth, _ := strconv.ParseInt(attrs["BANDWIDTH"], 10, 64)
+			variant = &Variant{
+				Bandwidth:  bandwidth,
+				Resolution: attrs["RESOLUTION"],
+			}
+		case "#EXT-X-TARGETDURATION":
+			p.TargetDuration, _ = strconv.ParseFloat(value, 64)
+		case "#EXT-X-MEDIA-SEQUENCE":
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: media sequence %q", ErrInvalidPlaylist, value)
+			}
+			p.MediaSequence = n
+		case "#EXTINF":
+			d, _, _ := strings.Cut(value, ",")
+			duration, _ = strconv.ParseFloat(d, 64)
+		case "#EXT-X-BYTERANGE":
+			// https://datatracker.ietf.org/doc/html/rfc8216#section-4.3.2.2
+			n, o, hasOff := strings.Cut(value, "@")
+			length, err := strconv.ParseInt(n, 10, 64)
+			if err != nil || length <= 0 {
+				return nil, fmt.Errorf("%w: byte range %q", ErrInvalidPlaylist, value)
+			}
+			offset := int64(-1)
+			if hasOff {
+				if offset, err = strconv.ParseInt(o, 10, 64); err != nil || offset < 0 {
+					return nil, fmt.Errorf("%w: byte range %q", ErrInvalidPlaylist, value)
+				}
+			}
+			rangeLen, rangeOff = length, offset
+		case "#EXT-X-DISCONTINUITY":
+			discontinuity = true
+		case "#EXT-X-ENDLIST":
+			p.EndList = true
+		case "#EXT-X-KEY":
+			attrs := parseAttributes(value)
+			if attrs["METHOD"] == "NONE" {
+				key = nil
+				continue
+			}
+			uri, err := resolve(base, attrs["URI"])
+			if err != nil {
+				return nil, err
+			}
+			key = &Key{
+				Method: attrs["METHOD"],
+				URI:    uri,
+				IV:     attrs["IV"],
+			}
+		case "#EXT-X-MAP":
+			attrs := parseAttributes(value)
+			uri, err := resolve(base, attrs["URI"])
+			if err != nil {
+				return nil, err
+			}
+			initMap = &Map{
+				URI:       uri,
+				ByteRange: attrs["BYTERANGE"],
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func resolve(base *url.URL, ref string) (string, error) {
+	if base == nil {
+		return ref, nil
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// parseAttributes parses an attribute list like `METHOD=AES-128,URI="k.key"`.
+func parseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for len(s) > 0 {
+		var k, v string
+		k, s, _ = strings.Cut(s, "=")
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				v, s = s[1:], ""
+			} else {
+				v, s = s[1:end+1], s[end+2:]
+			}
+			s = strings.TrimPrefix(s, ",")
+		} else {
+			v, s, _ = strings.Cut(s, ",")
+		}
+		attrs[strings.TrimSpace(k)] = v
+	}
+	return attrs
+}
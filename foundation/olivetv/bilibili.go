@@ -2,6 +2,7 @@ package olivetv
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-olive/olive/foundation/olivetv/model"
@@ -83,6 +84,12 @@ func (this *bilibili) setRoomOn() Option {
 		}
 
 		tv.roomName = titleInfo.Data.RoomInfo.Title
+		tv.category = titleInfo.Data.RoomInfo.AreaName
+		for _, tag := range strings.Split(titleInfo.Data.RoomInfo.Tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tv.tags = append(tv.tags, tag)
+			}
+		}
 		return nil
 	}
 }
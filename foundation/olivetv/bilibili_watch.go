@@ -0,0 +1,218 @@
+package olivetv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-olive/olive/foundation/olivetv/util"
+	jsoniter "github.com/json-iterator/go"
+	"golang.org/x/net/websocket"
+)
+
+// https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/live/message_stream.md
+const (
+	bilibiliWSURL    = "wss://broadcastlv.chat.bilibili.com/sub"
+	bilibiliWSOrigin = "https://live.bilibili.com"
+
+	bilibiliHeaderLen = 16
+
+	bilibiliVerPlain = 0
+	bilibiliVerZlib  = 2
+
+	bilibiliOpHeartbeat = 2
+	bilibiliOpMessage   = 5
+	bilibiliOpAuth      = 7
+
+	bilibiliHeartbeatInterval = 30 * time.Second
+)
+
+func (this *bilibili) Watch(tv *TV, stop <-chan struct{}) (<-chan LiveEvent, error) {
+	roomID, err := this.realRoomID(tv.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	wsURL, token := this.danmuInfo(roomID)
+	ws, err := websocket.Dial(wsURL, "", bilibiliWSOrigin)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, _ := jsoniter.Marshal(map[string]interface{}{
+		"uid":      0,
+		"roomid":   roomID,
+		"protover": bilibiliVerZlib,
+		"platform": "web",
+		"type":     2,
+		"key":      token,
+	})
+	if err := websocket.Message.Send(ws, this.packet(bilibiliOpAuth, auth)); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	events := make(chan LiveEvent)
+	done := make(chan struct{})
+
+	go func() {
+		t := time.NewTicker(bilibiliHeartbeatInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				ws.Close()
+				return
+			case <-done:
+				return
+			case <-t.C:
+				if err := websocket.Message.Send(ws, this.packet(bilibiliOpHeartbeat, nil)); err != nil {
+					ws.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(events)
+		defer close(done)
+		defer ws.Close()
+
+		for {
+			var msg []byte
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			for _, e := range this.liveEvents(msg) {
+				select {
+				case events <- e:
+				case <-stop:
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func (this *bilibili) realRoomID(roomID string) (int64, error) {
+	type roomInit struct {
+		Code int64 `json:"code"`
+		Data struct {
+			RoomID int64 `json:"room_id"`
+		}
+	}
+	resp := new(roomInit)
+	req := &util.HttpRequest{
+		URL:    "https://api.live.bilibili.com/room/v1/Room/room_init",
+		Method: "POST",
+		RequestData: map[string]interface{}{
+			"id": roomID,
+		},
+		ResponseData: resp,
+		ContentType:  "application/form-data",
+	}
+	if err := req.Send(); err != nil {
+		return 0, err
+	}
+	if resp.Code != 0 || resp.Data.RoomID == 0 {
+		return 0, fmt.Errorf("room(ID = %s) not found", roomID)
+	}
+	return resp.Data.RoomID, nil
+}
+
+// danmuInfo returns the websocket url and the auth token of the room,
+// falling back to the default server with an empty token.
+func (this *bilibili) danmuInfo(roomID int64) (string, string) {
+	type danmuInfo struct {
+		Code int64 `json:"code"`
+		Data struct {
+			Token    string `json:"token"`
+			HostList []struct {
+				Host    string `json:"host"`
+				WssPort int    `json:"wss_port"`
+			} `json:"host_list"`
+		} `json:"data"`
+	}
+	resp := new(danmuInfo)
+	req := &util.HttpRequest{
+		URL:          "https://api.live.bilibili.com/xlive/web-room/v1/index/getDanmuInfo?id=" + strconv.FormatInt(roomID, 10),
+		Method:       "GET",
+		ResponseData: resp,
+		ContentType:  "application/json",
+	}
+	if err := req.Send(); err != nil || resp.Code != 0 || len(resp.Data.HostList) == 0 {
+		return bilibiliWSURL, ""
+	}
+	host := resp.Data.HostList[0]
+	return fmt.Sprintf("wss://%s:%d/sub", host.Host, host.WssPort), resp.Data.Token
+}
+
+func (this *bilibili) packet(op uint32, body []byte) []byte {
+	buf := make([]byte, bilibiliHeaderLen, bilibiliHeaderLen+len(body))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(bilibiliHeaderLen+len(body)))
+	binary.BigEndian.PutUint16(buf[4:6], bilibiliHeaderLen)
+	binary.BigEndian.PutUint16(buf[6:8], 1)
+	binary.BigEndian.PutUint32(buf[8:12], op)
+	binary.BigEndian.PutUint32(buf[12:16], 1)
+	return append(buf, body...)
+}
+
+// liveEvents extracts the live status changes out of a websocket message,
+// which may contain several packets.
+func (this *bilibili) liveEvents(msg []byte) []LiveEvent {
+	var events []LiveEvent
+	for len(msg) >= bilibiliHeaderLen {
+		packetLen := binary.BigEndian.Uint32(msg[0:4])
+		headerLen := binary.BigEndian.Uint16(msg[4:6])
+		ver := binary.BigEndian.Uint16(msg[6:8])
+		op := binary.BigEndian.Uint32(msg[8:12])
+		if packetLen < uint32(headerLen) || int(packetLen) > len(msg) {
+			break
+		}
+		body := msg[headerLen:packetLen]
+		msg = msg[packetLen:]
+
+		if op != bilibiliOpMessage {
+			continue
+		}
+		switch ver {
+		case bilibiliVerZlib:
+			r, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				continue
+			}
+			inflated, err := io.ReadAll(r)
+			r.Close()
+			if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+				continue
+			}
+			events = append(events, this.liveEvents(inflated)...)
+		case bilibiliVerPlain:
+			if e, ok := this.liveEvent(body); ok {
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+func (this *bilibili) liveEvent(body []byte) (LiveEvent, bool) {
+	cmd := jsoniter.Get(body, "cmd").ToString()
+	cmd = strings.SplitN(cmd, ":", 2)[0]
+	switch cmd {
+	case "LIVE":
+		return LiveEvent{RoomOn: true, Timestamp: time.Now().Unix()}, true
+	case "PREPARING":
+		return LiveEvent{RoomOn: false, Timestamp: time.Now().Unix()}, true
+	}
+	return LiveEvent{}, false
+}
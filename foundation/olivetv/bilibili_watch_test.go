@@ -0,0 +1,81 @@
+package olivetv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+)
+
+func TestBilibiliPacket(t *testing.T) {
+	got := new(bilibili).packet(bilibiliOpAuth, []byte("{}"))
+	want := []byte{
+		0x00, 0x00, 0x00, 0x12, // packet length
+		0x00, 0x10, // header length
+		0x00, 0x01, // version
+		0x00, 0x00, 0x00, 0x07, // op
+		0x00, 0x00, 0x00, 0x01, // sequence
+		'{', '}',
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+// frame returns a packet of the version and op as sent by the server.
+func frame(ver uint16, op uint32, body string) []byte {
+	b := new(bilibili).packet(op, []byte(body))
+	binary.BigEndian.PutUint16(b[6:8], ver)
+	return b
+}
+
+func deflate(t *testing.T, b []byte) string {
+	buf := new(bytes.Buffer)
+	w := zlib.NewWriter(buf)
+	if _, err := w.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestBilibiliLiveEvents(t *testing.T) {
+	live := frame(bilibiliVerPlain, bilibiliOpMessage, `{"cmd":"LIVE","roomid":1}`)
+	preparing := frame(bilibiliVerPlain, bilibiliOpMessage, `{"cmd":"PREPARING","roomid":"1"}`)
+	danmu := frame(bilibiliVerPlain, bilibiliOpMessage, `{"cmd":"DANMU_MSG:4:0:2:2:2:0","info":[]}`)
+	popularity := frame(1, 3, "\x00\x00\x00\x01")
+
+	join := func(packets ...[]byte) []byte {
+		return bytes.Join(packets, nil)
+	}
+	tests := []struct {
+		name string
+		msg  []byte
+		want []bool
+	}{
+		{"live", live, []bool{true}},
+		{"preparing", preparing, []bool{false}},
+		{"other messages", join(popularity, danmu), nil},
+		{"several packets", join(popularity, preparing, danmu, live), []bool{false, true}},
+		{"zlib", frame(bilibiliVerZlib, bilibiliOpMessage, deflate(t, join(danmu, live, danmu))), []bool{true}},
+		{"zlib and plain", join(frame(bilibiliVerZlib, bilibiliOpMessage, deflate(t, preparing)), live), []bool{false, true}},
+		{"bad zlib", frame(bilibiliVerZlib, bilibiliOpMessage, "not zlib"), nil},
+		{"truncated", join(live, preparing[:len(preparing)-1]), []bool{true}},
+		{"bad length", join(frame(bilibiliVerPlain, bilibiliOpMessage, "")[:8], live), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := new(bilibili).liveEvents(tt.msg)
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %+v, want room on %v", events, tt.want)
+			}
+			for i, e := range events {
+				if e.RoomOn != tt.want[i] {
+					t.Errorf("got %+v, want room on %v", events, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -140,6 +140,9 @@ func (this *huya) setRoomOn() Option {
 		if len(titleRes) > 0 {
 			tv.roomName = titleRes[0]
 		}
+		if category, err := util.Match(`"gameFullName":"([^"]+)"`, resp); err == nil {
+			tv.category = category
+		}
 
 		return nil
 	}
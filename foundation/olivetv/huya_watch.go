@@ -0,0 +1,131 @@
+package olivetv
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/go-olive/olive/foundation/olivetv/util"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	huyaWSURL    = "wss://cdnws.api.huya.com"
+	huyaWSOrigin = "https://www.huya.com"
+
+	huyaCmdHeartbeat     = 5
+	huyaCmdMsgPush       = 7
+	huyaCmdRegisterGroup = 16
+
+	huyaURIBeginLive = 8000
+	huyaURIEndLive   = 8001
+
+	huyaHeartbeatInterval = 30 * time.Second
+)
+
+var huyaPresenterUIDRe = regexp.MustCompile(`"lp":"?(\d+)`)
+
+func (this *huya) Watch(tv *TV, stop <-chan struct{}) (<-chan LiveEvent, error) {
+	uid, err := this.presenterUID(tv.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := websocket.Dial(huyaWSURL, "", huyaWSOrigin)
+	if err != nil {
+		return nil, err
+	}
+
+	req := new(tarsWriter)
+	req.writeStrings(0, []string{"live:" + uid, "chat:" + uid})
+	req.writeString(1, "")
+	if err := websocket.Message.Send(ws, this.command(huyaCmdRegisterGroup, req.Bytes())); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	events := make(chan LiveEvent)
+	done := make(chan struct{})
+
+	go func() {
+		t := time.NewTicker(huyaHeartbeatInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				ws.Close()
+				return
+			case <-done:
+				return
+			case <-t.C:
+				if err := websocket.Message.Send(ws, this.command(huyaCmdHeartbeat, nil)); err != nil {
+					ws.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(events)
+		defer close(done)
+		defer ws.Close()
+
+		for {
+			var msg []byte
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			e, ok := this.liveEvent(msg)
+			if !ok {
+				continue
+			}
+			select {
+			case events <- e:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func (this *huya) presenterUID(roomID string) (string, error) {
+	content, err := util.GetURLContent(fmt.Sprintf("https://www.huya.com/%s", roomID))
+	if err != nil {
+		return "", err
+	}
+	res := huyaPresenterUIDRe.FindStringSubmatch(content)
+	if len(res) < 2 || res[1] == "0" {
+		return "", fmt.Errorf("presenter of room(ID = %s) not found", roomID)
+	}
+	return res[1], nil
+}
+
+// command wraps data in a WebSocketCommand.
+func (this *huya) command(cmdType int64, data []byte) []byte {
+	w := new(tarsWriter)
+	w.writeInt(0, cmdType)
+	w.writeBytes(1, data)
+	return w.Bytes()
+}
+
+func (this *huya) liveEvent(msg []byte) (LiveEvent, bool) {
+	ints, lists, err := newTarsReader(msg).fields()
+	if err != nil || ints[0] != huyaCmdMsgPush {
+		return LiveEvent{}, false
+	}
+	// WSPushMessage
+	ints, _, err = newTarsReader(lists[1]).fields()
+	if err != nil {
+		return LiveEvent{}, false
+	}
+	switch ints[1] {
+	case huyaURIBeginLive:
+		return LiveEvent{RoomOn: true, Timestamp: time.Now().Unix()}, true
+	case huyaURIEndLive:
+		return LiveEvent{RoomOn: false, Timestamp: time.Now().Unix()}, true
+	}
+	return LiveEvent{}, false
+}
@@ -0,0 +1,51 @@
+package olivetv
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHuyaCommand(t *testing.T) {
+	got := new(huya).command(huyaCmdHeartbeat, nil)
+	want := []byte{0x00, 0x05, 0x1d, 0x00, 0x0c}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+// pushMessage returns a WSPushMessage of uri pushed as a WebSocketCommand.
+func pushMessage(uri int64) []byte {
+	w := new(tarsWriter)
+	w.writeInt(0, 0)
+	w.writeInt(1, uri)
+	w.writeBytes(2, []byte("notice"))
+	w.writeInt(3, 0)
+	return new(huya).command(huyaCmdMsgPush, w.Bytes())
+}
+
+func TestHuyaLiveEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    []byte
+		ok     bool
+		roomOn bool
+	}{
+		{"begin live", pushMessage(huyaURIBeginLive), true, true},
+		{"end live", pushMessage(huyaURIEndLive), true, false},
+		{"other notice", pushMessage(1400), false, false},
+		{"heartbeat", new(huya).command(huyaCmdHeartbeat, nil), false, false},
+		{"truncated", pushMessage(huyaURIBeginLive)[:6], false, false},
+		{"empty", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := new(huya).liveEvent(tt.msg)
+			if ok != tt.ok || e.RoomOn != tt.roomOn {
+				t.Errorf("got %+v, %t, want room on %t, %t", e, ok, tt.roomOn, tt.ok)
+			}
+			if ok && e.Timestamp == 0 {
+				t.Error("event without a timestamp")
+			}
+		})
+	}
+}
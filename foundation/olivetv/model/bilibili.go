@@ -62,7 +62,9 @@ type BilibiliAutoGenerated struct {
 type BilibiliRoomTitle struct {
 	Data struct {
 		RoomInfo struct {
-			Title string `json:"title"`
+			Title    string `json:"title"`
+			AreaName string `json:"area_name"`
+			Tags     string `json:"tags"`
 		} `json:"room_info"`
 	} `json:"data"`
 }
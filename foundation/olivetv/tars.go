@@ -0,0 +1,249 @@
+package olivetv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// A minimal tars codec, covering just what the huya websocket needs.
+const (
+	tarsInt8 byte = iota
+	tarsInt16
+	tarsInt32
+	tarsInt64
+	tarsFloat
+	tarsDouble
+	tarsString1
+	tarsString4
+	tarsMap
+	tarsList
+	tarsStructBegin
+	tarsStructEnd
+	tarsZero
+	tarsSimpleList
+)
+
+var errTarsType = errors.New("tars: unexpected type")
+
+type tarsWriter struct {
+	bytes.Buffer
+}
+
+func (w *tarsWriter) head(tag, typ byte) {
+	if tag < 15 {
+		w.WriteByte(tag<<4 | typ)
+		return
+	}
+	w.WriteByte(0xf0 | typ)
+	w.WriteByte(tag)
+}
+
+func (w *tarsWriter) writeInt(tag byte, v int64) {
+	switch {
+	case v == 0:
+		w.head(tag, tarsZero)
+	case v >= -128 && v <= 127:
+		w.head(tag, tarsInt8)
+		w.WriteByte(byte(v))
+	case v >= -32768 && v <= 32767:
+		w.head(tag, tarsInt16)
+		binary.Write(w, binary.BigEndian, int16(v))
+	case v >= -2147483648 && v <= 2147483647:
+		w.head(tag, tarsInt32)
+		binary.Write(w, binary.BigEndian, int32(v))
+	default:
+		w.head(tag, tarsInt64)
+		binary.Write(w, binary.BigEndian, v)
+	}
+}
+
+func (w *tarsWriter) writeString(tag byte, s string) {
+	if len(s) <= 255 {
+		w.head(tag, tarsString1)
+		w.WriteByte(byte(len(s)))
+	} else {
+		w.head(tag, tarsString4)
+		binary.Write(w, binary.BigEndian, uint32(len(s)))
+	}
+	w.WriteString(s)
+}
+
+func (w *tarsWriter) writeStrings(tag byte, ss []string) {
+	w.head(tag, tarsList)
+	w.writeInt(0, int64(len(ss)))
+	for _, s := range ss {
+		w.writeString(0, s)
+	}
+}
+
+func (w *tarsWriter) writeBytes(tag byte, b []byte) {
+	w.head(tag, tarsSimpleList)
+	w.head(0, tarsInt8)
+	w.writeInt(0, int64(len(b)))
+	w.Write(b)
+}
+
+type tarsReader struct {
+	r *bytes.Reader
+}
+
+func newTarsReader(b []byte) *tarsReader {
+	return &tarsReader{r: bytes.NewReader(b)}
+}
+
+func (r *tarsReader) head() (tag, typ byte, err error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	tag, typ = b>>4, b&0x0f
+	if tag == 15 {
+		tag, err = r.r.ReadByte()
+	}
+	return tag, typ, err
+}
+
+// fields reads the top-level fields of a struct, keeping the ints and byte lists
+// by tag and skipping everything else.
+func (r *tarsReader) fields() (ints map[byte]int64, lists map[byte][]byte, err error) {
+	ints = make(map[byte]int64)
+	lists = make(map[byte][]byte)
+	for {
+		tag, typ, err := r.head()
+		if err == io.EOF {
+			return ints, lists, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		switch typ {
+		case tarsInt8, tarsInt16, tarsInt32, tarsInt64, tarsZero:
+			if ints[tag], err = r.int(typ); err != nil {
+				return nil, nil, err
+			}
+		case tarsSimpleList:
+			if lists[tag], err = r.simpleList(); err != nil {
+				return nil, nil, err
+			}
+		case tarsStructEnd:
+			return ints, lists, nil
+		default:
+			if err := r.skip(typ); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+func (r *tarsReader) int(typ byte) (int64, error) {
+	switch typ {
+	case tarsZero:
+		return 0, nil
+	case tarsInt8:
+		var v int8
+		err := binary.Read(r.r, binary.BigEndian, &v)
+		return int64(v), err
+	case tarsInt16:
+		var v int16
+		err := binary.Read(r.r, binary.BigEndian, &v)
+		return int64(v), err
+	case tarsInt32:
+		var v int32
+		err := binary.Read(r.r, binary.BigEndian, &v)
+		return int64(v), err
+	case tarsInt64:
+		var v int64
+		err := binary.Read(r.r, binary.BigEndian, &v)
+		return v, err
+	}
+	return 0, errTarsType
+}
+
+func (r *tarsReader) length() (int64, error) {
+	_, typ, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.int(typ)
+	if err == nil && n < 0 {
+		err = errTarsType
+	}
+	return n, err
+}
+
+func (r *tarsReader) simpleList() ([]byte, error) {
+	if _, _, err := r.head(); err != nil {
+		return nil, err
+	}
+	n, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(r.r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r.r, b)
+	return b, err
+}
+
+func (r *tarsReader) discard(n int64) error {
+	if n > int64(r.r.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := r.r.Seek(n, io.SeekCurrent)
+	return err
+}
+
+func (r *tarsReader) skip(typ byte) error {
+	switch typ {
+	case tarsInt8, tarsInt16, tarsInt32, tarsInt64, tarsZero:
+		_, err := r.int(typ)
+		return err
+	case tarsFloat:
+		return r.discard(4)
+	case tarsDouble:
+		return r.discard(8)
+	case tarsString1:
+		n, err := r.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		return r.discard(int64(n))
+	case tarsString4:
+		var n uint32
+		if err := binary.Read(r.r, binary.BigEndian, &n); err != nil {
+			return err
+		}
+		return r.discard(int64(n))
+	case tarsMap, tarsList:
+		n, err := r.length()
+		if err != nil {
+			return err
+		}
+		if typ == tarsMap {
+			n *= 2
+		}
+		for i := int64(0); i < n; i++ {
+			_, elemTyp, err := r.head()
+			if err != nil {
+				return err
+			}
+			if err := r.skip(elemTyp); err != nil {
+				return err
+			}
+		}
+		return nil
+	case tarsSimpleList:
+		_, err := r.simpleList()
+		return err
+	case tarsStructBegin:
+		_, _, err := r.fields()
+		return err
+	case tarsStructEnd:
+		return nil
+	}
+	return errTarsType
+}
@@ -0,0 +1,87 @@
+package olivetv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestTarsWriteInt(t *testing.T) {
+	tests := []struct {
+		tag  byte
+		v    int64
+		want []byte
+	}{
+		{0, 0, []byte{0x0c}},
+		{1, 5, []byte{0x10, 0x05}},
+		{2, -1, []byte{0x20, 0xff}},
+		{3, 300, []byte{0x31, 0x01, 0x2c}},
+		{4, -70000, []byte{0x42, 0xff, 0xfe, 0xee, 0x90}},
+		{5, 1 << 40, []byte{0x53, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{20, 300, []byte{0xf1, 0x14, 0x01, 0x2c}},
+	}
+	for _, tt := range tests {
+		w := new(tarsWriter)
+		w.writeInt(tt.tag, tt.v)
+		if !bytes.Equal(w.Bytes(), tt.want) {
+			t.Errorf("writeInt(%d, %d) = % x, want % x", tt.tag, tt.v, w.Bytes(), tt.want)
+		}
+	}
+}
+
+func TestTarsFields(t *testing.T) {
+	w := new(tarsWriter)
+	w.writeInt(0, 7)
+	w.writeInt(1, 0)
+	w.writeInt(2, -70000)
+	w.writeInt(20, 1<<40)
+	w.writeString(3, "live:1")
+	w.writeStrings(4, []string{"live:1", "chat:1"})
+	w.writeBytes(5, []byte("data"))
+	// a float, a map of a string to an int and a nested struct, all skipped.
+	w.head(6, tarsFloat)
+	w.Write([]byte{0x3f, 0x80, 0x00, 0x00})
+	w.head(7, tarsMap)
+	w.writeInt(0, 1)
+	w.writeString(0, "k")
+	w.writeInt(1, 300)
+	w.head(8, tarsStructBegin)
+	w.writeInt(0, 99)
+	w.writeString(1, "nested")
+	w.head(0, tarsStructEnd)
+	w.writeInt(9, 300)
+
+	ints, lists, err := newTarsReader(w.Bytes()).fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantInts := map[byte]int64{0: 7, 1: 0, 2: -70000, 20: 1 << 40, 9: 300}
+	if len(ints) != len(wantInts) {
+		t.Errorf("got ints %v, want %v", ints, wantInts)
+	}
+	for tag, v := range wantInts {
+		if ints[tag] != v {
+			t.Errorf("got int[%d] = %d, want %d", tag, ints[tag], v)
+		}
+	}
+	if len(lists) != 1 || string(lists[5]) != "data" {
+		t.Errorf("got lists %q, want the data at 5", lists)
+	}
+}
+
+func TestTarsFieldsTruncated(t *testing.T) {
+	w := new(tarsWriter)
+	w.writeBytes(0, []byte("data"))
+	b := w.Bytes()
+
+	_, _, err := newTarsReader(b[:len(b)-1]).fields()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want unexpected EOF", err)
+	}
+
+	_, _, err = newTarsReader([]byte{0x0e}).fields()
+	if !errors.Is(err, errTarsType) {
+		t.Errorf("got %v, want an unexpected type", err)
+	}
+}
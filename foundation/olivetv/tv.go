@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/go-olive/olive/foundation/olivetv/util"
 	"golang.org/x/net/publicsuffix"
 )
 
@@ -20,8 +21,20 @@ var (
 
 	ErrNotSupported = errors.New("streamer not supported")
 	ErrSiteInvalid  = errors.New("site invalid")
+	ErrRateLimited  = util.ErrRateLimited
 )
 
+// roomURLFormats are the room page urls of the sites, given the room ID.
+var roomURLFormats = map[string]string{
+	"bilibili": "https://live.bilibili.com/%s",
+	"douyin":   "https://live.douyin.com/%s",
+	"huya":     "https://www.huya.com/%s",
+	"kuaishou": "https://live.kuaishou.com/u/%s",
+	"tiktok":   "https://www.tiktok.com/@%s/live",
+	"twitch":   "https://www.twitch.tv/%s",
+	"youtube":  "https://www.youtube.com/channel/%s/live",
+}
+
 type ITV interface {
 	Snap() error
 	StreamURL() (string, bool)
@@ -85,6 +98,9 @@ type Info struct {
 	roomOn       bool
 	roomName     string
 	streamerName string
+	// category and tags are set by the sites which expose them.
+	category string
+	tags     []string
 }
 
 // Snap takes the latest snapshot of the streamer info that could be retrieved individually.
@@ -137,6 +153,22 @@ func (tv *TV) RoomName() (string, bool) {
 	return tv.roomName, tv.roomName != EmptyRoomName
 }
 
+// Category returns the category, or game, the room streams in.
+func (tv *TV) Category() (string, bool) {
+	if tv == nil || tv.Info == nil {
+		return "", false
+	}
+	return tv.category, tv.category != ""
+}
+
+// Tags returns the tags the streamer set on the room.
+func (tv *TV) Tags() []string {
+	if tv == nil || tv.Info == nil {
+		return nil
+	}
+	return tv.tags
+}
+
 func (tv *TV) StreamerName() (string, bool) {
 	if tv == nil || tv.Info == nil {
 		return "", false
@@ -144,6 +176,18 @@ func (tv *TV) StreamerName() (string, bool) {
 	return tv.streamerName, tv.streamerName != EmptyStreamerName
 }
 
+// RoomURL returns the room page url, or an empty string if the site is unknown.
+func (tv *TV) RoomURL() string {
+	if tv == nil {
+		return ""
+	}
+	format, ok := roomURLFormats[tv.SiteID]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(format, tv.RoomID)
+}
+
 func (tv *TV) String() string {
 	sb := &strings.Builder{}
 	sb.WriteString("Powered by go-olive/olive\n")
@@ -153,6 +197,12 @@ func (tv *TV) String() string {
 	if roomName, ok := tv.RoomName(); ok {
 		sb.WriteString(format("RoomName", roomName))
 	}
+	if category, ok := tv.Category(); ok {
+		sb.WriteString(format("Category", category))
+	}
+	if tags := tv.Tags(); len(tags) > 0 {
+		sb.WriteString(format("Tags", strings.Join(tags, ",")))
+	}
 	if streamerName, ok := tv.StreamerName(); ok {
 		sb.WriteString(format("Streamer", streamerName))
 	}
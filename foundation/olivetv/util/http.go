@@ -3,6 +3,7 @@ package util
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -24,6 +25,9 @@ var client = &http.Client{
 	// },
 }
 
+// ErrRateLimited is returned when the site answers 429 Too Many Requests.
+var ErrRateLimited = errors.New("rate limited")
+
 type HttpRequest struct {
 	URL          string
 	Method       string
@@ -54,6 +58,9 @@ func (this *HttpRequest) Send() error {
 		return fmt.Errorf("send http request failed: %s", err.Error())
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
 
 	switch this.ResponseData.(type) {
 	case string:
@@ -0,0 +1,38 @@
+package olivetv
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrWatchNotSupported = errors.New("watch not supported")
+
+// LiveEvent is a live status change pushed by a site.
+type LiveEvent struct {
+	RoomOn    bool
+	Timestamp int64
+}
+
+// Watcher is implemented by sites that push live status changes through a
+// long-lived connection, e.g. a live-message websocket.
+type Watcher interface {
+	Watch(tv *TV, stop <-chan struct{}) (<-chan LiveEvent, error)
+}
+
+// Watch subscribes to the live status changes of the room. The returned
+// channel is closed once the underlying connection drops or stop is closed,
+// callers are expected to call Watch again to reconnect.
+func (tv *TV) Watch(stop <-chan struct{}) (<-chan LiveEvent, error) {
+	if tv == nil {
+		return nil, errors.New("tv is nil")
+	}
+	site, ok := Sniff(tv.SiteID)
+	if !ok {
+		return nil, fmt.Errorf("site(ID = %s) not supported", tv.SiteID)
+	}
+	w, ok := site.(Watcher)
+	if !ok {
+		return nil, ErrWatchNotSupported
+	}
+	return w.Watch(tv, stop)
+}
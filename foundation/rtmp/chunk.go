@@ -0,0 +1,251 @@
+package rtmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Set of message types, see https://rtmp.veriskope.com/docs/spec/
+const (
+	msgSetChunkSize     = 1
+	msgAck              = 3
+	msgUserControl      = 4
+	msgWindowAckSize    = 5
+	msgSetPeerBandwidth = 6
+	msgAudio            = 8
+	msgVideo            = 9
+	msgDataAMF0         = 18
+	msgCommandAMF0      = 20
+)
+
+// Set of chunk stream IDs used for the outgoing messages.
+const (
+	csidControl = 2
+	csidCommand = 3
+	csidAudio   = 4
+	csidData    = 5
+	csidVideo   = 6
+)
+
+const (
+	defaultChunkSize = 128
+	maxTimestamp     = 0xffffff
+)
+
+type message struct {
+	typ       byte
+	streamID  uint32
+	timestamp uint32
+	payload   []byte
+}
+
+// chunkWriter splits the messages into chunks, every message starting with
+// a full header.
+type chunkWriter struct {
+	w         *bufio.Writer
+	chunkSize int
+}
+
+func (cw *chunkWriter) write(csid byte, m *message) error {
+	ext := m.timestamp >= maxTimestamp
+	ts := m.timestamp
+	if ext {
+		ts = maxTimestamp
+	}
+	n := len(m.payload)
+
+	header := make([]byte, 12, 16)
+	header[0] = csid & 0x3f
+	header[1], header[2], header[3] = byte(ts>>16), byte(ts>>8), byte(ts)
+	header[4], header[5], header[6] = byte(n>>16), byte(n>>8), byte(n)
+	header[7] = m.typ
+	binary.LittleEndian.PutUint32(header[8:], m.streamID)
+	if ext {
+		header = appendUint32(header, m.timestamp)
+	}
+	if _, err := cw.w.Write(header); err != nil {
+		return err
+	}
+
+	payload := m.payload
+	for {
+		size := len(payload)
+		if size > cw.chunkSize {
+			size = cw.chunkSize
+		}
+		if _, err := cw.w.Write(payload[:size]); err != nil {
+			return err
+		}
+		payload = payload[size:]
+		if len(payload) == 0 {
+			break
+		}
+
+		// continuation chunks only carry the basic header.
+		cont := []byte{0xc0 | csid&0x3f}
+		if ext {
+			cont = appendUint32(cont, m.timestamp)
+		}
+		if _, err := cw.w.Write(cont); err != nil {
+			return err
+		}
+	}
+	return cw.w.Flush()
+}
+
+// chunkStream is the state of an incoming chunk stream.
+type chunkStream struct {
+	timestamp uint32
+	delta     uint32
+	length    uint32
+	typ       byte
+	streamID  uint32
+	ext       bool
+
+	payload []byte
+}
+
+// chunkReader reassembles the incoming chunks into messages.
+type chunkReader struct {
+	r         *bufio.Reader
+	chunkSize uint32
+	streams   map[uint32]*chunkStream
+}
+
+func newChunkReader(r *bufio.Reader) *chunkReader {
+	return &chunkReader{
+		r:         r,
+		chunkSize: defaultChunkSize,
+		streams:   make(map[uint32]*chunkStream),
+	}
+}
+
+func (cr *chunkReader) read() (*message, error) {
+	for {
+		m, err := cr.readChunk()
+		if err != nil || m != nil {
+			return m, err
+		}
+	}
+}
+
+// readChunk reads a single chunk and returns the message it completes, if any.
+func (cr *chunkReader) readChunk() (*message, error) {
+	b, err := cr.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	format := b >> 6
+	csid := uint32(b & 0x3f)
+	switch csid {
+	case 0:
+		b, err := cr.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		csid = 64 + uint32(b)
+	case 1:
+		var b [2]byte
+		if _, err := io.ReadFull(cr.r, b[:]); err != nil {
+			return nil, err
+		}
+		csid = 64 + uint32(b[0]) + uint32(b[1])*256
+	}
+
+	cs, ok := cr.streams[csid]
+	if !ok {
+		cs = new(chunkStream)
+		cr.streams[csid] = cs
+	}
+	starting := len(cs.payload) == 0
+
+	var header [11]byte
+	switch format {
+	case 0:
+		if _, err := io.ReadFull(cr.r, header[:11]); err != nil {
+			return nil, err
+		}
+		cs.length = uint24(header[3:6])
+		cs.typ = header[6]
+		cs.streamID = binary.LittleEndian.Uint32(header[7:11])
+	case 1:
+		if _, err := io.ReadFull(cr.r, header[:7]); err != nil {
+			return nil, err
+		}
+		cs.length = uint24(header[3:6])
+		cs.typ = header[6]
+	case 2:
+		if _, err := io.ReadFull(cr.r, header[:3]); err != nil {
+			return nil, err
+		}
+	}
+
+	if format < 3 {
+		ts := uint24(header[:3])
+		cs.ext = ts == maxTimestamp
+		if cs.ext {
+			if ts, err = cr.readUint32(); err != nil {
+				return nil, err
+			}
+		}
+		if format == 0 {
+			cs.timestamp = ts
+			cs.delta = 0
+		} else {
+			cs.delta = ts
+			cs.timestamp += ts
+		}
+	} else {
+		if cs.ext {
+			if _, err := cr.readUint32(); err != nil {
+				return nil, err
+			}
+		}
+		if starting {
+			cs.timestamp += cs.delta
+		}
+	}
+
+	size := cs.length - uint32(len(cs.payload))
+	if size > cr.chunkSize {
+		size = cr.chunkSize
+	}
+	chunk := make([]byte, size)
+	if _, err := io.ReadFull(cr.r, chunk); err != nil {
+		return nil, err
+	}
+	cs.payload = append(cs.payload, chunk...)
+	if uint32(len(cs.payload)) < cs.length {
+		return nil, nil
+	}
+
+	m := &message{
+		typ:       cs.typ,
+		streamID:  cs.streamID,
+		timestamp: cs.timestamp,
+		payload:   cs.payload,
+	}
+	cs.payload = nil
+
+	if m.typ == msgSetChunkSize && len(m.payload) >= 4 {
+		cr.chunkSize = binary.BigEndian.Uint32(m.payload) & 0x7fffffff
+	}
+	return m, nil
+}
+
+func (cr *chunkReader) readUint32() (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(cr.r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -0,0 +1,340 @@
+// Package rtmp provides a minimal RTMP client publishing flv tags to a media
+// server.
+package rtmp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/foundation/amf"
+)
+
+const (
+	handshakeSize = 1536
+	chunkSize     = 4096
+	writeTimeout  = 10 * time.Second
+
+	// flv tag types.
+	tagAudio  = 8
+	tagVideo  = 9
+	tagScript = 18
+)
+
+var (
+	ErrInvalidURL = errors.New("invalid rtmp url")
+	ErrRejected   = errors.New("rejected by the server")
+)
+
+// Publisher publishes a stream to a RTMP server.
+type Publisher struct {
+	conn     net.Conn
+	cr       *chunkReader
+	streamID uint32
+	txID     int
+
+	mu sync.Mutex
+	cw *chunkWriter
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// Dial connects to the RTMP url, e.g. rtmp://host/app/key, and starts
+// publishing the stream named after the last path segment.
+func Dial(rawURL string, timeout time.Duration) (*Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return nil, fmt.Errorf("%w: %s misses the app or the stream key", ErrInvalidURL, rawURL)
+	}
+	app, key := path[:i], path[i+1:]
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "rtmp":
+		conn, err = dialer.Dial("tcp", hostPort(u, "1935"))
+	case "rtmps":
+		conn, err = tls.DialWithDialer(dialer, "tcp", hostPort(u, "443"), &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %s", ErrInvalidURL, u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Publisher{
+		conn: conn,
+		cr:   newChunkReader(bufio.NewReader(conn)),
+		cw:   &chunkWriter{w: bufio.NewWriter(conn), chunkSize: defaultChunkSize},
+		done: make(chan struct{}),
+	}
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	tcURL := fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, app)
+	if err := p.publish(app, tcURL, key); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	go p.readLoop()
+	return p, nil
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
+func (p *Publisher) publish(app, tcURL, key string) error {
+	if err := p.handshake(); err != nil {
+		return fmt.Errorf("handshake: %w", err)
+	}
+
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, chunkSize)
+	if err := p.cw.write(csidControl, &message{typ: msgSetChunkSize, payload: size}); err != nil {
+		return err
+	}
+	p.cw.chunkSize = chunkSize
+
+	if _, err := p.call("connect", amf.Object{
+		{Key: "app", Value: app},
+		{Key: "type", Value: "nonprivate"},
+		{Key: "flashVer", Value: "FMLE/3.0 (compatible; FMSc/1.0)"},
+		{Key: "tcUrl", Value: tcURL},
+	}); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	// some servers want the stream to be released beforehand, their answers
+	// do not matter.
+	p.send("releaseStream", nil, key)
+	p.send("FCPublish", nil, key)
+
+	res, err := p.call("createStream", nil)
+	if err != nil {
+		return fmt.Errorf("createStream: %w", err)
+	}
+	if len(res) < 4 {
+		return fmt.Errorf("createStream: %w: no stream id", ErrRejected)
+	}
+	id, _ := res[3].(float64)
+	p.streamID = uint32(id)
+
+	payload, _ := amf.Encode("publish", 0, nil, key, "live")
+	if err := p.cw.write(csidCommand, &message{typ: msgCommandAMF0, streamID: p.streamID, payload: payload}); err != nil {
+		return err
+	}
+	return p.waitStatus("NetStream.Publish.Start")
+}
+
+// handshake performs the plain handshake, which media servers accept from
+// publishers.
+func (p *Publisher) handshake() error {
+	c0c1 := make([]byte, 1+handshakeSize)
+	c0c1[0] = 3
+	if _, err := rand.Read(c0c1[9:]); err != nil {
+		return err
+	}
+	if _, err := p.conn.Write(c0c1); err != nil {
+		return err
+	}
+
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	if _, err := io.ReadFull(p.cr.r, s0s1s2); err != nil {
+		return err
+	}
+	if s0s1s2[0] != 3 {
+		return fmt.Errorf("unsupported rtmp version %d", s0s1s2[0])
+	}
+	// C2 echoes S1.
+	_, err := p.conn.Write(s0s1s2[1 : 1+handshakeSize])
+	return err
+}
+
+// send sends a command without waiting for its result.
+func (p *Publisher) send(name string, args ...any) error {
+	p.txID++
+	payload, err := amf.Encode(append([]any{name, p.txID}, args...)...)
+	if err != nil {
+		return err
+	}
+	return p.cw.write(csidCommand, &message{typ: msgCommandAMF0, payload: payload})
+}
+
+// call sends a command and waits for its result.
+func (p *Publisher) call(name string, args ...any) ([]any, error) {
+	if err := p.send(name, args...); err != nil {
+		return nil, err
+	}
+	txID := float64(p.txID)
+	for {
+		values, err := p.readCommand()
+		if err != nil {
+			return nil, err
+		}
+		if len(values) < 2 || values[1] != txID {
+			continue
+		}
+		switch values[0] {
+		case "_result":
+			return values, nil
+		case "_error":
+			return nil, fmt.Errorf("%w: %s", ErrRejected, description(values))
+		}
+	}
+}
+
+func (p *Publisher) waitStatus(code string) error {
+	for {
+		values, err := p.readCommand()
+		if err != nil {
+			return err
+		}
+		if len(values) < 4 || values[0] != "onStatus" {
+			continue
+		}
+		info, _ := values[3].(map[string]any)
+		switch info["code"] {
+		case code:
+			return nil
+		default:
+			if info["level"] == "error" {
+				return fmt.Errorf("%w: %v %s", ErrRejected, info["code"], description(values))
+			}
+		}
+	}
+}
+
+// readCommand reads messages until a command comes in.
+func (p *Publisher) readCommand() ([]any, error) {
+	for {
+		m, err := p.cr.read()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.handle(m); err != nil {
+			return nil, err
+		}
+		if m.typ != msgCommandAMF0 {
+			continue
+		}
+		values, err := amf.Decode(m.payload)
+		if err != nil || len(values) == 0 {
+			continue
+		}
+		return values, nil
+	}
+}
+
+// handle answers the protocol control messages which need it.
+func (p *Publisher) handle(m *message) error {
+	const pingRequest, pingResponse = 6, 7
+	if m.typ != msgUserControl || len(m.payload) < 6 || binary.BigEndian.Uint16(m.payload) != pingRequest {
+		return nil
+	}
+	payload := make([]byte, 6)
+	binary.BigEndian.PutUint16(payload, pingResponse)
+	copy(payload[2:], m.payload[2:6])
+	return p.write(csidControl, &message{typ: msgUserControl, payload: payload})
+}
+
+func description(values []any) string {
+	for _, v := range values {
+		if info, ok := v.(map[string]any); ok {
+			if d, ok := info["description"].(string); ok {
+				return d
+			}
+		}
+	}
+	return ""
+}
+
+func (p *Publisher) readLoop() {
+	for {
+		m, err := p.cr.read()
+		if err == nil {
+			err = p.handle(m)
+		}
+		if err != nil {
+			p.closeOnce.Do(func() {
+				p.err = err
+				close(p.done)
+			})
+			return
+		}
+	}
+}
+
+func (p *Publisher) write(csid byte, m *message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return p.cw.write(csid, m)
+}
+
+// WriteTag publishes the body of a flv tag, without its trailing previous
+// tag size.
+func (p *Publisher) WriteTag(typ byte, timestamp uint32, data []byte) error {
+	select {
+	case <-p.done:
+		return fmt.Errorf("connection closed: %w", p.err)
+	default:
+	}
+
+	m := &message{typ: typ, streamID: p.streamID, timestamp: timestamp, payload: data}
+	var csid byte
+	switch typ {
+	case tagAudio:
+		csid = csidAudio
+	case tagVideo:
+		csid = csidVideo
+	case tagScript:
+		csid = csidData
+		m.typ = msgDataAMF0
+		prefix, _ := amf.Encode("@setDataFrame")
+		m.payload = append(prefix, data...)
+	default:
+		return fmt.Errorf("unsupported tag type %d", typ)
+	}
+	return p.write(csid, m)
+}
+
+// Done is closed when the connection is lost.
+func (p *Publisher) Done() <-chan struct{} {
+	return p.done
+}
+
+// Close stops publishing and closes the connection.
+func (p *Publisher) Close() error {
+	p.closeOnce.Do(func() {
+		p.err = net.ErrClosed
+		close(p.done)
+	})
+	if payload, err := amf.Encode("deleteStream", 0, nil, p.streamID); err == nil {
+		p.write(csidCommand, &message{typ: msgCommandAMF0, payload: payload})
+	}
+	return p.conn.Close()
+}
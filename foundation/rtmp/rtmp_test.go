@@ -0,0 +1,137 @@
+package rtmp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/foundation/amf"
+)
+
+// server is a stand-in RTMP server accepting a single publisher.
+type server struct {
+	ln       net.Listener
+	app      string
+	key      string
+	messages chan *message
+}
+
+func newServer(t *testing.T) *server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{ln: ln, messages: make(chan *message, 16)}
+	go s.serve(t)
+	return s
+}
+
+func (s *server) serve(t *testing.T) {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer close(s.messages)
+
+	br := bufio.NewReader(conn)
+	c0c1 := make([]byte, 1+handshakeSize)
+	if _, err := io.ReadFull(br, c0c1); err != nil {
+		t.Error(err)
+		return
+	}
+	s0s1s2 := append([]byte{3}, make([]byte, handshakeSize)...)
+	s0s1s2 = append(s0s1s2, c0c1[1:]...)
+	conn.Write(s0s1s2)
+	if _, err := io.ReadFull(br, make([]byte, handshakeSize)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cr := newChunkReader(br)
+	cw := &chunkWriter{w: bufio.NewWriter(conn), chunkSize: defaultChunkSize}
+	reply := func(streamID uint32, values ...any) {
+		payload, _ := amf.Encode(values...)
+		cw.write(csidCommand, &message{typ: msgCommandAMF0, streamID: streamID, payload: payload})
+	}
+	for {
+		m, err := cr.read()
+		if err != nil {
+			return
+		}
+		if m.typ != msgCommandAMF0 {
+			if m.typ != msgSetChunkSize {
+				s.messages <- m
+			}
+			continue
+		}
+
+		values, _ := amf.Decode(m.payload)
+		switch values[0] {
+		case "connect":
+			s.app, _ = values[2].(map[string]any)["app"].(string)
+			reply(0, "_result", values[1], nil, amf.Object{{Key: "code", Value: "NetConnection.Connect.Success"}})
+		case "createStream":
+			reply(0, "_result", values[1], nil, 1)
+		case "publish":
+			s.key, _ = values[3].(string)
+			reply(1, "onStatus", 0, nil, amf.Object{
+				{Key: "level", Value: "status"},
+				{Key: "code", Value: "NetStream.Publish.Start"},
+			})
+		}
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	s := newServer(t)
+	defer s.ln.Close()
+
+	p, err := Dial("rtmp://"+s.ln.Addr().String()+"/live/room?token=abc", 5*time.Second)
+	if err != nil {
+		t.Fatalf("Should be able to publish: %s", err)
+	}
+	if s.app != "live" || s.key != "room?token=abc" {
+		t.Errorf("Should publish room?token=abc on live, got %s on %s", s.key, s.app)
+	}
+
+	meta, _ := amf.Encode("onMetaData", amf.ECMAArray{{Key: "duration", Value: 0}})
+	video := bytes.Repeat([]byte{0x17, 0x01, 0xaa}, 5000)
+	tags := []struct {
+		typ  byte
+		ts   uint32
+		data []byte
+	}{
+		{tagScript, 0, meta},
+		{tagVideo, 40, video},
+		{tagAudio, 0x1000000, []byte{0xaf, 0x01, 0xbb}},
+	}
+	for _, tag := range tags {
+		if err := p.WriteTag(tag.typ, tag.ts, tag.data); err != nil {
+			t.Fatalf("Should be able to write the tag: %s", err)
+		}
+	}
+	p.Close()
+
+	var got []*message
+	for m := range s.messages {
+		got = append(got, m)
+	}
+	if len(got) != len(tags) {
+		t.Fatalf("Should receive %d messages, got %d", len(tags), len(got))
+	}
+
+	values, _ := amf.Decode(got[0].payload)
+	if got[0].typ != msgDataAMF0 || len(values) != 3 || values[0] != "@setDataFrame" || values[1] != "onMetaData" {
+		t.Errorf("Should receive the metadata, got %v", values)
+	}
+	for i, tag := range tags[1:] {
+		m := got[i+1]
+		if m.typ != tag.typ || m.timestamp != tag.ts || m.streamID != 1 || !bytes.Equal(m.payload, tag.data) {
+			t.Errorf("Should receive tag %d unchanged, got type %d ts %d stream %d with %d bytes", i+1, m.typ, m.timestamp, m.streamID, len(m.payload))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cron is a standard five fields expression: minute, hour, day of month,
+// month and day of week. Fields take *, lists, ranges and steps.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are set for *, the day matching either restricted
+	// day field otherwise.
+	domAny, dowAny bool
+}
+
+func parseCron(expr string) (*cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron[%s] does not have 5 fields", expr)
+	}
+
+	var (
+		c   cron
+		err error
+	)
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// 7 is Sunday too.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return &c, nil
+}
+
+// parseField returns the bitset of the values the field matches.
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("cron field[%s] has an invalid step", field)
+			}
+			rng = part[:i]
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			var err error
+			from, to, isRange := strings.Cut(rng, "-")
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("cron field[%s] is invalid", field)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("cron field[%s] is invalid", field)
+				}
+			} else if step > 1 {
+				// a/n runs from a up to the max.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("cron field[%s] is not within [%d, %d]", field, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cron) match(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 ||
+		c.hour&(1<<uint(t.Hour())) == 0 ||
+		c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,185 @@
+// Package schedule tells whether a time falls within recording windows, given
+// as cron expressions or weekday time ranges in a named timezone.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // timezones for hosts without a zoneinfo database
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// What to do outside the windows.
+const (
+	OutsideSlow = "slow"
+	OutsideStop = "stop"
+)
+
+// maxCronDuration bounds the length of a cron window, which is looked back
+// minute by minute.
+const maxCronDuration = 7 * 24 * time.Hour
+
+// Schedule is a set of windows, a time within any of them is active.
+type Schedule struct {
+	// Timezone is an IANA name, e.g. Asia/Shanghai. Local time by default.
+	Timezone string
+	Windows  []Window
+	// Outside is slow to keep polling slowly outside the windows, stop not
+	// to poll at all.
+	Outside string
+	// FastRestSeconds and SlowRestSeconds are the polling intervals inside
+	// and outside the windows, zero meaning the global one.
+	FastRestSeconds uint
+	SlowRestSeconds uint
+	// StopOnClose stops the recording when its window closes, and keeps the
+	// show from recording outside the windows.
+	StopOnClose bool
+
+	loc *time.Location
+}
+
+// Window is either a cron expression starting windows of the given
+// duration, or a daily time range on the given weekdays.
+type Window struct {
+	Cron     string
+	Duration string
+
+	// Weekdays are 0 for Sunday to 6 for Saturday, every day if empty.
+	Weekdays []time.Weekday
+	// Start and End are HH:MM, an End before Start runs past midnight.
+	Start string
+	End   string
+
+	cron     *cron
+	duration time.Duration
+	start    int
+	end      int
+}
+
+// Parse parses a schedule from its JSON form.
+func Parse(str string) (*Schedule, error) {
+	var s Schedule
+	if err := jsoniter.UnmarshalFromString(str, &s); err != nil {
+		return nil, err
+	}
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schedule) init() error {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return err
+	}
+	s.loc = loc
+
+	switch s.Outside {
+	case "":
+		s.Outside = OutsideSlow
+	case OutsideSlow, OutsideStop:
+	default:
+		return fmt.Errorf("outside[%s] is neither %s nor %s", s.Outside, OutsideSlow, OutsideStop)
+	}
+
+	if len(s.Windows) == 0 {
+		return errors.New("no window")
+	}
+	for i := range s.Windows {
+		if err := s.Windows[i].init(); err != nil {
+			return fmt.Errorf("window %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (w *Window) init() error {
+	if w.Cron != "" {
+		c, err := parseCron(w.Cron)
+		if err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return err
+		}
+		if d <= 0 || d > maxCronDuration {
+			return fmt.Errorf("duration[%s] is not within (0, %s]", w.Duration, maxCronDuration)
+		}
+		w.cron, w.duration = c, d
+		return nil
+	}
+
+	var err error
+	if w.start, err = parseClock(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = parseClock(w.End); err != nil {
+		return err
+	}
+	for _, d := range w.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("weekday[%d] is not within [0, 6]", d)
+		}
+	}
+	return nil
+}
+
+// parseClock returns the minutes past midnight of HH:MM.
+func parseClock(str string) (int, error) {
+	t, err := time.Parse("15:04", str)
+	if err != nil {
+		return 0, fmt.Errorf("time[%s] is not HH:MM", str)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Active reports whether t falls within a window.
+func (s *Schedule) Active(t time.Time) bool {
+	t = t.In(s.loc)
+	for i := range s.Windows {
+		if s.Windows[i].active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Window) active(t time.Time) bool {
+	if w.cron != nil {
+		// the window is active if it started within the duration.
+		start := t.Truncate(time.Minute)
+		for m := start; t.Sub(m) < w.duration; m = m.Add(-time.Minute) {
+			if w.cron.match(m) {
+				return true
+			}
+		}
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start <= w.end {
+		return w.onDay(day) && now >= w.start && now < w.end
+	}
+	// the range runs past midnight, belonging to the day it starts on.
+	if now >= w.start {
+		return w.onDay(day)
+	}
+	return now < w.end && w.onDay((day+6)%7)
+}
+
+func (w *Window) onDay(d time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, wd := range w.Weekdays {
+		if wd == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/foundation/schedule"
+)
+
+func TestActive(t *testing.T) {
+	s, err := schedule.Parse(`{
+		"Timezone": "Asia/Shanghai",
+		"Windows": [
+			{"Cron": "30 20 * * 5", "Duration": "2h"},
+			{"Weekdays": [6], "Start": "23:00", "End": "01:00"}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("Should be able to parse the schedule: %s", err)
+	}
+	if s.Outside != schedule.OutsideSlow {
+		t.Errorf("Should poll slowly outside the windows by default, got %s", s.Outside)
+	}
+
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	tests := []struct {
+		time   string
+		active bool
+	}{
+		{"2022-07-01 20:29", false}, // Friday
+		{"2022-07-01 20:30", true},
+		{"2022-07-01 22:29", true},
+		{"2022-07-01 22:30", false},
+		{"2022-07-02 20:30", false}, // Saturday
+		{"2022-07-02 23:30", true},
+		{"2022-07-03 00:59", true}, // Sunday, past midnight
+		{"2022-07-03 01:00", false},
+		{"2022-07-03 23:30", false},
+	}
+	for _, tt := range tests {
+		at, _ := time.ParseInLocation("2006-01-02 15:04", tt.time, loc)
+		if got := s.Active(at.UTC()); got != tt.active {
+			t.Errorf("Should be active %v at %s, got %v", tt.active, tt.time, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, str := range []string{
+		`{"Windows": []}`,
+		`{"Timezone": "Nowhere/City", "Windows": [{"Start": "10:00", "End": "11:00"}]}`,
+		`{"Windows": [{"Cron": "* * *", "Duration": "1h"}]}`,
+		`{"Windows": [{"Cron": "61 * * * *", "Duration": "1h"}]}`,
+		`{"Windows": [{"Cron": "0 20 * * *"}]}`,
+		`{"Windows": [{"Start": "25:00", "End": "11:00"}]}`,
+		`{"Outside": "never", "Windows": [{"Start": "10:00", "End": "11:00"}]}`,
+	} {
+		if _, err := schedule.Parse(str); err == nil {
+			t.Errorf("Should reject %s", str)
+		}
+	}
+}
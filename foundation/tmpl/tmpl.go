@@ -0,0 +1,58 @@
+// Package tmpl renders the file name and save dir templates of recordings.
+package tmpl
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig"
+	"github.com/go-dora/filenamify"
+)
+
+// funcs are the functions the templates may call.
+var funcs = sprig.TxtFuncMap()
+
+// Info is what the templates are rendered with.
+type Info struct {
+	ShowID       string    `json:"show_id"`
+	Platform     string    `json:"platform"`
+	SiteName     string    `json:"site_name"`
+	RoomID       string    `json:"room_id"`
+	StreamerName string    `json:"streamer_name"`
+	RoomName     string    `json:"room_name"`
+	Category     string    `json:"category"`
+	SessionID    string    `json:"session_id"`
+	SessionStart time.Time `json:"session_start"`
+	SegmentIndex int       `json:"segment_index"`
+	// Quality is "audio" for audio only shows, the ffmpeg profile when one is
+	// selected, "source" otherwise.
+	Quality string `json:"quality"`
+	Parser  string `json:"parser"`
+}
+
+// RenderOutTmpl renders the file name template, made safe as a file name.
+func RenderOutTmpl(text string, info Info) (string, error) {
+	out, err := render("user_defined_filename", text, info)
+	if err != nil {
+		return "", err
+	}
+	return filenamify.FilenamifyMustCompile(out), nil
+}
+
+// RenderSaveDir renders the save dir template.
+func RenderSaveDir(text string, info Info) (string, error) {
+	return render("user_defined_savedir_tmpl", text, info)
+}
+
+func render(name, text string, info Info) (string, error) {
+	t, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, info); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,37 @@
+package tmpl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/foundation/tmpl"
+)
+
+func TestRenderOutTmpl(t *testing.T) {
+	info := tmpl.Info{
+		ShowID:       "a",
+		Platform:     "bilibili",
+		RoomID:       "1",
+		StreamerName: "olive",
+		RoomName:     "live/now",
+		Category:     "music",
+		SessionStart: time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC),
+		SegmentIndex: 2,
+		Quality:      "source",
+		Parser:       "flv",
+	}
+	out, err := tmpl.RenderOutTmpl(`{{ .Platform }}-{{ .RoomID }}-{{ .RoomName }}-{{ .Category }}-{{ .SessionStart.Format "20060102" }}-{{ .SegmentIndex }}-{{ .Quality }}-{{ .Parser }}.flv`, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the slash of the room name is not kept in a file name.
+	if want := "bilibili-1-live_now-music-20220501-2-source-flv.flv"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	for _, text := range []string{`{{ .StreamerName `, `{{ .Unknown }}`} {
+		if _, err := tmpl.RenderOutTmpl(text, info); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// streamWriteTimeout is how long a write of a stream waits for a client
+// which stopped reading.
+const streamWriteTimeout = 10 * time.Second
+
+// Stream takes the connection over to stream a response for as long as the
+// client reads it, the server write timeout would cut the stream otherwise.
+// The header, status line included, is to be written first to the returned
+// writer, which gives up on clients which stop reading. The connection is
+// to be closed once done.
+func Stream(ctx context.Context, w http.ResponseWriter) (net.Conn, io.Writer, error) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("streaming needs a hijackable connection")
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("hijack: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	SetStatusCode(ctx, http.StatusOK)
+
+	return conn, deadlineWriter{conn}, nil
+}
+
+// deadlineWriter gives up on clients which stop reading.
+type deadlineWriter struct {
+	conn net.Conn
+}
+
+func (w deadlineWriter) Write(p []byte) (int, error) {
+	w.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return w.conn.Write(p)
+}
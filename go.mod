@@ -7,7 +7,6 @@ require (
 	github.com/Masterminds/sprig v2.22.0+incompatible
 	github.com/ardanlabs/conf/v3 v3.1.2
 	github.com/ardanlabs/darwin v1.3.0
-	github.com/coocood/freecache v1.2.2
 	github.com/dimfeld/httptreemux/v5 v5.4.0
 	github.com/fsnotify/fsnotify v1.5.4
 	github.com/funny/slab v0.0.0-20180511031532-b1fad5e5d478
@@ -23,7 +22,6 @@ require (
 	github.com/jmoiron/sqlx v1.3.5
 	github.com/json-iterator/go v1.1.12
 	github.com/lib/pq v1.10.7
-	github.com/lthibault/jitterbug/v2 v2.2.2
 	github.com/mdp/qrterminal/v3 v3.0.0
 	github.com/pelletier/go-toml/v2 v2.0.5
 	github.com/rifflock/lfshook v0.0.0-20180920164130-b9218ef580f5
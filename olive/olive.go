@@ -0,0 +1,197 @@
+// Package olive embeds the recording engine in other programs. Each Olive is
+// independent of the others, so several of them may run in one process.
+//
+//	o, err := olive.New(olive.WithConfig(cfg), olive.WithLogger(log))
+//	if err != nil {
+//		return err
+//	}
+//	events := o.Subscribe()
+//	o.AddShow(olive.Show{Platform: "bilibili", RoomID: "21852"})
+//	go o.Run(ctx)
+//	for e := range events {
+//		...
+//	}
+package olive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/engine/events"
+	"github.com/go-olive/olive/engine/kernel"
+	"github.com/go-olive/olive/foundation/olivetv"
+	"github.com/sirupsen/logrus"
+)
+
+// Show is the configuration of a show, see kernel.Show. Shows without an
+// ID are given one.
+type Show = kernel.Show
+
+// Status is the runtime status of a show.
+type Status = kernel.Status
+
+// Event is something which happened to a show, see the events package for
+// the types.
+type Event = events.Event
+
+// subscribeSize is the number of events a subscriber may lag behind before
+// events are dropped.
+const subscribeSize = 256
+
+// shutdownTimeout bounds the wait for the recorders to stop once the context
+// of Run is done.
+const shutdownTimeout = 10 * time.Second
+
+// ErrRunning is returned by Run when the engine is already running or has
+// run.
+var ErrRunning = errors.New("olive is already running")
+
+// Option configures an Olive.
+type Option func(*options)
+
+type options struct {
+	log   *logrus.Logger
+	cfg   *config.Config
+	shows []Show
+}
+
+// WithLogger sets the logger, logs are discarded by default.
+func WithLogger(log *logrus.Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+// WithConfig sets the engine configuration, the zero fields taking the
+// defaults. The engine works on a copy, leaving cfg as it is.
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		c := *cfg
+		o.cfg = &c
+	}
+}
+
+// WithShows adds the shows to record once running.
+func WithShows(shows ...Show) Option {
+	return func(o *options) {
+		o.shows = append(o.shows, shows...)
+	}
+}
+
+// Olive is a recording engine.
+type Olive struct {
+	k   *kernel.Kernel
+	cfg *config.Config
+
+	mu      sync.Mutex
+	running bool
+}
+
+// New returns an engine, which starts recording once Run is called.
+func New(opts ...Option) (*Olive, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.log == nil {
+		o.log = logrus.New()
+		o.log.SetOutput(io.Discard)
+	}
+	if o.cfg == nil {
+		o.cfg = new(config.Config)
+	}
+	o.cfg.CheckAndFix()
+
+	shows := make([]Show, 0, len(o.shows))
+	for _, show := range o.shows {
+		if err := check(show); err != nil {
+			return nil, err
+		}
+		show.Enable = true
+		show.CheckAndFix(o.cfg)
+		shows = append(shows, show)
+	}
+
+	return &Olive{
+		k:   kernel.New(o.log, o.cfg, shows),
+		cfg: o.cfg,
+	}, nil
+}
+
+// Run records the shows until the context is done, then stops the recorders
+// and returns. An Olive runs once.
+func (o *Olive) Run(ctx context.Context) error {
+	o.mu.Lock()
+	if o.running {
+		o.mu.Unlock()
+		return ErrRunning
+	}
+	o.running = true
+	o.mu.Unlock()
+
+	o.k.Run()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	go o.k.Shutdown(shutdownCtx)
+
+	select {
+	case <-o.k.Done():
+		return nil
+	case <-shutdownCtx.Done():
+		return errors.New("timeout stopping the recorders")
+	}
+}
+
+// AddShow adds or updates a show, which is monitored right away, returning it
+// with its ID.
+func (o *Olive) AddShow(show Show) (Show, error) {
+	if err := check(show); err != nil {
+		return Show{}, err
+	}
+	show.Enable = true
+	show.CheckAndFix(o.cfg)
+	o.k.HandleShow(show)
+	return show, nil
+}
+
+// RemoveShow stops monitoring and recording a show.
+func (o *Olive) RemoveShow(id string) {
+	o.k.HandleShow(Show{ID: id, Enable: false})
+}
+
+// Subscribe returns a channel of the events of the shows from now on. Events
+// are dropped while the subscriber lags behind. The channel is closed once
+// the engine stops.
+func (o *Olive) Subscribe() <-chan Event {
+	ch, _ := o.k.Subscribe(subscribeSize)
+	return ch
+}
+
+// Status returns the runtime status of every show.
+func (o *Olive) Status() []Status {
+	return o.k.Status()
+}
+
+// Kernel returns the underlying kernel, for the controls this package does
+// not wrap.
+func (o *Olive) Kernel() *kernel.Kernel {
+	return o.k
+}
+
+func check(show Show) error {
+	if show.RoomID == "" {
+		return errors.New("show needs a room id")
+	}
+	if _, ok := olivetv.Sniff(show.Platform); !ok {
+		return fmt.Errorf("platform[%s] is not supported", show.Platform)
+	}
+	return nil
+}
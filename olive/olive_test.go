@@ -0,0 +1,71 @@
+package olive_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-olive/olive/engine/config"
+	"github.com/go-olive/olive/olive"
+)
+
+func TestRunIndependently(t *testing.T) {
+	var engines []*olive.Olive
+	for i := 0; i < 2; i++ {
+		cfg := &config.Config{
+			LogDir:  t.TempDir(),
+			SaveDir: t.TempDir(),
+		}
+		o, err := olive.New(olive.WithConfig(cfg))
+		if err != nil {
+			t.Fatalf("Should be able to create an engine: %s", err)
+		}
+		if cfg.SnapRestSeconds != 0 {
+			t.Error("Should leave the config given as it is")
+		}
+		engines = append(engines, o)
+	}
+
+	if _, err := engines[0].AddShow(olive.Show{Platform: "nowhere", RoomID: "1"}); err == nil {
+		t.Error("Should reject a show of an unknown platform")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subs := make([]<-chan olive.Event, len(engines))
+	errs := make(chan error, len(engines)+1)
+	for i, o := range engines {
+		subs[i] = o.Subscribe()
+		go func(o *olive.Olive) {
+			errs <- o.Run(ctx)
+		}(o)
+	}
+	// whichever run of the first engine comes second fails at once.
+	go func() {
+		errs <- engines[0].Run(ctx)
+	}()
+	select {
+	case err := <-errs:
+		if err != olive.ErrRunning {
+			t.Errorf("Should not run twice, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Should not run twice")
+	}
+	cancel()
+
+	for range engines {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("Should stop cleanly: %s", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Should stop once the context is done")
+		}
+	}
+	for _, sub := range subs {
+		if _, ok := <-sub; ok {
+			t.Error("Should close the subscriptions once stopped")
+		}
+	}
+}